@@ -37,9 +37,64 @@ type appConfig struct {
 	Logs map[string]string `mapstructure:"logs"`
 
 	Obyte map[string]string `mapstructure:"obyte"`
+	// the backend settling the trades: OBYTE talks to the Obyte node, SIMULATOR settles the trades
+	// in memory without node. Defaults to OBYTE
+	SettlementBackend string `mapstructure:"settlement_backend"`
+	// the operator address returned by the simulator. Defaults to SIMULATEDOPERATORADDRESS00000000
+	SimulatorOperatorAddress string `mapstructure:"simulator_operator_address"`
+	// the probability that the simulator bounces a trade. Defaults to 0
+	SimulatorBounceRate float64 `mapstructure:"simulator_bounce_rate"`
+	// the number of milliseconds before the simulator responds to a trigger. Defaults to 1000
+	SimulatorResponseDelay int `mapstructure:"simulator_response_delay"`
+	// the amount of every token the simulator credits to a new address. Defaults to 0
+	SimulatorInitialBalance int64 `mapstructure:"simulator_initial_balance"`
 
 	Env string `mapstructure:"env"`
 
+	// the self-trade prevention mode of the matching engine: CANCEL_NEWEST, CANCEL_OLDEST,
+	// CANCEL_BOTH, DECREMENT or NONE. Defaults to NONE
+	SelfTradePrevention string `mapstructure:"self_trade_prevention"`
+	// the number of journaled inputs between two snapshots of an orderbook. Defaults to 1000
+	EngineSnapshotInterval int64 `mapstructure:"engine_snapshot_interval"`
+
+	// the number of transaction queues settling the trades in parallel. Defaults to 1
+	TxQueues int `mapstructure:"tx_queues"`
+	// the routing of the trades to the transaction queues: ADDRESS keeps the trades of a user
+	// on the same queue, SHORTEST picks the shortest queue. Defaults to ADDRESS
+	TxQueueRouting string `mapstructure:"tx_queue_routing"`
+	// the number of seconds after which a trade not yet committed is checked against the
+	// Obyte node. Defaults to 600
+	SettlementTimeout int `mapstructure:"settlement_timeout"`
+	// the number of seconds between two scans of the unsettled trades. Defaults to 60
+	SettlementReconciliationInterval int `mapstructure:"settlement_reconciliation_interval"`
+	// the number of attempts to send a trade to the node before its matches are dead-lettered.
+	// Defaults to 5
+	TradeExecutionAttempts int `mapstructure:"trade_execution_attempts"`
+	// the number of seconds before the first retry of a trade execution, doubled after each
+	// failed attempt. Defaults to 1
+	TradeExecutionRetryDelay int `mapstructure:"trade_execution_retry_delay"`
+	// the maximum number of matches settled by a single trigger of the exchange AA. Defaults to 1,
+	// which sends every matches on its own
+	TradeBatchSize int `mapstructure:"trade_batch_size"`
+	// the number of milliseconds a TX queue waits for more matches to fill a batch. Defaults to 100
+	TradeBatchWindow int `mapstructure:"trade_batch_window"`
+
+	// the token expected in the Authorization header of the admin endpoints. The admin
+	// endpoints are disabled when it is empty
+	AdminToken string `mapstructure:"admin_token"`
+
+	// the number of seconds after a login during which its session id can be exchanged for an
+	// API key. Defaults to 600
+	APIKeyLoginTimeout int `mapstructure:"api_key_login_timeout"`
+	// the number of seconds by which the timestamp of a request to the private API can differ
+	// from the server time. Defaults to 30
+	APIRequestWindow int `mapstructure:"api_request_window"`
+
+	// the number of seconds without heartbeat after which the orders of a connection that enabled
+	// cancel-on-disconnect are auto-cancelled, when the connection doesn't choose its own timeout.
+	// Defaults to 30
+	CancelOnDisconnectTimeout int `mapstructure:"cancel_on_disconnect_timeout"`
+
 	EnableTLS    bool   `mapstructure:"enable_tls"`
 	ServerCACert string `mapstructure:"server_ca_cert"`
 	ServerCert   string `mapstructure:"server_cert"`
@@ -53,6 +108,8 @@ type appConfig struct {
 func (config appConfig) Validate() error {
 	return validation.ValidateStruct(&config,
 		validation.Field(&config.MongoURL, validation.Required),
+		validation.Field(&config.SelfTradePrevention, validation.In("CANCEL_NEWEST", "CANCEL_OLDEST", "CANCEL_BOTH", "DECREMENT", "NONE")),
+		validation.Field(&config.SettlementBackend, validation.In("OBYTE", "SIMULATOR")),
 	)
 }
 
@@ -126,13 +183,102 @@ func LoadConfig(configPath string, env string) error {
 		Config.MongoDBShardURL3 = ""
 	}
 
+	//Matching engine Configuration
+	Config.SelfTradePrevention = v.GetString("SELF_TRADE_PREVENTION")
+	if Config.SelfTradePrevention == "" {
+		Config.SelfTradePrevention = "NONE"
+	}
+
+	Config.EngineSnapshotInterval = v.GetInt64("ENGINE_SNAPSHOT_INTERVAL")
+	if Config.EngineSnapshotInterval <= 0 {
+		Config.EngineSnapshotInterval = 1000
+	}
+
+	//Operator Configuration
+	Config.TxQueues = v.GetInt("TX_QUEUES")
+	if Config.TxQueues <= 0 {
+		Config.TxQueues = 1
+	}
+
+	Config.TxQueueRouting = v.GetString("TX_QUEUE_ROUTING")
+	if Config.TxQueueRouting == "" {
+		Config.TxQueueRouting = "ADDRESS"
+	}
+
+	Config.SettlementTimeout = v.GetInt("SETTLEMENT_TIMEOUT")
+	if Config.SettlementTimeout <= 0 {
+		Config.SettlementTimeout = 600
+	}
+
+	Config.SettlementReconciliationInterval = v.GetInt("SETTLEMENT_RECONCILIATION_INTERVAL")
+	if Config.SettlementReconciliationInterval <= 0 {
+		Config.SettlementReconciliationInterval = 60
+	}
+
+	Config.TradeExecutionAttempts = v.GetInt("TRADE_EXECUTION_ATTEMPTS")
+	if Config.TradeExecutionAttempts <= 0 {
+		Config.TradeExecutionAttempts = 5
+	}
+
+	Config.TradeExecutionRetryDelay = v.GetInt("TRADE_EXECUTION_RETRY_DELAY")
+	if Config.TradeExecutionRetryDelay <= 0 {
+		Config.TradeExecutionRetryDelay = 1
+	}
+
+	Config.TradeBatchSize = v.GetInt("TRADE_BATCH_SIZE")
+	if Config.TradeBatchSize <= 0 {
+		Config.TradeBatchSize = 1
+	}
+
+	Config.TradeBatchWindow = v.GetInt("TRADE_BATCH_WINDOW")
+	if Config.TradeBatchWindow <= 0 {
+		Config.TradeBatchWindow = 100
+	}
+
+	Config.AdminToken = v.GetString("ADMIN_TOKEN")
+
+	Config.APIKeyLoginTimeout = v.GetInt("API_KEY_LOGIN_TIMEOUT")
+	if Config.APIKeyLoginTimeout <= 0 {
+		Config.APIKeyLoginTimeout = 600
+	}
+
+	Config.APIRequestWindow = v.GetInt("API_REQUEST_WINDOW")
+	if Config.APIRequestWindow <= 0 {
+		Config.APIRequestWindow = 30
+	}
+
+	Config.CancelOnDisconnectTimeout = v.GetInt("CANCEL_ON_DISCONNECT_TIMEOUT")
+	if Config.CancelOnDisconnectTimeout <= 0 {
+		Config.CancelOnDisconnectTimeout = 30
+	}
+
 	Config.Obyte = make(map[string]string)
 	Config.Obyte["http_url"] = v.Get("OBYTE_NODE_HTTP_URL").(string)
 	Config.Obyte["ws_url"] = v.Get("OBYTE_NODE_WS_URL").(string)
 
+	Config.SettlementBackend = v.GetString("SETTLEMENT_BACKEND")
+	if Config.SettlementBackend == "" {
+		Config.SettlementBackend = "OBYTE"
+	}
+
+	Config.SimulatorOperatorAddress = v.GetString("SIMULATOR_OPERATOR_ADDRESS")
+	if Config.SimulatorOperatorAddress == "" {
+		Config.SimulatorOperatorAddress = "SIMULATEDOPERATORADDRESS00000000"
+	}
+
+	Config.SimulatorBounceRate = v.GetFloat64("SIMULATOR_BOUNCE_RATE")
+
+	Config.SimulatorResponseDelay = v.GetInt("SIMULATOR_RESPONSE_DELAY")
+	if Config.SimulatorResponseDelay <= 0 {
+		Config.SimulatorResponseDelay = 1000
+	}
+
+	Config.SimulatorInitialBalance = v.GetInt64("SIMULATOR_INITIAL_BALANCE")
+
 	logger.Infof("Server port: %v", Config.ServerPort)
 	logger.Infof("Obyte node HTTP url: %v", Config.Obyte["http_url"])
 	logger.Infof("Obyte node WS url: %v", Config.Obyte["ws_url"])
+	logger.Infof("Settlement backend: %v", Config.SettlementBackend)
 	logger.Infof("MongoDB url: %v", Config.MongoURL)
 	logger.Infof("MongoDB db name: %v", Config.DBName)
 	logger.Infof("MongoUserName: %v", Config.MongoDBUsername)
@@ -142,6 +288,15 @@ func LoadConfig(configPath string, env string) error {
 	logger.Infof("RabbitMQ url: %v", Config.RabbitMQURL)
 	logger.Infof("RabbitMQUserName: %v", Config.RabbitMQUsername)
 	logger.Infof("TLS Enabled: %v", Config.EnableTLS)
+	logger.Infof("Self-trade prevention: %v", Config.SelfTradePrevention)
+	logger.Infof("Engine snapshot interval: %v", Config.EngineSnapshotInterval)
+	logger.Infof("Transaction queues: %v (%v routing)", Config.TxQueues, Config.TxQueueRouting)
+	logger.Infof("Settlement timeout: %vs (checked every %vs)", Config.SettlementTimeout, Config.SettlementReconciliationInterval)
+	logger.Infof("Trade execution attempts: %v (first retry after %vs)", Config.TradeExecutionAttempts, Config.TradeExecutionRetryDelay)
+	logger.Infof("Trade batches: up to %v matches within %vms", Config.TradeBatchSize, Config.TradeBatchWindow)
+	logger.Infof("Admin endpoints enabled: %v", Config.AdminToken != "")
+	logger.Infof("Private API: keys issued within %vs of a login, requests signed within %vs", Config.APIKeyLoginTimeout, Config.APIRequestWindow)
+	logger.Infof("Cancel-on-disconnect default timeout: %vs", Config.CancelOnDisconnectTimeout)
 
 	return Config.Validate()
 }
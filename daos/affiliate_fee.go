@@ -0,0 +1,152 @@
+package daos
+
+import (
+	"time"
+
+	"github.com/byteball/odex-backend/app"
+	"github.com/byteball/odex-backend/types"
+	mgo "github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+// AffiliateFeeDao contains:
+// collectionName: MongoDB collection name
+// dbName: name of mongodb to interact with
+type AffiliateFeeDao struct {
+	collectionName string
+	dbName         string
+}
+
+type AffiliateFeeDaoOption = func(*AffiliateFeeDao) error
+
+func AffiliateFeeDaoDBOption(dbName string) func(dao *AffiliateFeeDao) error {
+	return func(dao *AffiliateFeeDao) error {
+		dao.dbName = dbName
+		return nil
+	}
+}
+
+// NewAffiliateFeeDao returns a new instance of AffiliateFeeDao
+func NewAffiliateFeeDao(options ...AffiliateFeeDaoOption) *AffiliateFeeDao {
+	dao := &AffiliateFeeDao{}
+	dao.collectionName = "affiliate_fees"
+	dao.dbName = app.Config.DBName
+
+	for _, op := range options {
+		err := op(dao)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	index := mgo.Index{
+		Key:    []string{"tradeHash", "orderHash"},
+		Unique: true,
+	}
+
+	err := db.Session.DB(dao.dbName).C(dao.collectionName).EnsureIndex(index)
+	if err != nil {
+		panic(err)
+	}
+
+	index = mgo.Index{
+		Key: []string{"affiliateAddress", "tradedAt"},
+	}
+
+	err = db.Session.DB(dao.dbName).C(dao.collectionName).EnsureIndex(index)
+	if err != nil {
+		panic(err)
+	}
+
+	return dao
+}
+
+// Upsert records the affiliate fee of an order on a trade. Recording the same fee again has no
+// effect.
+func (dao *AffiliateFeeDao) Upsert(f *types.AffiliateFee) error {
+	q := bson.M{"tradeHash": f.TradeHash, "orderHash": f.OrderHash}
+
+	err := db.Upsert(dao.dbName, dao.collectionName, q, f)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetByAffiliate returns the fees earned by an affiliate on the trades made between two times,
+// oldest first
+func (dao *AffiliateFeeDao) GetByAffiliate(address string, from, to time.Time) ([]*types.AffiliateFee, error) {
+	res := []*types.AffiliateFee{}
+
+	q := bson.M{
+		"affiliateAddress": address,
+		"tradedAt":         bson.M{"$gte": from, "$lt": to},
+	}
+
+	sort := []string{"tradedAt"}
+	err := db.GetAndSort(dao.dbName, dao.collectionName, q, sort, 0, 0, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// GetRevenue returns the fees earned by an affiliate between two times, summed by asset over
+// each day or month depending on the given unit ("day" or "month")
+func (dao *AffiliateFeeDao) GetRevenue(address string, unit string, from, to time.Time) ([]*types.AffiliateRevenue, error) {
+	format := "%Y-%m-%d"
+	if unit == "month" {
+		format = "%Y-%m"
+	}
+
+	q := []bson.M{
+		bson.M{
+			"$match": bson.M{
+				"affiliateAddress": address,
+				"tradedAt":         bson.M{"$gte": from, "$lt": to},
+			},
+		},
+		bson.M{
+			"$group": bson.M{
+				"_id": bson.M{
+					"period": bson.M{"$dateToString": bson.M{"format": format, "date": "$tradedAt"}},
+					"asset":  "$asset",
+				},
+				"amount": bson.M{"$sum": "$amount"},
+				"trades": bson.M{"$sum": 1},
+			},
+		},
+		bson.M{
+			"$project": bson.M{
+				"_id":    0,
+				"period": "$_id.period",
+				"asset":  "$_id.asset",
+				"amount": 1,
+				"trades": 1,
+			},
+		},
+		bson.M{
+			"$sort": bson.D{
+				{Name: "period", Value: 1},
+				{Name: "asset", Value: 1},
+			},
+		},
+	}
+
+	res := []*types.AffiliateRevenue{}
+	err := db.Aggregate(dao.dbName, dao.collectionName, q, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (dao *AffiliateFeeDao) Drop() {
+	db.DropCollection(dao.dbName, dao.collectionName)
+}
@@ -0,0 +1,121 @@
+package daos
+
+import (
+	"github.com/byteball/odex-backend/app"
+	"github.com/byteball/odex-backend/types"
+	mgo "github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+// APIKeyDao contains:
+// collectionName: MongoDB collection name
+// dbName: name of mongodb to interact with
+type APIKeyDao struct {
+	collectionName string
+	dbName         string
+}
+
+type APIKeyDaoOption = func(*APIKeyDao) error
+
+func APIKeyDaoDBOption(dbName string) func(dao *APIKeyDao) error {
+	return func(dao *APIKeyDao) error {
+		dao.dbName = dbName
+		return nil
+	}
+}
+
+// NewAPIKeyDao returns a new instance of APIKeyDao
+func NewAPIKeyDao(options ...APIKeyDaoOption) *APIKeyDao {
+	dao := &APIKeyDao{}
+	dao.collectionName = "api_keys"
+	dao.dbName = app.Config.DBName
+
+	for _, op := range options {
+		err := op(dao)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	index := mgo.Index{
+		Key:    []string{"key"},
+		Unique: true,
+	}
+
+	err := db.Session.DB(dao.dbName).C(dao.collectionName).EnsureIndex(index)
+	if err != nil {
+		panic(err)
+	}
+
+	index = mgo.Index{
+		Key: []string{"address"},
+	}
+
+	err = db.Session.DB(dao.dbName).C(dao.collectionName).EnsureIndex(index)
+	if err != nil {
+		panic(err)
+	}
+
+	return dao
+}
+
+// Create records an API key
+func (dao *APIKeyDao) Create(k *types.APIKey) error {
+	k.ID = bson.NewObjectId()
+
+	err := db.Create(dao.dbName, dao.collectionName, k)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetByKey returns an API key, nil if there is none
+func (dao *APIKeyDao) GetByKey(key string) (*types.APIKey, error) {
+	res := []*types.APIKey{}
+
+	q := bson.M{"key": key}
+	err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if len(res) == 0 {
+		return nil, nil
+	}
+
+	return res[0], nil
+}
+
+// GetByAddress returns the API keys of an address, oldest first
+func (dao *APIKeyDao) GetByAddress(address string) ([]*types.APIKey, error) {
+	res := []*types.APIKey{}
+
+	q := bson.M{"address": address}
+	sort := []string{"createdAt"}
+	err := db.GetAndSort(dao.dbName, dao.collectionName, q, sort, 0, 0, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Delete removes an API key of an address
+func (dao *APIKeyDao) Delete(address string, key string) error {
+	err := db.RemoveAll(dao.dbName, dao.collectionName, bson.M{"address": address, "key": key})
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (dao *APIKeyDao) Drop() {
+	db.DropCollection(dao.dbName, dao.collectionName)
+}
@@ -0,0 +1,118 @@
+package daos
+
+import (
+	"time"
+
+	"github.com/byteball/odex-backend/app"
+	"github.com/byteball/odex-backend/types"
+	mgo "github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+// FeeScheduleDao contains:
+// collectionName: MongoDB collection name
+// dbName: name of mongodb to interact with
+type FeeScheduleDao struct {
+	collectionName string
+	dbName         string
+}
+
+type FeeScheduleDaoOption = func(*FeeScheduleDao) error
+
+func FeeScheduleDaoDBOption(dbName string) func(dao *FeeScheduleDao) error {
+	return func(dao *FeeScheduleDao) error {
+		dao.dbName = dbName
+		return nil
+	}
+}
+
+// NewFeeScheduleDao returns a new instance of FeeScheduleDao
+func NewFeeScheduleDao(options ...FeeScheduleDaoOption) *FeeScheduleDao {
+	dao := &FeeScheduleDao{}
+	dao.collectionName = "fee_schedules"
+	dao.dbName = app.Config.DBName
+
+	for _, op := range options {
+		err := op(dao)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	index := mgo.Index{
+		Key:    []string{"pairName"},
+		Unique: true,
+	}
+
+	err := db.Session.DB(dao.dbName).C(dao.collectionName).EnsureIndex(index)
+	if err != nil {
+		panic(err)
+	}
+
+	return dao
+}
+
+// Upsert creates or replaces the fee schedule of a pair
+func (dao *FeeScheduleDao) Upsert(s *types.FeeSchedule) error {
+	if err := s.Validate(); err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	s.UpdatedAt = time.Now()
+
+	err := db.Upsert(dao.dbName, dao.collectionName, bson.M{"pairName": s.PairName}, s)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetAll returns the fee schedules, the default schedule first
+func (dao *FeeScheduleDao) GetAll() ([]*types.FeeSchedule, error) {
+	res := []*types.FeeSchedule{}
+
+	sort := []string{"pairName"}
+	err := db.GetAndSort(dao.dbName, dao.collectionName, bson.M{}, sort, 0, 0, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// GetByPairName returns the fee schedule of a pair, nil if there is none
+func (dao *FeeScheduleDao) GetByPairName(pairName string) (*types.FeeSchedule, error) {
+	res := []*types.FeeSchedule{}
+
+	q := bson.M{"pairName": pairName}
+	err := db.Get(dao.dbName, dao.collectionName, q, 0, 1, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if len(res) == 0 {
+		return nil, nil
+	}
+
+	return res[0], nil
+}
+
+// DeleteByPairName removes the fee schedule of a pair
+func (dao *FeeScheduleDao) DeleteByPairName(pairName string) error {
+	err := db.RemoveAll(dao.dbName, dao.collectionName, bson.M{"pairName": pairName})
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (dao *FeeScheduleDao) Drop() {
+	db.DropCollection(dao.dbName, dao.collectionName)
+}
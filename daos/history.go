@@ -0,0 +1,40 @@
+package daos
+
+import (
+	"github.com/byteball/odex-backend/types"
+	"github.com/globalsign/mgo/bson"
+)
+
+// historySort lists the orders and trades from the newest, in the order of the history cursors
+var historySort = []string{"-createdAt", "-_id"}
+
+// historyFilters returns the conditions of a history query shared by the orders and the trades:
+// the pair, the status, the time range and the position of the cursor
+func historyFilters(q *types.HistoryQuery) []bson.M {
+	filters := []bson.M{}
+
+	if q.PairName != "" {
+		filters = append(filters, bson.M{"pairName": q.PairName})
+	}
+
+	if q.Status != "" {
+		filters = append(filters, bson.M{"status": q.Status})
+	}
+
+	if !q.From.IsZero() {
+		filters = append(filters, bson.M{"createdAt": bson.M{"$gte": q.From}})
+	}
+
+	if !q.To.IsZero() {
+		filters = append(filters, bson.M{"createdAt": bson.M{"$lt": q.To}})
+	}
+
+	if q.Cursor != nil {
+		filters = append(filters, bson.M{"$or": []bson.M{
+			{"createdAt": bson.M{"$lt": q.Cursor.CreatedAt}},
+			{"createdAt": q.Cursor.CreatedAt, "_id": bson.M{"$lt": q.Cursor.ID}},
+		}})
+	}
+
+	return filters
+}
@@ -0,0 +1,163 @@
+package daos
+
+import (
+	"time"
+
+	"github.com/byteball/odex-backend/app"
+	"github.com/byteball/odex-backend/types"
+	mgo "github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+// JournalDao contains:
+// entriesCollectionName: MongoDB collection of the engine journal entries
+// snapshotsCollectionName: MongoDB collection of the orderbook snapshots
+// dbName: name of mongodb to interact with
+type JournalDao struct {
+	entriesCollectionName   string
+	snapshotsCollectionName string
+	dbName                  string
+}
+
+type JournalDaoOption = func(*JournalDao) error
+
+func JournalDaoDBOption(dbName string) func(dao *JournalDao) error {
+	return func(dao *JournalDao) error {
+		dao.dbName = dbName
+		return nil
+	}
+}
+
+// NewJournalDao returns a new instance of JournalDao
+func NewJournalDao(options ...JournalDaoOption) *JournalDao {
+	dao := &JournalDao{}
+	dao.entriesCollectionName = "engine_journal"
+	dao.snapshotsCollectionName = "engine_snapshots"
+	dao.dbName = app.Config.DBName
+
+	for _, op := range options {
+		err := op(dao)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	index := mgo.Index{
+		Key:    []string{"pairCode", "sequence"},
+		Unique: true,
+	}
+
+	err := db.Session.DB(dao.dbName).C(dao.entriesCollectionName).EnsureIndex(index)
+	if err != nil {
+		panic(err)
+	}
+
+	err = db.Session.DB(dao.dbName).C(dao.snapshotsCollectionName).EnsureIndex(index)
+	if err != nil {
+		panic(err)
+	}
+
+	return dao
+}
+
+// AppendEntry inserts a new entry in the journal
+func (dao *JournalDao) AppendEntry(e *types.JournalEntry) error {
+	e.ID = bson.NewObjectId()
+
+	err := db.Create(dao.dbName, dao.entriesCollectionName, e)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// CompleteEntry records the results of a processed journal entry
+func (dao *JournalDao) CompleteEntry(e *types.JournalEntry) error {
+	e.Processed = true
+
+	q := bson.M{"pairCode": e.PairCode, "sequence": e.Sequence}
+	update := bson.M{"$set": bson.M{
+		"cancelledOrders": e.CancelledOrders,
+		"responses":       e.Responses,
+		"processed":       true,
+	}}
+
+	err := db.Update(dao.dbName, dao.entriesCollectionName, q, update)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetEntries returns the journal entries of a pair following the given sequence, in order
+func (dao *JournalDao) GetEntries(pairCode string, after int64) ([]*types.JournalEntry, error) {
+	res := []*types.JournalEntry{}
+
+	q := bson.M{"pairCode": pairCode, "sequence": bson.M{"$gt": after}}
+	sort := []string{"sequence"}
+	err := db.GetAndSort(dao.dbName, dao.entriesCollectionName, q, sort, 0, 0, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// GetLatestSnapshot returns the last snapshot of the orderbook of a pair, nil if there is none
+func (dao *JournalDao) GetLatestSnapshot(pairCode string) (*types.EngineSnapshot, error) {
+	res := []*types.EngineSnapshot{}
+
+	q := bson.M{"pairCode": pairCode}
+	sort := []string{"-sequence"}
+	err := db.GetAndSort(dao.dbName, dao.snapshotsCollectionName, q, sort, 0, 1, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if len(res) == 0 {
+		return nil, nil
+	}
+
+	return res[0], nil
+}
+
+// SaveSnapshot stores a snapshot of the orderbook of a pair. The previous snapshots and
+// the journal entries it covers are removed.
+func (dao *JournalDao) SaveSnapshot(s *types.EngineSnapshot) error {
+	s.ID = bson.NewObjectId()
+	s.CreatedAt = time.Now()
+
+	err := db.Create(dao.dbName, dao.snapshotsCollectionName, s)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	q := bson.M{"pairCode": s.PairCode, "sequence": bson.M{"$lt": s.Sequence}}
+	err = db.RemoveAll(dao.dbName, dao.snapshotsCollectionName, q)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	q = bson.M{"pairCode": s.PairCode, "sequence": bson.M{"$lte": s.Sequence}}
+	err = db.RemoveAll(dao.dbName, dao.entriesCollectionName, q)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// Drop drops all the journal entries and snapshots
+func (dao *JournalDao) Drop() {
+	db.DropCollection(dao.dbName, dao.entriesCollectionName)
+	db.DropCollection(dao.dbName, dao.snapshotsCollectionName)
+}
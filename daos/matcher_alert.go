@@ -0,0 +1,87 @@
+package daos
+
+import (
+	"github.com/byteball/odex-backend/app"
+	"github.com/byteball/odex-backend/types"
+	mgo "github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+)
+
+// MatcherAlertDao contains:
+// collectionName: MongoDB collection name
+// dbName: name of mongodb to interact with
+type MatcherAlertDao struct {
+	collectionName string
+	dbName         string
+}
+
+type MatcherAlertDaoOption = func(*MatcherAlertDao) error
+
+func MatcherAlertDaoDBOption(dbName string) func(dao *MatcherAlertDao) error {
+	return func(dao *MatcherAlertDao) error {
+		dao.dbName = dbName
+		return nil
+	}
+}
+
+// NewMatcherAlertDao returns a new instance of MatcherAlertDao
+func NewMatcherAlertDao(options ...MatcherAlertDaoOption) *MatcherAlertDao {
+	dao := &MatcherAlertDao{}
+	dao.collectionName = "matcher_alerts"
+	dao.dbName = app.Config.DBName
+
+	for _, op := range options {
+		err := op(dao)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	index := mgo.Index{
+		Key: []string{"matcherAddress", "-createdAt"},
+	}
+
+	err := db.Session.DB(dao.dbName).C(dao.collectionName).EnsureIndex(index)
+	if err != nil {
+		panic(err)
+	}
+
+	return dao
+}
+
+// Create records an alert
+func (dao *MatcherAlertDao) Create(a *types.MatcherAlert) error {
+	a.ID = bson.NewObjectId()
+
+	err := db.Create(dao.dbName, dao.collectionName, a)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetLatest returns the most recent alerts, about the trades of a matcher or of all the matchers
+// when the address is empty, newest first
+func (dao *MatcherAlertDao) GetLatest(matcherAddress string, limit int) ([]*types.MatcherAlert, error) {
+	res := []*types.MatcherAlert{}
+
+	q := bson.M{}
+	if matcherAddress != "" {
+		q["matcherAddress"] = matcherAddress
+	}
+
+	sort := []string{"-createdAt"}
+	err := db.GetAndSort(dao.dbName, dao.collectionName, q, sort, 0, limit, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (dao *MatcherAlertDao) Drop() {
+	db.DropCollection(dao.dbName, dao.collectionName)
+}
@@ -77,6 +77,10 @@ func NewOrderDao(opts ...OrderDaoOption) *OrderDao {
 		Key: []string{"userAddress", "baseToken", "side", "status"},
 	}
 
+	indexByUserCreatedAt := mgo.Index{
+		Key: []string{"userAddress", "-createdAt", "-_id"},
+	}
+
 	/*i7 := mgo.Index{
 		Key: []string{"side", "status"},
 	}*/
@@ -130,6 +134,11 @@ func NewOrderDao(opts ...OrderDaoOption) *OrderDao {
 		panic(err)
 	}
 
+	err = db.Session.DB(dao.dbName).C(dao.collectionName).EnsureIndex(indexByUserCreatedAt)
+	if err != nil {
+		panic(err)
+	}
+
 	return dao
 }
 
@@ -485,6 +494,7 @@ func (dao *OrderDao) GetCurrentByUserAddress(addr string, limit ...int) ([]*type
 		"status": bson.M{"$in": []string{
 			"OPEN",
 			"PARTIAL_FILLED",
+			"UNTRIGGERED",
 		},
 		},
 	}
@@ -510,6 +520,7 @@ func (dao *OrderDao) GetCurrentByUserAddressAndSignerAddress(address string, sig
 		"status": bson.M{"$in": []string{
 			"OPEN",
 			"PARTIAL_FILLED",
+			"UNTRIGGERED",
 		},
 		},
 	}
@@ -541,6 +552,7 @@ func (dao *OrderDao) GetHistoryByUserAddress(addr string, limit ...int) ([]*type
 		"status": bson.M{"$nin": []string{
 			"OPEN",
 			"PARTIAL_FILLED",
+			"UNTRIGGERED",
 		},
 		},
 	}
@@ -554,6 +566,37 @@ func (dao *OrderDao) GetHistoryByUserAddress(addr string, limit ...int) ([]*type
 	return res, nil
 }
 
+// GetPageByUserAddress returns a page of the orders of a user selected by a history query,
+// newest first
+func (dao *OrderDao) GetPageByUserAddress(q *types.HistoryQuery) ([]*types.Order, error) {
+	return dao.getPage(q, bson.M{"userAddress": q.Address})
+}
+
+// GetHistoryPageByUserAddress returns a page of the orders of a user that are no longer in the
+// orderbook, selected by a history query, newest first
+func (dao *OrderDao) GetHistoryPageByUserAddress(q *types.HistoryQuery) ([]*types.Order, error) {
+	return dao.getPage(q, bson.M{
+		"userAddress": q.Address,
+		"status":      bson.M{"$nin": []string{"OPEN", "PARTIAL_FILLED", "UNTRIGGERED"}},
+	})
+}
+
+func (dao *OrderDao) getPage(q *types.HistoryQuery, owner bson.M) ([]*types.Order, error) {
+	filters := append(historyFilters(q), owner)
+	if q.Side != "" {
+		filters = append(filters, bson.M{"side": q.Side})
+	}
+
+	res := []*types.Order{}
+	err := db.GetAndSort(dao.dbName, dao.collectionName, bson.M{"$and": filters}, historySort, 0, q.Limit, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
 func (dao *OrderDao) GetUserLockedBalance(account string, token string) (int64, []*types.Order, error) {
 	var orders []*types.Order
 
@@ -561,13 +604,13 @@ func (dao *OrderDao) GetUserLockedBalance(account string, token string) (int64,
 		"$or": []bson.M{
 			bson.M{
 				"userAddress": account,
-				"status":      bson.M{"$in": []string{"OPEN", "PARTIAL_FILLED"}},
+				"status":      bson.M{"$in": []string{"OPEN", "PARTIAL_FILLED", "UNTRIGGERED"}},
 				"quoteToken":  token,
 				"side":        "BUY",
 			},
 			bson.M{
 				"userAddress": account,
-				"status":      bson.M{"$in": []string{"OPEN", "PARTIAL_FILLED"}},
+				"status":      bson.M{"$in": []string{"OPEN", "PARTIAL_FILLED", "UNTRIGGERED"}},
 				"baseToken":   token,
 				"side":        "SELL",
 			},
@@ -624,6 +667,26 @@ func (dao *OrderDao) GetRawOrderBook(p *types.Pair) ([]*types.Order, error) {
 	return orders, nil
 }
 
+// GetUntriggeredOrders returns the stop-loss and take-profit orders of a pair that are
+// waiting for the last trade price to cross their stop price
+func (dao *OrderDao) GetUntriggeredOrders(p *types.Pair) ([]*types.Order, error) {
+	var orders []*types.Order
+	q := bson.M{
+		"status":     "UNTRIGGERED",
+		"triggered":  bson.M{"$ne": true},
+		"baseToken":  p.BaseAsset,
+		"quoteToken": p.QuoteAsset,
+	}
+
+	err := db.GetAndSort(dao.dbName, dao.collectionName, q, []string{"createdAt"}, 0, 0, &orders)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return orders, nil
+}
+
 func (dao *OrderDao) GetOrderBook(p *types.Pair) ([]map[string]interface{}, []map[string]interface{}, error) {
 	/*bidsQuery := []bson.M{
 		bson.M{
@@ -702,8 +765,8 @@ func (dao *OrderDao) GetOrderBook(p *types.Pair) ([]map[string]interface{}, []ma
 	orders, _ := dao.GetRawOrderBook(p)
 	sum := int64(0)
 	for i, o := range orders {
-		sum += o.Amount - o.FilledAmount
-		last := (i == len(orders)-1 || o.Price != orders[i+1].Price || o.Side != orders[i+1].Side || o.MatcherAddress != orders[i+1].MatcherAddress)
+		sum += o.DisplayedAmount()
+		last := (i == len(orders)-1 || o.Price.Cmp(orders[i+1].Price) != 0 || o.Side != orders[i+1].Side || o.MatcherAddress != orders[i+1].MatcherAddress)
 		if last {
 			entry := map[string]interface{}{
 				"price":          o.Price,
@@ -735,7 +798,7 @@ func (dao *OrderDao) GetOrderBook(p *types.Pair) ([]map[string]interface{}, []ma
 	return bids, asks, nil
 }
 
-func (dao *OrderDao) GetOrderBookPrice(p *types.Pair, pp float64, side string) (int64, string, float64, error) {
+func (dao *OrderDao) GetOrderBookPrice(p *types.Pair, pp types.Decimal, side string) (int64, string, float64, error) {
 	q := bson.M{
 		"status":     bson.M{"$in": []string{"OPEN", "PARTIAL_FILLED"}},
 		"baseToken":  p.BaseAsset,
@@ -784,7 +847,7 @@ func (dao *OrderDao) GetOrderBookPrice(p *types.Pair, pp float64, side string) (
 	matcherFeeRate := float64(0)
 	matcherAddress := ""
 	for _, o := range orders {
-		amount += o.Amount - o.FilledAmount
+		amount += o.DisplayedAmount()
 		if matcherAddress == "" {
 			matcherAddress = o.MatcherAddress
 			matcherFeeRate = o.MatcherFeeRate()
@@ -889,11 +952,48 @@ func (dao *OrderDao) GetMatchingSellOrders(o *types.Order) ([]*types.Order, erro
 	return orders, nil
 }
 
+// GetBestPrice returns the best price of the resting orders of a side of the orderbook of a
+// matcher, 0 if there is none. The orders expiring within a minute are skipped as they are by
+// GetMatchingBuyOrders and GetMatchingSellOrders.
+func (dao *OrderDao) GetBestPrice(p *types.Pair, side string, matcherAddress string) (types.Decimal, error) {
+	var orders []*types.Order
+
+	q := bson.M{
+		"status":         bson.M{"$in": []string{"OPEN", "PARTIAL_FILLED"}},
+		"baseToken":      p.BaseAsset,
+		"quoteToken":     p.QuoteAsset,
+		"matcherAddress": matcherAddress,
+		"side":           side,
+		"$or": []bson.M{
+			bson.M{"originalOrder.signed_message.expiry_ts": bson.M{"$exists": false}},
+			bson.M{"originalOrder.signed_message.expiry_ts": bson.M{"$gte": time.Now().Unix() + 60}},
+		},
+	}
+
+	// the highest bid or the lowest ask
+	sort := []string{"price"}
+	if side == "BUY" {
+		sort = []string{"-price"}
+	}
+
+	err := db.GetAndSort(dao.dbName, dao.collectionName, q, sort, 0, 1, &orders)
+	if err != nil {
+		logger.Error(err)
+		return types.Decimal{}, err
+	}
+
+	if len(orders) == 0 {
+		return types.Decimal{}, nil
+	}
+
+	return orders[0].Price, nil
+}
+
 func (dao *OrderDao) GetExpiredOrders() ([]*types.Order, error) {
 	var orders []*types.Order
 
 	q := bson.M{
-		"status":                                 bson.M{"$in": []string{"OPEN", "PARTIAL_FILLED"}},
+		"status":                                 bson.M{"$in": []string{"OPEN", "PARTIAL_FILLED", "UNTRIGGERED"}},
 		"originalOrder.signed_message.expiry_ts": bson.M{"$lte": time.Now().Unix()},
 	}
 
@@ -30,7 +30,7 @@ func TestUpdateOrderByHash(t *testing.T) {
 		MatcherAddress: exchange,
 		BaseToken:      "0x3",
 		QuoteToken:     "0x4",
-		Price:          1000,
+		Price:          types.NewDecimal(1000),
 		Amount:         1000,
 		FilledAmount:   100,
 		Status:         "OPEN",
@@ -54,7 +54,7 @@ func TestUpdateOrderByHash(t *testing.T) {
 		MatcherAddress: exchange,
 		BaseToken:      o.BaseToken,
 		QuoteToken:     o.QuoteToken,
-		Price:          4000,
+		Price:          types.NewDecimal(4000),
 		Amount:         4000,
 		FilledAmount:   200,
 		Status:         "FILLED",
@@ -94,7 +94,7 @@ func TestOrderUpdate(t *testing.T) {
 		UserAddress:  "0x1",
 		BaseToken:    "0x3",
 		QuoteToken:   "0x4",
-		Price:        1000,
+		Price:        types.NewDecimal(1000),
 		Amount:       1000,
 		FilledAmount: 100,
 		Status:       "OPEN",
@@ -115,7 +115,7 @@ func TestOrderUpdate(t *testing.T) {
 		UserAddress:  o.UserAddress,
 		BaseToken:    o.BaseToken,
 		QuoteToken:   o.QuoteToken,
-		Price:        4000,
+		Price:        types.NewDecimal(4000),
 		Amount:       4000,
 		FilledAmount: 200,
 		Status:       "FILLED",
@@ -160,7 +160,7 @@ func TestOrderDao1(t *testing.T) {
 		Status:       "OPEN",
 		Side:         "BUY",
 		PairName:     "ZRX/WETH",
-		Price:        1000,
+		Price:        types.NewDecimal(1000),
 		Hash:         "0x8",
 		CreatedAt:    time.Unix(1405544146, 0),
 		UpdatedAt:    time.Unix(1405544146, 0),
@@ -239,7 +239,7 @@ func TestGetUserLockedBalance(t *testing.T) {
 		FilledAmount:        0,
 		Amount:              10,
 		RemainingSellAmount: 1000,
-		Price:               100,
+		Price:               types.NewDecimal(100),
 		BaseToken:           p.BaseAsset,
 		QuoteToken:          p.QuoteAsset,
 		Status:              "OPEN",
@@ -255,7 +255,7 @@ func TestGetUserLockedBalance(t *testing.T) {
 		FilledAmount:        0,
 		RemainingSellAmount: 1000,
 		Amount:              10,
-		Price:               100,
+		Price:               types.NewDecimal(100),
 		BaseToken:           p.BaseAsset,
 		QuoteToken:          p.QuoteAsset,
 		Status:              "OPEN",
@@ -270,7 +270,7 @@ func TestGetUserLockedBalance(t *testing.T) {
 		MatcherAddress:      exchange,
 		FilledAmount:        5,
 		Amount:              10,
-		Price:               100,
+		Price:               types.NewDecimal(100),
 		RemainingSellAmount: 500,
 		BaseToken:           p.BaseAsset,
 		QuoteToken:          p.QuoteAsset,
@@ -286,7 +286,7 @@ func TestGetUserLockedBalance(t *testing.T) {
 		MatcherAddress:      exchange,
 		Amount:              10,
 		FilledAmount:        10,
-		Price:               100,
+		Price:               types.NewDecimal(100),
 		RemainingSellAmount: 0,
 		BaseToken:           p.BaseAsset,
 		QuoteToken:          p.QuoteAsset,
@@ -329,7 +329,7 @@ func TestGetUserOrderHistory(t *testing.T) {
 		Amount:         5,
 		BaseToken:      baseToken,
 		QuoteToken:     quoteToken,
-		Price:          1e9,
+		Price:          types.NewDecimal(1e9),
 		Status:         "FILLED",
 		Side:           "BUY",
 		PairName:       "ZRX/WETH",
@@ -344,7 +344,7 @@ func TestGetUserOrderHistory(t *testing.T) {
 		Amount:         5,
 		BaseToken:      baseToken,
 		QuoteToken:     quoteToken,
-		Price:          1e9,
+		Price:          types.NewDecimal(1e9),
 		Status:         "OPEN",
 		Side:           "BUY",
 		PairName:       "ZRX/WETH",
@@ -359,7 +359,7 @@ func TestGetUserOrderHistory(t *testing.T) {
 		QuoteToken:     quoteToken,
 		Amount:         5,
 		FilledAmount:   5,
-		Price:          1e9,
+		Price:          types.NewDecimal(1e9),
 		Status:         "INVALID",
 		Side:           "BUY",
 		PairName:       "ZRX/WETH",
@@ -374,7 +374,7 @@ func TestGetUserOrderHistory(t *testing.T) {
 		QuoteToken:     quoteToken,
 		Amount:         5,
 		FilledAmount:   10,
-		Price:          1e9,
+		Price:          types.NewDecimal(1e9),
 		Status:         "PARTIAL_FILLED",
 		Side:           "BUY",
 		PairName:       "ZRX/WETH",
@@ -598,6 +598,63 @@ func TestUpdateOrderFilledAmounts(t *testing.T) {
 	assert.Equal(t, int64(2), orders[1].FilledAmount)
 }
 
+func TestGetBestPrice(t *testing.T) {
+	dao := NewOrderDao()
+	err := dao.Drop()
+	if err != nil {
+		t.Error("Could not drop previous order collection")
+	}
+
+	pair := &types.Pair{BaseAsset: "0x3", QuoteAsset: "0x4"}
+	newOrder := func(hash string, matcher string, price float64, expiry int64) *types.Order {
+		o := &types.Order{
+			ID:             bson.NewObjectId(),
+			UserAddress:    "0x1",
+			MatcherAddress: matcher,
+			BaseToken:      pair.BaseAsset,
+			QuoteToken:     pair.QuoteAsset,
+			Price:          types.NewDecimal(price),
+			Amount:         1000,
+			Status:         "OPEN",
+			Side:           "SELL",
+			PairName:       "ZRX/WETH",
+			Hash:           hash,
+		}
+
+		if expiry > 0 {
+			o.OriginalOrder = map[string]interface{}{
+				"signed_message": map[string]interface{}{"expiry_ts": expiry},
+			}
+		}
+
+		return o
+	}
+
+	orders := []*types.Order{
+		newOrder("0x5", "0x2", 3, 0),
+		newOrder("0x6", "0x2", 4, time.Now().Unix()+3600),
+		// expiring within a minute
+		newOrder("0x7", "0x2", 2, time.Now().Unix()+10),
+		// another matcher
+		newOrder("0x8", "0x9", 1, 0),
+	}
+
+	for _, o := range orders {
+		err = dao.Create(o)
+		if err != nil {
+			t.Error("Could not create order")
+		}
+	}
+
+	price, err := dao.GetBestPrice(pair, "SELL", "0x2")
+	assert.Nil(t, err)
+	assert.Equal(t, types.NewDecimal(3), price)
+
+	price, err = dao.GetBestPrice(pair, "BUY", "0x2")
+	assert.Nil(t, err)
+	assert.Equal(t, types.Decimal{}, price)
+}
+
 func TestOrderStatusesByHashes(t *testing.T) {
 	dao := NewOrderDao()
 	err := dao.Drop()
@@ -698,7 +755,7 @@ func ExampleGetOrderBookPrice() {
 		panic(err)
 	}
 
-	orderPricePoint, _, __, err := orderDao.GetOrderBookPrice(pair, float64(59303), "BUY")
+	orderPricePoint, _, __, err := orderDao.GetOrderBookPrice(pair, types.NewDecimal(59303), "BUY")
 	if err != nil {
 		panic(err)
 	}
@@ -71,6 +71,14 @@ func NewTradeDao() *TradeDao {
 		Key: []string{"txHash"},
 	}
 
+	indexByMakerCreatedAt := mgo.Index{
+		Key: []string{"maker", "-createdAt", "-_id"},
+	}
+
+	indexByTakerCreatedAt := mgo.Index{
+		Key: []string{"taker", "-createdAt", "-_id"},
+	}
+
 	err := db.Session.DB(dbName).C(collection).EnsureIndex(i1)
 	if err != nil {
 		panic(err)
@@ -116,6 +124,16 @@ func NewTradeDao() *TradeDao {
 		panic(err)
 	}
 
+	err = db.Session.DB(dbName).C(collection).EnsureIndex(indexByMakerCreatedAt)
+	if err != nil {
+		panic(err)
+	}
+
+	err = db.Session.DB(dbName).C(collection).EnsureIndex(indexByTakerCreatedAt)
+	if err != nil {
+		panic(err)
+	}
+
 	return &TradeDao{collection, dbName}
 }
 
@@ -455,6 +473,33 @@ func (dao *TradeDao) GetSortedTradesByUserAddress(a string, limit ...int) ([]*ty
 	return res, nil
 }
 
+// GetPageByUserAddress returns a page of the trades of a user selected by a history query,
+// newest first. The side of the user in a trade is the side of their order.
+func (dao *TradeDao) GetPageByUserAddress(q *types.HistoryQuery) ([]*types.Trade, error) {
+	owner := bson.M{"$or": []bson.M{{"maker": q.Address}, {"taker": q.Address}}}
+	if q.Side != "" {
+		takerSide := "BUY"
+		if q.Side == "BUY" {
+			takerSide = "SELL"
+		}
+
+		owner = bson.M{"$or": []bson.M{
+			{"maker": q.Address, "makerSide": q.Side},
+			{"taker": q.Address, "makerSide": takerSide},
+		}}
+	}
+
+	res := []*types.Trade{}
+	filters := append(historyFilters(q), owner)
+	err := db.GetAndSort(dao.dbName, dao.collectionName, bson.M{"$and": filters}, historySort, 0, q.Limit, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
 // GetByUserAddress fetches all the trades corresponding to a particular user address.
 func (dao *TradeDao) GetByUserAddress(a string) ([]*types.Trade, error) {
 	var res []*types.Trade
@@ -572,6 +617,61 @@ func (dao *TradeDao) UpdateTradeStatusesByHashes(status string, hashes ...string
 	return trades, nil
 }
 
+// GetUnsettledTrades returns the trades created before the given time that were neither
+// committed nor rejected yet
+func (dao *TradeDao) GetUnsettledTrades(before time.Time) ([]*types.Trade, error) {
+	q := bson.M{
+		"status":    bson.M{"$in": []string{"PENDING", "SUCCESS"}},
+		"createdAt": bson.M{"$lt": before},
+	}
+
+	res := []*types.Trade{}
+	err := db.Get(dao.dbName, dao.collectionName, q, 0, 0, &res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// GetUserVolume returns the quote amount of the committed trades of a user on a pair since the
+// given time
+func (dao *TradeDao) GetUserVolume(address string, pairName string, since time.Time) (int64, error) {
+	q := []bson.M{
+		bson.M{
+			"$match": bson.M{
+				"$or":       []bson.M{bson.M{"maker": address}, bson.M{"taker": address}},
+				"pairName":  pairName,
+				"status":    "COMMITTED",
+				"createdAt": bson.M{"$gte": since},
+			},
+		},
+		bson.M{
+			"$group": bson.M{
+				"_id":    nil,
+				"volume": bson.M{"$sum": "$quoteAmount"},
+			},
+		},
+	}
+
+	res := []struct {
+		Volume int64 `bson:"volume"`
+	}{}
+
+	err := db.Aggregate(dao.dbName, dao.collectionName, q, &res)
+	if err != nil {
+		logger.Error(err)
+		return 0, err
+	}
+
+	if len(res) == 0 {
+		return 0, nil
+	}
+
+	return res[0].Volume, nil
+}
+
 // Drop drops all the order documents in the current database
 func (dao *TradeDao) Drop() {
 	db.DropCollection(dao.dbName, dao.collectionName)
@@ -1,8 +1,10 @@
 package daos
 
 import (
+	"fmt"
 	"io/ioutil"
 	"testing"
+	"time"
 
 	"github.com/byteball/odex-backend/types"
 	"github.com/byteball/odex-backend/utils/testutils"
@@ -38,7 +40,7 @@ func TestTradeDao(t *testing.T) {
 			MakerOrderHash: "0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff",
 			TxHash:         "0x41787e3a418997174e2445b51849e79953e334d94a02119e25beff1f13e39aa8",
 			PairName:       "ZRX/WETH",
-			Price:          10000000,
+			Price:          types.NewDecimal(10000000),
 			Amount:         100,
 		},
 		&types.Trade{
@@ -51,7 +53,7 @@ func TestTradeDao(t *testing.T) {
 			Hash:           "0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a",
 			MakerOrderHash: "0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff",
 			PairName:       "ZRX/WETH",
-			Price:          10000000,
+			Price:          types.NewDecimal(10000000),
 			Amount:         100,
 		},
 		&types.Trade{
@@ -64,7 +66,7 @@ func TestTradeDao(t *testing.T) {
 			Hash:           "0xb9070a2d333403c255ce71ddf6e795053599b2e885321de40353832b96d8880a",
 			MakerOrderHash: "0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff",
 			PairName:       "ZRX/DAI",
-			Price:          10000000,
+			Price:          types.NewDecimal(10000000),
 			Amount:         100,
 		},
 	}
@@ -121,7 +123,7 @@ func TestUpdateTrade(t *testing.T) {
 		MakerOrderHash: "0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff",
 		TxHash:         "Transaction  0xf16e0b1ad8536bc43fba0ac009fc19098e19920e045273fa16fa0fc7c83ae1e8",
 		PairName:       "ZRX/WETH",
-		Price:          10000000,
+		Price:          types.NewDecimal(10000000),
 		Amount:         100,
 	}
 
@@ -159,3 +161,149 @@ func TestUpdateTrade(t *testing.T) {
 
 	testutils.CompareTrade(t, queried, updated)
 }
+
+func TestGetUnsettledTrades(t *testing.T) {
+	dao := NewTradeDao()
+	dao.Drop()
+
+	statuses := []string{"PENDING", "SUCCESS", "COMMITTED", "REJECTED"}
+	for i, status := range statuses {
+		err := dao.Create(&types.Trade{
+			Maker:    "0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa",
+			Taker:    "0xae55690d4b079460e6ac28aaa58c9ec7b73a7485",
+			Hash:     fmt.Sprintf("0x%064d", i),
+			TxHash:   fmt.Sprintf("unit%d", i),
+			PairName: "ZRX/WETH",
+			Status:   status,
+			Price:    types.NewDecimal(10000000),
+			Amount:   100,
+		})
+
+		if err != nil {
+			t.Errorf("Could not create trade object")
+		}
+	}
+
+	trades, err := dao.GetUnsettledTrades(time.Now().Add(-time.Minute))
+	if err != nil {
+		t.Errorf("Could not retrieve unsettled trades: %v", err)
+	}
+
+	assert.Equal(t, 0, len(trades))
+
+	trades, err = dao.GetUnsettledTrades(time.Now().Add(time.Minute))
+	if err != nil {
+		t.Errorf("Could not retrieve unsettled trades: %v", err)
+	}
+
+	assert.Equal(t, 2, len(trades))
+	for _, tr := range trades {
+		assert.Contains(t, []string{"PENDING", "SUCCESS"}, tr.Status)
+	}
+}
+
+func TestGetUserVolume(t *testing.T) {
+	dao := NewTradeDao()
+	dao.Drop()
+
+	user := "0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"
+	trades := []*types.Trade{
+		&types.Trade{Maker: user, Taker: "0x1", PairName: "ZRX/WETH", Status: "COMMITTED", QuoteAmount: 100},
+		&types.Trade{Maker: "0x1", Taker: user, PairName: "ZRX/WETH", Status: "COMMITTED", QuoteAmount: 200},
+		&types.Trade{Maker: user, Taker: "0x1", PairName: "ZRX/WETH", Status: "REJECTED", QuoteAmount: 400},
+		&types.Trade{Maker: user, Taker: "0x1", PairName: "ZRX/DAI", Status: "COMMITTED", QuoteAmount: 800},
+		&types.Trade{Maker: "0x1", Taker: "0x2", PairName: "ZRX/WETH", Status: "COMMITTED", QuoteAmount: 1600},
+	}
+
+	for i, tr := range trades {
+		tr.Hash = fmt.Sprintf("0x%064d", i)
+		tr.Price = types.NewDecimal(10000000)
+		tr.Amount = 100
+
+		err := dao.Create(tr)
+		if err != nil {
+			t.Errorf("Could not create trade object")
+		}
+	}
+
+	volume, err := dao.GetUserVolume(user, "ZRX/WETH", time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Errorf("Could not compute the volume: %v", err)
+	}
+
+	assert.Equal(t, int64(300), volume)
+
+	volume, err = dao.GetUserVolume(user, "ZRX/WETH", time.Now().Add(time.Hour))
+	if err != nil {
+		t.Errorf("Could not compute the volume: %v", err)
+	}
+
+	assert.Equal(t, int64(0), volume)
+}
+
+func TestGetTradesPageByUserAddress(t *testing.T) {
+	dao := NewTradeDao()
+	dao.Drop()
+
+	user := "0x7a9f3cd060ab180f36c17fe6bdf9974f577d77aa"
+	trades := []*types.Trade{
+		&types.Trade{Maker: user, Taker: "0x1", PairName: "ZRX/WETH", MakerSide: "SELL", Status: "COMMITTED"},
+		&types.Trade{Maker: "0x1", Taker: user, PairName: "ZRX/WETH", MakerSide: "SELL", Status: "COMMITTED"},
+		&types.Trade{Maker: user, Taker: "0x1", PairName: "ZRX/WETH", MakerSide: "BUY", Status: "ERROR"},
+		&types.Trade{Maker: user, Taker: "0x1", PairName: "ZRX/DAI", MakerSide: "SELL", Status: "COMMITTED"},
+		&types.Trade{Maker: "0x1", Taker: user, PairName: "ZRX/WETH", MakerSide: "BUY", Status: "COMMITTED"},
+		&types.Trade{Maker: "0x1", Taker: "0x2", PairName: "ZRX/WETH", MakerSide: "SELL", Status: "COMMITTED"},
+	}
+
+	for i, tr := range trades {
+		tr.Hash = fmt.Sprintf("0x%064d", i)
+		tr.Price = types.NewDecimal(10000000)
+		tr.Amount = 100
+
+		err := dao.Create(tr)
+		if err != nil {
+			t.Errorf("Could not create trade object")
+		}
+	}
+
+	// walk the history of the user by pages of 2 trades
+	q := &types.HistoryQuery{Address: user, Limit: 2}
+	hashes := []string{}
+	for {
+		page, err := dao.GetPageByUserAddress(q)
+		if err != nil {
+			t.Errorf("Could not get the trades of the user: %v", err)
+		}
+
+		for _, tr := range page {
+			hashes = append(hashes, tr.Hash)
+		}
+
+		if len(page) < q.Limit {
+			break
+		}
+
+		last := page[len(page)-1]
+		q.Cursor = types.NewCursor(last.CreatedAt, last.ID)
+	}
+
+	assert.Equal(t, []string{trades[4].Hash, trades[3].Hash, trades[2].Hash, trades[1].Hash, trades[0].Hash}, hashes)
+
+	// the user bought as a maker of trade 2 and as a taker of trade 1
+	page, err := dao.GetPageByUserAddress(&types.HistoryQuery{Address: user, Side: "BUY", PairName: "ZRX/WETH"})
+	if err != nil {
+		t.Errorf("Could not get the trades of the user: %v", err)
+	}
+
+	assert.Equal(t, 2, len(page))
+	assert.Equal(t, trades[2].Hash, page[0].Hash)
+	assert.Equal(t, trades[1].Hash, page[1].Hash)
+
+	page, err = dao.GetPageByUserAddress(&types.HistoryQuery{Address: user, Status: "ERROR"})
+	if err != nil {
+		t.Errorf("Could not get the trades of the user: %v", err)
+	}
+
+	assert.Equal(t, 1, len(page))
+	assert.Equal(t, trades[2].Hash, page[0].Hash)
+}
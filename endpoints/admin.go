@@ -0,0 +1,130 @@
+package endpoints
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/byteball/odex-backend/app"
+	"github.com/byteball/odex-backend/interfaces"
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils/httputils"
+	"github.com/gorilla/mux"
+)
+
+type adminEndpoint struct {
+	deadLetters interfaces.DeadLetterQueue
+	feeService  interfaces.FeeService
+}
+
+// ServeAdminResource sets up the routing of the admin endpoints. The requests must carry the
+// configured admin token in their Authorization header ("Bearer <token>").
+func ServeAdminResource(
+	r *mux.Router,
+	deadLetters interfaces.DeadLetterQueue,
+	feeService interfaces.FeeService,
+) {
+	e := &adminEndpoint{deadLetters, feeService}
+	r.HandleFunc("/admin/dead-letters", e.authorize(e.handleGetDeadLetters)).Methods("GET")
+	r.HandleFunc("/admin/dead-letters/{id}/replay", e.authorize(e.handleReplayDeadLetter)).Methods("POST")
+	r.HandleFunc("/admin/dead-letters/{id}", e.authorize(e.handleDiscardDeadLetter)).Methods("DELETE")
+	r.HandleFunc("/admin/fees", e.authorize(e.handleUpdateFeeSchedule)).Methods("PUT")
+	r.HandleFunc("/admin/fees", e.authorize(e.handleDeleteFeeSchedule)).Methods("DELETE")
+}
+
+// authorize rejects the requests without the admin token. All requests are rejected when no
+// admin token is configured.
+func (e *adminEndpoint) authorize(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := app.Config.AdminToken
+		expected := []byte("Bearer " + token)
+		header := []byte(r.Header.Get("Authorization"))
+
+		if token == "" || subtle.ConstantTimeCompare(header, expected) != 1 {
+			httputils.WriteError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		h(w, r)
+	}
+}
+
+func (e *adminEndpoint) handleGetDeadLetters(w http.ResponseWriter, r *http.Request) {
+	res, err := e.deadLetters.GetDeadLetters()
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (e *adminEndpoint) handleReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	err := e.deadLetters.ReplayDeadLetter(id)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, map[string]string{"id": id})
+}
+
+func (e *adminEndpoint) handleDiscardDeadLetter(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	err := e.deadLetters.DiscardDeadLetter(id)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, map[string]string{"id": id})
+}
+
+func (e *adminEndpoint) handleUpdateFeeSchedule(w http.ResponseWriter, r *http.Request) {
+	schedule := &types.FeeSchedule{}
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+
+	err := decoder.Decode(schedule)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid Payload")
+		return
+	}
+
+	err = schedule.Validate()
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	err = e.feeService.UpdateSchedule(schedule)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, schedule)
+}
+
+func (e *adminEndpoint) handleDeleteFeeSchedule(w http.ResponseWriter, r *http.Request) {
+	pairName := r.URL.Query().Get("pairName")
+
+	err := e.feeService.DeleteSchedule(pairName)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, map[string]string{"pairName": pairName})
+}
@@ -0,0 +1,157 @@
+package endpoints
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/byteball/odex-backend/app"
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils/testutils/mocks"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func SetupAdminTest() (*mux.Router, *mocks.DeadLetterQueue, *mocks.FeeService) {
+	app.Config.AdminToken = "secret"
+
+	r := mux.NewRouter()
+	deadLetters := new(mocks.DeadLetterQueue)
+	feeService := new(mocks.FeeService)
+
+	ServeAdminResource(r, deadLetters, feeService)
+
+	return r, deadLetters, feeService
+}
+
+func TestHandleGetDeadLetters(t *testing.T) {
+	router, deadLetters, _ := SetupAdminTest()
+
+	letters := []*types.DeadLetter{&types.DeadLetter{ID: "1", Queue: "oper", Error: "timeout", Attempts: 5}}
+	deadLetters.On("GetDeadLetters").Return(letters, nil)
+
+	req, _ := http.NewRequest("GET", "/admin/dead-letters", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req.Header.Set("Authorization", "Bearer wrong")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	req.Header.Set("Authorization", "Bearer secret")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	res := struct {
+		Data []*types.DeadLetter `json:"data"`
+	}{}
+	json.NewDecoder(rr.Body).Decode(&res)
+	assert.Equal(t, 1, len(res.Data))
+	assert.Equal(t, "1", res.Data[0].ID)
+	assert.Equal(t, "timeout", res.Data[0].Error)
+
+	deadLetters.AssertExpectations(t)
+}
+
+func TestHandleReplayDeadLetter(t *testing.T) {
+	router, deadLetters, _ := SetupAdminTest()
+
+	deadLetters.On("ReplayDeadLetter", "1").Return(nil)
+	deadLetters.On("ReplayDeadLetter", "2").Return(errors.New("Dead letter not found"))
+
+	req, _ := http.NewRequest("POST", "/admin/dead-letters/1/replay", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req, _ = http.NewRequest("POST", "/admin/dead-letters/2/replay", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	deadLetters.AssertExpectations(t)
+}
+
+func TestHandleDiscardDeadLetter(t *testing.T) {
+	router, deadLetters, _ := SetupAdminTest()
+
+	deadLetters.On("DiscardDeadLetter", "1").Return(nil)
+	deadLetters.On("DiscardDeadLetter", "2").Return(errors.New("Dead letter not found"))
+
+	req, _ := http.NewRequest("DELETE", "/admin/dead-letters/1", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	req, _ = http.NewRequest("DELETE", "/admin/dead-letters/2", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	deadLetters.AssertExpectations(t)
+}
+
+func TestAdminDisabledWithoutToken(t *testing.T) {
+	router, _, _ := SetupAdminTest()
+	app.Config.AdminToken = ""
+
+	req, _ := http.NewRequest("GET", "/admin/dead-letters", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestHandleUpdateFeeSchedule(t *testing.T) {
+	router, _, feeService := SetupAdminTest()
+
+	schedule := &types.FeeSchedule{
+		PairName: "GBYTE/USDC",
+		MakerFee: 0.001,
+		TakerFee: 0.002,
+		Tiers:    []types.FeeTier{types.FeeTier{MinVolume: 1000, MakerFee: 0, TakerFee: 0.001}},
+	}
+
+	feeService.On("UpdateSchedule", schedule).Return(nil)
+
+	b, _ := json.Marshal(schedule)
+	req, _ := http.NewRequest("PUT", "/admin/fees", bytes.NewBuffer(b))
+	req.Header.Set("Authorization", "Bearer secret")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	schedule.TakerFee = 2
+	b, _ = json.Marshal(schedule)
+	req, _ = http.NewRequest("PUT", "/admin/fees", bytes.NewBuffer(b))
+	req.Header.Set("Authorization", "Bearer secret")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	feeService.AssertNumberOfCalls(t, "UpdateSchedule", 1)
+}
+
+func TestHandleDeleteFeeSchedule(t *testing.T) {
+	router, _, feeService := SetupAdminTest()
+
+	feeService.On("DeleteSchedule", "GBYTE/USDC").Return(nil)
+
+	req, _ := http.NewRequest("DELETE", "/admin/fees?pairName=GBYTE/USDC", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	feeService.AssertExpectations(t)
+}
@@ -0,0 +1,137 @@
+package endpoints
+
+import (
+	"encoding/csv"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/byteball/odex-backend/interfaces"
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils/httputils"
+	"github.com/gorilla/mux"
+)
+
+type affiliateEndpoint struct {
+	affiliateService interfaces.AffiliateService
+}
+
+// ServeAffiliateResource sets up the routing of the affiliate revenue endpoints
+func ServeAffiliateResource(
+	r *mux.Router,
+	affiliateService interfaces.AffiliateService,
+) {
+	e := &affiliateEndpoint{affiliateService}
+	r.HandleFunc("/affiliates/{address}/revenue", e.handleGetRevenue).Methods("GET")
+	r.HandleFunc("/affiliates/{address}/fees.csv", e.handleGetFeesCSV).Methods("GET")
+}
+
+func (e *affiliateEndpoint) handleGetRevenue(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	if !isValidAddress(address) {
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid Address")
+		return
+	}
+
+	unit := r.URL.Query().Get("unit")
+	if unit == "" {
+		unit = "day"
+	}
+
+	if unit != "day" && unit != "month" {
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid unit")
+		return
+	}
+
+	from, to, err := parseAffiliatePeriod(r)
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid from or to parameter")
+		return
+	}
+
+	res, err := e.affiliateService.GetRevenue(address, unit, from, to)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	if res == nil {
+		httputils.WriteJSON(w, http.StatusOK, []*types.AffiliateRevenue{})
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (e *affiliateEndpoint) handleGetFeesCSV(w http.ResponseWriter, r *http.Request) {
+	address := mux.Vars(r)["address"]
+	if !isValidAddress(address) {
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid Address")
+		return
+	}
+
+	from, to, err := parseAffiliatePeriod(r)
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid from or to parameter")
+		return
+	}
+
+	fees, err := e.affiliateService.GetFees(address, from, to)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"affiliate-fees-"+address+".csv\"")
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"tradedAt", "tradeHash", "orderHash", "userAddress", "pairName", "asset", "amount"})
+	for _, f := range fees {
+		cw.Write([]string{
+			f.TradedAt.UTC().Format(time.RFC3339),
+			f.TradeHash,
+			f.OrderHash,
+			f.UserAddress,
+			f.PairName,
+			f.Asset,
+			strconv.FormatInt(f.Amount, 10),
+		})
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		logger.Error(err)
+	}
+}
+
+// parseAffiliatePeriod reads the from and to unix timestamps of the request. The period defaults
+// to the last year.
+func parseAffiliatePeriod(r *http.Request) (time.Time, time.Time, error) {
+	v := r.URL.Query()
+
+	to := time.Now()
+	if v.Get("to") != "" {
+		t, err := strconv.ParseInt(v.Get("to"), 10, 64)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+
+		to = time.Unix(t, 0)
+	}
+
+	from := to.AddDate(-1, 0, 0)
+	if v.Get("from") != "" {
+		f, err := strconv.ParseInt(v.Get("from"), 10, 64)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+
+		from = time.Unix(f, 0)
+	}
+
+	return from, to, nil
+}
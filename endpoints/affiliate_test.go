@@ -0,0 +1,95 @@
+package endpoints
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils/testutils/mocks"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testAffiliateAddress = "AFFILIATEADDRESS0000000000000000"
+
+func SetupAffiliateTest() (*mux.Router, *mocks.AffiliateService) {
+	r := mux.NewRouter()
+	affiliateService := new(mocks.AffiliateService)
+
+	ServeAffiliateResource(r, affiliateService)
+
+	return r, affiliateService
+}
+
+func TestHandleGetAffiliateRevenue(t *testing.T) {
+	router, affiliateService := SetupAffiliateTest()
+
+	revenue := []*types.AffiliateRevenue{
+		&types.AffiliateRevenue{Period: "2019-05", Asset: "base", Amount: 1200, Trades: 3},
+	}
+
+	from, to := time.Unix(1550000000, 0), time.Unix(1560000000, 0)
+	affiliateService.On("GetRevenue", testAffiliateAddress, "month", from, to).Return(revenue, nil)
+
+	req, _ := http.NewRequest("GET", "/affiliates/"+testAffiliateAddress+"/revenue?unit=month&from=1550000000&to=1560000000", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	res := struct {
+		Data []*types.AffiliateRevenue `json:"data"`
+	}{}
+	json.NewDecoder(rr.Body).Decode(&res)
+	assert.Equal(t, revenue, res.Data)
+
+	req, _ = http.NewRequest("GET", "/affiliates/"+testAffiliateAddress+"/revenue?unit=week", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	req, _ = http.NewRequest("GET", "/affiliates/SHORT/revenue", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	affiliateService.AssertExpectations(t)
+}
+
+func TestHandleGetAffiliateFeesCSV(t *testing.T) {
+	router, affiliateService := SetupAffiliateTest()
+
+	fees := []*types.AffiliateFee{
+		&types.AffiliateFee{
+			AffiliateAddress: testAffiliateAddress,
+			UserAddress:      "USER",
+			OrderHash:        "0xorder",
+			TradeHash:        "0xtrade",
+			PairName:         "GBYTE/USDC",
+			Asset:            "base",
+			Amount:           25,
+			TradedAt:         time.Unix(1500000000, 0),
+		},
+	}
+
+	affiliateService.On("GetFees", testAffiliateAddress, mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time")).Return(fees, nil)
+
+	req, _ := http.NewRequest("GET", "/affiliates/"+testAffiliateAddress+"/fees.csv", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+
+	records, err := csv.NewReader(rr.Body).ReadAll()
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{
+		{"tradedAt", "tradeHash", "orderHash", "userAddress", "pairName", "asset", "amount"},
+		{"2017-07-14T02:40:00Z", "0xtrade", "0xorder", "USER", "GBYTE/USDC", "base", "25"},
+	}, records)
+
+	affiliateService.AssertExpectations(t)
+}
@@ -0,0 +1,66 @@
+package endpoints
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/byteball/odex-backend/types"
+)
+
+// parseHistoryQuery reads the pagination and the filters of a request for the orders or trades
+// of a user: limit, cursor, from and to (unix timestamps), pairName, side and status
+func parseHistoryQuery(v url.Values, address string, defaultLimit int) (*types.HistoryQuery, error) {
+	q := &types.HistoryQuery{
+		Address:  address,
+		PairName: v.Get("pairName"),
+		Side:     strings.ToUpper(v.Get("side")),
+		Status:   strings.ToUpper(v.Get("status")),
+		Limit:    defaultLimit,
+	}
+
+	if limit := v.Get("limit"); limit != "" {
+		lim, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, errors.New("Invalid limit")
+		}
+
+		q.Limit = lim
+	}
+
+	if cursor := v.Get("cursor"); cursor != "" {
+		c, err := types.DecodeCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+
+		q.Cursor = c
+	}
+
+	if from := v.Get("from"); from != "" {
+		f, err := strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			return nil, errors.New("Invalid from parameter")
+		}
+
+		q.From = time.Unix(f, 0)
+	}
+
+	if to := v.Get("to"); to != "" {
+		t, err := strconv.ParseInt(to, 10, 64)
+		if err != nil {
+			return nil, errors.New("Invalid to parameter")
+		}
+
+		q.To = time.Unix(t, 0)
+	}
+
+	err := q.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return q, nil
+}
@@ -12,6 +12,7 @@ import (
 type infoEndpoint struct {
 	tokenService  interfaces.TokenService
 	infoService   interfaces.InfoService
+	feeService    interfaces.FeeService
 	obyteProvider interfaces.ObyteProvider
 }
 
@@ -19,14 +20,16 @@ func ServeInfoResource(
 	r *mux.Router,
 	tokenService interfaces.TokenService,
 	infoService interfaces.InfoService,
+	feeService interfaces.FeeService,
 	obyteProvider interfaces.ObyteProvider,
 ) {
 
-	e := &infoEndpoint{tokenService, infoService, obyteProvider}
+	e := &infoEndpoint{tokenService, infoService, feeService, obyteProvider}
 	r.HandleFunc("/info", e.handleGetInfo)
 	r.HandleFunc("/info/exchange", e.handleGetExchangeInfo)
 	r.HandleFunc("/info/operators", e.handleGetOperatorsInfo)
 	r.HandleFunc("/info/fees", e.handleGetFeeInfo)
+	r.HandleFunc("/info/node", e.handleGetNodeInfo)
 	r.HandleFunc("/stats/trading", e.handleGetTradingStats)
 	// r.HandleFunc("/stats/all", e.handleGetStats)
 	// r.HandleFunc("/stats/pairs", e.handleGetPairStats)
@@ -34,7 +37,14 @@ func ServeInfoResource(
 
 func (e *infoEndpoint) handleGetInfo(w http.ResponseWriter, r *http.Request) {
 	operator_address := e.obyteProvider.GetOperatorAddress()
-	matcherFee, affiliateFee := e.obyteProvider.GetFees()
+
+	// the orders signed with the default taker fee are accepted on the pairs without schedule
+	schedule, err := e.feeService.GetSchedule("")
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
 
 	operators := [1]string{operator_address}
 
@@ -47,8 +57,8 @@ func (e *infoEndpoint) handleGetInfo(w http.ResponseWriter, r *http.Request) {
 	fees := map[string]map[string]float64{}
 	for _, q := range quotes {
 		fees[q.Symbol] = map[string]float64{
-			"matcherFee":   matcherFee,
-			"affiliateFee": affiliateFee,
+			"matcherFee":   schedule.TakerFee,
+			"affiliateFee": schedule.AffiliateFee,
 		}
 	}
 
@@ -77,23 +87,44 @@ func (e *infoEndpoint) handleGetOperatorsInfo(w http.ResponseWriter, r *http.Req
 	httputils.WriteJSON(w, http.StatusOK, res)
 }
 
+// handleGetFeeInfo returns the fee schedules, or the fee rates of a user on a pair when an address
+// and a pair name are given
 func (e *infoEndpoint) handleGetFeeInfo(w http.ResponseWriter, r *http.Request) {
-	matcherFee, affiliateFee := e.obyteProvider.GetFees()
-	quotes, err := e.tokenService.GetQuoteTokens()
+	v := r.URL.Query()
+	address := v.Get("address")
+	pairName := v.Get("pairName")
+
+	if address == "" && pairName == "" {
+		res, err := e.feeService.GetSchedules()
+		if err != nil {
+			logger.Error(err)
+			httputils.WriteError(w, http.StatusInternalServerError, "")
+			return
+		}
+
+		httputils.WriteJSON(w, http.StatusOK, res)
+		return
+	}
+
+	if address == "" || pairName == "" {
+		httputils.WriteError(w, http.StatusBadRequest, "address and pairName are both required")
+		return
+	}
+
+	res, err := e.feeService.GetUserFees(address, pairName)
 	if err != nil {
 		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
 		return
 	}
 
-	fees := map[string]map[string]float64{}
-	for _, q := range quotes {
-		fees[q.Symbol] = map[string]float64{
-			"matcherFee":   matcherFee,
-			"affiliateFee": affiliateFee,
-		}
-	}
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (e *infoEndpoint) handleGetNodeInfo(w http.ResponseWriter, r *http.Request) {
+	res := e.obyteProvider.ConnectionState()
 
-	httputils.WriteJSON(w, http.StatusOK, fees)
+	httputils.WriteJSON(w, http.StatusOK, res)
 }
 
 func (e *infoEndpoint) handleGetTradingStats(w http.ResponseWriter, r *http.Request) {
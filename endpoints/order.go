@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"reflect"
 	"strconv"
+	"time"
 
+	"github.com/byteball/odex-backend/app"
 	"github.com/byteball/odex-backend/interfaces"
 	"github.com/byteball/odex-backend/utils/httputils"
 	"github.com/gorilla/mux"
@@ -40,7 +42,6 @@ func ServeOrderResource(
 func (e *orderEndpoint) handleGetOrders(w http.ResponseWriter, r *http.Request) {
 	v := r.URL.Query()
 	addr := v.Get("address")
-	limit := v.Get("limit")
 
 	if addr == "" {
 		httputils.WriteError(w, http.StatusBadRequest, "address Parameter Missing")
@@ -52,17 +53,13 @@ func (e *orderEndpoint) handleGetOrders(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var err error
-	var orders []*types.Order
-	address := addr
-
-	if limit == "" {
-		orders, err = e.orderService.GetByUserAddress(address)
-	} else {
-		lim, _ := strconv.Atoi(limit)
-		orders, err = e.orderService.GetByUserAddress(address, lim)
+	q, err := parseHistoryQuery(v, addr, 0)
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
+	orders, nextCursor, err := e.orderService.GetPageByUserAddress(q)
 	if err != nil {
 		logger.Error(err)
 		httputils.WriteError(w, http.StatusInternalServerError, "")
@@ -70,11 +67,10 @@ func (e *orderEndpoint) handleGetOrders(w http.ResponseWriter, r *http.Request)
 	}
 
 	if orders == nil {
-		httputils.WriteJSON(w, http.StatusOK, []types.Order{})
-		return
+		orders = []*types.Order{}
 	}
 
-	httputils.WriteJSON(w, http.StatusOK, orders)
+	httputils.WritePage(w, http.StatusOK, orders, types.NextCursorHeader, nextCursor)
 }
 
 func (e *orderEndpoint) handleGetCurrentOrders(w http.ResponseWriter, r *http.Request) {
@@ -120,7 +116,6 @@ func (e *orderEndpoint) handleGetCurrentOrders(w http.ResponseWriter, r *http.Re
 func (e *orderEndpoint) handleGetOrderHistory(w http.ResponseWriter, r *http.Request) {
 	v := r.URL.Query()
 	addr := v.Get("address")
-	limit := v.Get("limit")
 
 	if addr == "" {
 		httputils.WriteError(w, http.StatusBadRequest, "address Parameter missing")
@@ -132,17 +127,13 @@ func (e *orderEndpoint) handleGetOrderHistory(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	var err error
-	var orders []*types.Order
-	address := addr
-
-	if limit == "" {
-		orders, err = e.orderService.GetHistoryByUserAddress(address)
-	} else {
-		lim, _ := strconv.Atoi(limit)
-		orders, err = e.orderService.GetHistoryByUserAddress(address, lim)
+	q, err := parseHistoryQuery(v, addr, 0)
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
+	orders, nextCursor, err := e.orderService.GetHistoryPageByUserAddress(q)
 	if err != nil {
 		logger.Error(err)
 		httputils.WriteError(w, http.StatusInternalServerError, "Internal Server Error")
@@ -150,11 +141,10 @@ func (e *orderEndpoint) handleGetOrderHistory(w http.ResponseWriter, r *http.Req
 	}
 
 	if orders == nil {
-		httputils.WriteJSON(w, http.StatusOK, []types.Order{})
-		return
+		orders = []*types.Order{}
 	}
 
-	httputils.WriteJSON(w, http.StatusOK, orders)
+	httputils.WritePage(w, http.StatusOK, orders, types.NextCursorHeader, nextCursor)
 }
 
 // ws function handles incoming websocket messages on the order channel
@@ -172,8 +162,18 @@ func (e *orderEndpoint) ws(input interface{}, c *ws.Client) {
 		e.handleAddress(msg, c)
 	case "NEW_ORDER":
 		e.handleNewOrder(msg, c)
+	case "AMEND_ORDER":
+		e.handleAmendOrder(msg, c)
 	case "CANCEL_ORDER":
 		e.handleCancelOrder(msg, c)
+	case "CANCEL_ALL_ORDERS":
+		e.handleCancelAllOrders(msg, c)
+	case "ENABLE_CANCEL_ON_DISCONNECT":
+		e.handleEnableCancelOnDisconnect(msg, c)
+	case "DISABLE_CANCEL_ON_DISCONNECT":
+		e.handleDisableCancelOnDisconnect(msg, c)
+	case "HEARTBEAT":
+		e.handleHeartbeat(msg, c)
 	default:
 		log.Print("Response with error")
 	}
@@ -228,6 +228,19 @@ func (e *orderEndpoint) handleNewOrder(ev *types.WebsocketEvent, c *ws.Client) {
 	}*/
 }
 
+// handleAmendOrder handles AmendOrder message. The new signed order is checked by the node, which
+// sends it back as an amend_order event
+func (e *orderEndpoint) handleAmendOrder(ev *types.WebsocketEvent, c *ws.Client) {
+	c.RpcMutex.Lock()
+	_, err := e.obyteProvider.AmendOrder(&ev.Payload)
+	c.RpcMutex.Unlock()
+	if err != nil {
+		logger.Error(err)
+		go c.SendMessage(ws.OrderChannel, "ERROR", err.Error())
+		return
+	}
+}
+
 // handleCancelOrder handles CancelOrder message.
 func (e *orderEndpoint) handleCancelOrder(ev *types.WebsocketEvent, c *ws.Client) {
 	c.RpcMutex.Lock()
@@ -279,6 +292,75 @@ func (e *orderEndpoint) handleCancelOrder(ev *types.WebsocketEvent, c *ws.Client
 	}*/
 }
 
+// handleCancelAllOrders handles CancelAllOrders message. The signed message is checked by the node,
+// which sends it back as a cancel_all_orders event
+func (e *orderEndpoint) handleCancelAllOrders(ev *types.WebsocketEvent, c *ws.Client) {
+	c.RpcMutex.Lock()
+	err := e.obyteProvider.CancelAllOrders(&ev.Payload)
+	c.RpcMutex.Unlock()
+	if err != nil {
+		logger.Error(err)
+		go c.SendMessage(ws.OrderChannel, "ERROR", err.Error())
+		return
+	}
+}
+
+// handleEnableCancelOnDisconnect handles EnableCancelOnDisconnect message. Only the connections on
+// which the address logged in with its wallet can have its orders cancelled.
+func (e *orderEndpoint) handleEnableCancelOnDisconnect(ev *types.WebsocketEvent, c *ws.Client) {
+	p := &types.CancelOnDisconnectPayload{}
+
+	bytes, _ := json.Marshal(ev.Payload)
+	err := json.Unmarshal(bytes, p)
+	if err == nil {
+		err = p.Validate()
+	}
+
+	if err != nil {
+		logger.Error(err)
+		go c.SendMessage(ws.OrderChannel, "ERROR", err.Error())
+		return
+	}
+
+	if !ws.GetLoginSocket().IsClientLoggedIn(p.Address, c) {
+		go c.SendMessage(ws.OrderChannel, "ERROR", "client not logged in to this address")
+		return
+	}
+
+	if p.Timeout == 0 {
+		p.Timeout = app.Config.CancelOnDisconnectTimeout
+	}
+
+	e.orderService.EnableCancelOnDisconnect(c, p.Address, time.Duration(p.Timeout)*time.Second)
+	go c.SendMessage(ws.OrderChannel, "CANCEL_ON_DISCONNECT_ENABLED", p)
+}
+
+// handleDisableCancelOnDisconnect handles DisableCancelOnDisconnect message
+func (e *orderEndpoint) handleDisableCancelOnDisconnect(ev *types.WebsocketEvent, c *ws.Client) {
+	address, ok := ev.Payload.(string)
+	if !ok {
+		go c.SendMessage(ws.OrderChannel, "ERROR", "bad type of payload")
+		return
+	}
+
+	e.orderService.DisableCancelOnDisconnect(c, address)
+	go c.SendMessage(ws.OrderChannel, "CANCEL_ON_DISCONNECT_DISABLED", address)
+}
+
+// handleHeartbeat handles Heartbeat message, which postpones the auto-cancellation of the orders
+// of the address
+func (e *orderEndpoint) handleHeartbeat(ev *types.WebsocketEvent, c *ws.Client) {
+	address, ok := ev.Payload.(string)
+	if !ok {
+		go c.SendMessage(ws.OrderChannel, "ERROR", "bad type of payload")
+		return
+	}
+
+	if !e.orderService.Heartbeat(c, address) {
+		go c.SendMessage(ws.OrderChannel, "ERROR", "cancel-on-disconnect not enabled for this address")
+	}
+}
+
 func (e *orderEndpoint) handleAddress(ev *types.WebsocketEvent, c *ws.Client) {
 	if reflect.TypeOf(ev.Payload).Kind() != reflect.String {
 		logger.Error("bad type of payload")
@@ -0,0 +1,241 @@
+package endpoints
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/byteball/odex-backend/app"
+	"github.com/byteball/odex-backend/interfaces"
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils/httputils"
+	"github.com/byteball/odex-backend/ws"
+	"github.com/gorilla/mux"
+)
+
+type privateEndpoint struct {
+	apiKeyService interfaces.APIKeyService
+	orderService  interfaces.OrderService
+	obyteProvider interfaces.ObyteProvider
+}
+
+// privateHandler handles a request authenticated by an API key
+type privateHandler func(w http.ResponseWriter, r *http.Request, k *types.APIKey)
+
+// ServePrivateResource sets up the routing of the private API. The API keys are issued to the
+// address logged in to a session, the other requests must be signed with an API key and act on
+// behalf of its address.
+func ServePrivateResource(
+	r *mux.Router,
+	apiKeyService interfaces.APIKeyService,
+	orderService interfaces.OrderService,
+	obyteProvider interfaces.ObyteProvider,
+) {
+	e := &privateEndpoint{apiKeyService, orderService, obyteProvider}
+	r.HandleFunc("/api-keys", e.handleCreateAPIKey).Methods("POST")
+	r.HandleFunc("/api-keys", e.authenticate(e.handleGetAPIKeys)).Methods("GET")
+	r.HandleFunc("/api-keys/{key}", e.authenticate(e.handleRevokeAPIKey)).Methods("DELETE")
+	r.HandleFunc("/private/orders", e.authenticate(e.handleGetOpenOrders)).Methods("GET")
+	r.HandleFunc("/private/orders", e.authenticate(e.handleNewOrder)).Methods("POST")
+	r.HandleFunc("/private/orders", e.authenticate(e.handleCancelAllOrders)).Methods("DELETE")
+	r.HandleFunc("/private/orders/{hash}", e.authenticate(e.handleCancelOrder)).Methods("DELETE")
+}
+
+// authenticate rejects the requests without a valid signature by an API key
+func (e *privateEndpoint) authenticate(h privateHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			logger.Error(err)
+			httputils.WriteError(w, http.StatusBadRequest, "Invalid payload")
+			return
+		}
+
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		k, err := e.apiKeyService.Authenticate(
+			r.Header.Get(types.APIKeyHeader),
+			r.Header.Get(types.APITimestampHeader),
+			r.Header.Get(types.APISignatureHeader),
+			r.Method,
+			r.URL.RequestURI(),
+			body,
+		)
+
+		if err != nil {
+			httputils.WriteError(w, http.StatusUnauthorized, err.Error())
+			return
+		}
+
+		h(w, r, k)
+	}
+}
+
+func (e *privateEndpoint) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	payload := struct {
+		SessionId string `json:"sessionId"`
+		Token     string `json:"token"`
+		Label     string `json:"label"`
+	}{}
+
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+
+	err := decoder.Decode(&payload)
+	if err != nil || payload.SessionId == "" || payload.Token == "" {
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	timeout := time.Duration(app.Config.APIKeyLoginTimeout) * time.Second
+	address := ws.GetLoginSocket().ClaimSession(payload.SessionId, payload.Token, timeout)
+	if address == "" {
+		httputils.WriteError(w, http.StatusUnauthorized, "No recent login for this session")
+		return
+	}
+
+	k, err := e.apiKeyService.CreateKey(address, payload.Label)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusCreated, k)
+}
+
+func (e *privateEndpoint) handleGetAPIKeys(w http.ResponseWriter, r *http.Request, k *types.APIKey) {
+	res, err := e.apiKeyService.GetKeys(k.Address)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	if res == nil {
+		httputils.WriteJSON(w, http.StatusOK, []*types.APIKey{})
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (e *privateEndpoint) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request, k *types.APIKey) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+
+	err := e.apiKeyService.RevokeKey(k.Address, key)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, map[string]string{"key": key})
+}
+
+func (e *privateEndpoint) handleGetOpenOrders(w http.ResponseWriter, r *http.Request, k *types.APIKey) {
+	orders, err := e.orderService.GetCurrentByUserAddress(k.Address)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	if orders == nil {
+		orders = []*types.Order{}
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, orders)
+}
+
+// handleNewOrder sends an order signed by the address of the API key to the node, which
+// validates it and emits it back to the order service like the orders sent by websocket
+func (e *privateEndpoint) handleNewOrder(w http.ResponseWriter, r *http.Request, k *types.APIKey) {
+	var signedOrder interface{}
+
+	decoder := json.NewDecoder(r.Body)
+	defer r.Body.Close()
+
+	err := decoder.Decode(&signedOrder)
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid payload")
+		return
+	}
+
+	address, err := types.SignedOrderAddress(signedOrder)
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if address != k.Address {
+		httputils.WriteError(w, http.StatusForbidden, "Order not signed by the address of the API key")
+		return
+	}
+
+	hash, err := e.obyteProvider.AddOrder(&signedOrder)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusCreated, map[string]string{"hash": hash})
+}
+
+func (e *privateEndpoint) handleCancelOrder(w http.ResponseWriter, r *http.Request, k *types.APIKey) {
+	vars := mux.Vars(r)
+	hash := vars["hash"]
+
+	o, err := e.orderService.GetByHash(hash)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	if o == nil || o.UserAddress != k.Address {
+		httputils.WriteError(w, http.StatusNotFound, "Order not found")
+		return
+	}
+
+	err = e.orderService.CancelOrder(&types.OrderCancel{OrderHash: hash, UserAddress: k.Address})
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, map[string]string{"hash": hash})
+}
+
+// handleCancelAllOrders cancels the open orders of the address of the API key, optionally
+// restricted to a pair and a side, and returns the summary of the cancelled orders
+func (e *privateEndpoint) handleCancelAllOrders(w http.ResponseWriter, r *http.Request, k *types.APIKey) {
+	v := r.URL.Query()
+	oca := &types.OrderCancelAll{
+		UserAddress: k.Address,
+		Address:     k.Address,
+		PairName:    v.Get("pairName"),
+		Side:        v.Get("side"),
+	}
+
+	err := oca.Validate()
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	summary, err := e.orderService.CancelAllOrders(oca)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, summary)
+}
@@ -0,0 +1,178 @@
+package endpoints
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/byteball/odex-backend/app"
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils/testutils/mocks"
+	"github.com/byteball/odex-backend/ws"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func SetupPrivateTest() (*mux.Router, *mocks.APIKeyService, *mocks.OrderService, *mocks.ObyteProvider) {
+	r := mux.NewRouter()
+	apiKeyService := new(mocks.APIKeyService)
+	orderService := new(mocks.OrderService)
+	provider := new(mocks.ObyteProvider)
+
+	ServePrivateResource(r, apiKeyService, orderService, provider)
+
+	return r, apiKeyService, orderService, provider
+}
+
+// loginTestSocket connects to the websocket server and subscribes to the login channel of a session
+func loginTestSocket(t *testing.T, server *httptest.Server, sessionId string) *websocket.Conn {
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	conn.WriteJSON(types.WebsocketMessage{Channel: ws.LoginChannel, Event: types.WebsocketEvent{Type: "SUBSCRIBE", Payload: sessionId}})
+
+	// the events of a connection are handled in order, the error answering an invalid event
+	// tells that the subscription is done
+	conn.WriteJSON(types.WebsocketMessage{Channel: ws.LoginChannel, Event: types.WebsocketEvent{Type: "PING"}})
+	nextLoginMessage(t, conn, "ERROR")
+
+	return conn
+}
+
+func nextLoginMessage(t *testing.T, conn *websocket.Conn, msgType string) interface{} {
+	for {
+		msg := types.WebsocketMessage{}
+		err := conn.ReadJSON(&msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if msg.Channel == ws.LoginChannel && msg.Event.Type == msgType {
+			return msg.Event.Payload
+		}
+	}
+}
+
+func TestHandleCreateAPIKey(t *testing.T) {
+	router, apiKeyService, _, _ := SetupPrivateTest()
+	ServeLoginResource(router)
+	app.Config.APIKeyLoginTimeout = 600
+
+	server := httptest.NewServer(http.HandlerFunc(ws.ConnectionEndpoint))
+	defer server.Close()
+
+	conn := loginTestSocket(t, server, "session")
+	defer conn.Close()
+
+	other := loginTestSocket(t, server, "other")
+	defer other.Close()
+
+	k := &types.APIKey{Key: "key", Secret: "secret", Address: "ADDRESS", Label: "bot"}
+	apiKeyService.On("CreateKey", "ADDRESS", "bot").Return(k, nil)
+
+	// the token of the login is sent only to the connections subscribed to the session
+	ws.GetLoginSocket().LinkAddressToClient("session", "ADDRESS")
+	payload := nextLoginMessage(t, conn, "TOKEN").(map[string]interface{})
+	assert.Equal(t, "session", payload["sessionId"])
+	token := payload["token"].(string)
+
+	other.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	_, _, err := other.ReadMessage()
+	assert.NotNil(t, err)
+
+	claim := func(body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api-keys", bytes.NewReader([]byte(body)))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// the session id alone is not enough to claim the login
+	rr := claim(`{"sessionId":"session","label":"bot"}`)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = claim(`{"sessionId":"session","token":"` + strings.Repeat("0", len(token)) + `","label":"bot"}`)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	body := `{"sessionId":"session","token":"` + token + `","label":"bot"}`
+	rr = claim(body)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.JSONEq(t, `{"data":{"key":"key","secret":"secret","address":"ADDRESS","label":"bot","createdAt":"0001-01-01T00:00:00Z"}}`, rr.Body.String())
+
+	// a session can be exchanged for a key only once
+	rr = claim(body)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	apiKeyService.AssertNumberOfCalls(t, "CreateKey", 1)
+}
+
+func TestHandlePrivateOrders(t *testing.T) {
+	router, apiKeyService, orderService, provider := SetupPrivateTest()
+
+	address := "TRADERADDRESS0000000000000000000"
+	k := &types.APIKey{Key: "key", Address: address}
+	apiKeyService.On("Authenticate", "key", "1500000000000", "signature", mock.Anything, mock.Anything, mock.Anything).Return(k, nil)
+	apiKeyService.On("Authenticate", "", "", "", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("Invalid API key or signature"))
+
+	own := &types.Order{Hash: "own", UserAddress: address}
+	other := &types.Order{Hash: "other", UserAddress: "OTHERADDRESS00000000000000000000"}
+	orderService.On("GetByHash", "own").Return(own, nil)
+	orderService.On("GetByHash", "other").Return(other, nil)
+	orderService.On("GetCurrentByUserAddress", address).Return([]*types.Order{own}, nil)
+	orderService.On("CancelOrder", &types.OrderCancel{OrderHash: "own", UserAddress: address}).Return(nil)
+	summary := &types.OrderCancelAllSummary{Address: address, PairName: "GBYTE/USDC", Cancelled: []string{"own"}}
+	orderService.On("CancelAllOrders", &types.OrderCancelAll{UserAddress: address, Address: address, PairName: "GBYTE/USDC"}).Return(summary, nil)
+	provider.On("AddOrder", mock.Anything).Return("hash", nil)
+
+	send := func(method string, url string, body string, signed bool) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, url, bytes.NewReader([]byte(body)))
+		if signed {
+			req.Header.Set(types.APIKeyHeader, "key")
+			req.Header.Set(types.APITimestampHeader, "1500000000000")
+			req.Header.Set(types.APISignatureHeader, "signature")
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := send("GET", "/private/orders", "", false)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	rr = send("GET", "/private/orders", "", true)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = send("POST", "/private/orders", `{"signed_message":{"address":"OTHERADDRESS00000000000000000000"}}`, true)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	provider.AssertNotCalled(t, "AddOrder", mock.Anything)
+
+	rr = send("POST", "/private/orders", `{"signed_message":{"address":"`+address+`"}}`, true)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.JSONEq(t, `{"data":{"hash":"hash"}}`, rr.Body.String())
+
+	rr = send("DELETE", "/private/orders/other", "", true)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = send("DELETE", "/private/orders/own", "", true)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = send("DELETE", "/private/orders?pairName=GBYTE/USDC", "", true)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"data":{"address":"`+address+`","pairName":"GBYTE/USDC","cancelled":["own"]}}`, rr.Body.String())
+
+	rr = send("DELETE", "/private/orders?side=LONG", "", true)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	orderService.AssertNumberOfCalls(t, "CancelOrder", 1)
+	orderService.AssertNumberOfCalls(t, "CancelAllOrders", 1)
+}
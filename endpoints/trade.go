@@ -81,7 +81,6 @@ func (e *tradeEndpoint) HandleGetTradeHistory(w http.ResponseWriter, r *http.Req
 func (e *tradeEndpoint) HandleGetTrades(w http.ResponseWriter, r *http.Request) {
 	v := r.URL.Query()
 	addr := v.Get("address")
-	limit := v.Get("limit")
 
 	if addr == "" {
 		httputils.WriteError(w, http.StatusBadRequest, "address Parameter missing")
@@ -93,13 +92,13 @@ func (e *tradeEndpoint) HandleGetTrades(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	lim := 100
-	if limit != "" {
-		lim, _ = strconv.Atoi(limit)
+	q, err := parseHistoryQuery(v, addr, 100)
+	if err != nil {
+		httputils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	address := addr
-	res, err := e.tradeService.GetSortedTradesByUserAddress(address, lim)
+	res, nextCursor, err := e.tradeService.GetPageByUserAddress(q)
 	if err != nil {
 		logger.Error(err)
 		httputils.WriteError(w, http.StatusInternalServerError, "")
@@ -107,11 +106,10 @@ func (e *tradeEndpoint) HandleGetTrades(w http.ResponseWriter, r *http.Request)
 	}
 
 	if res == nil {
-		httputils.WriteJSON(w, http.StatusOK, []types.Trade{})
-		return
+		res = []*types.Trade{}
 	}
 
-	httputils.WriteJSON(w, http.StatusOK, res)
+	httputils.WritePage(w, http.StatusOK, res, types.NextCursorHeader, nextCursor)
 }
 
 func (e *tradeEndpoint) tradeWebsocket(input interface{}, c *ws.Client) {
@@ -6,11 +6,15 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/byteball/odex-backend/types"
 	"github.com/byteball/odex-backend/utils/testutils"
 	"github.com/byteball/odex-backend/utils/testutils/mocks"
+	"github.com/globalsign/mgo/bson"
 	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func SetupTradeTest() (*mux.Router, *mocks.TradeService) {
@@ -49,3 +53,49 @@ func TestHandleGetTradeHistory(t *testing.T) {
 	json.NewDecoder(rr.Body)
 
 }
+
+func TestHandleGetTrades(t *testing.T) {
+	router, tradeService := SetupTradeTest()
+
+	address := "TRADERADDRESS0000000000000000000"
+	cursor := types.NewCursor(time.Unix(1500000000, 0), bson.ObjectIdHex("537f700b537461b70c5f0004"))
+	trs := []*types.Trade{&types.Trade{Hash: "0x1"}, &types.Trade{Hash: "0x2"}}
+
+	tradeService.On("GetPageByUserAddress", mock.MatchedBy(func(q *types.HistoryQuery) bool {
+		return q.Address == address && q.Limit == 2 && q.Side == "BUY" && q.PairName == "ZRX/WETH" &&
+			q.Cursor.ID == cursor.ID && q.From.Equal(time.Unix(1400000000, 0)) && q.To.IsZero()
+	})).Return(trs, "next", nil)
+
+	req, _ := http.NewRequest("GET", "/trades?address="+address+"&limit=2&side=buy&pairName="+url.QueryEscape("ZRX/WETH")+"&cursor="+cursor.Encode()+"&from=1400000000", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	res := struct {
+		Data []map[string]interface{} `json:"data"`
+	}{}
+	json.NewDecoder(rr.Body).Decode(&res)
+	assert.Equal(t, 2, len(res.Data))
+	assert.Equal(t, "0x2", res.Data[1]["hash"])
+	assert.Equal(t, "next", rr.Header().Get(types.NextCursorHeader))
+
+	// no cursor after the last page
+	tradeService.On("GetPageByUserAddress", mock.MatchedBy(func(q *types.HistoryQuery) bool {
+		return q.Address == address && q.Cursor == nil
+	})).Return(trs, "", nil)
+
+	req, _ = http.NewRequest("GET", "/trades?address="+address, nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "", rr.Header().Get(types.NextCursorHeader))
+
+	for _, params := range []string{"&cursor=invalid", "&side=LONG", "&limit=ten", "&from=1400000000&to=1300000000"} {
+		req, _ = http.NewRequest("GET", "/trades?address="+address+params, nil)
+		rr = httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, params)
+	}
+
+	tradeService.AssertExpectations(t)
+}
@@ -0,0 +1,81 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/byteball/odex-backend/interfaces"
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils/httputils"
+	"github.com/byteball/odex-backend/ws"
+	"github.com/gorilla/mux"
+)
+
+type watchdogEndpoint struct {
+	watchdogService interfaces.WatchdogService
+}
+
+// ServeWatchdogResource sets up the routing of the matcher alert endpoint and websocket channel
+func ServeWatchdogResource(
+	r *mux.Router,
+	watchdogService interfaces.WatchdogService,
+) {
+	e := &watchdogEndpoint{watchdogService}
+	r.HandleFunc("/matchers/alerts", e.handleGetAlerts).Methods("GET")
+	ws.RegisterChannel(ws.MatcherAlertChannel, e.matcherAlertWebsocket)
+}
+
+func (e *watchdogEndpoint) handleGetAlerts(w http.ResponseWriter, r *http.Request) {
+	v := r.URL.Query()
+	matcherAddress := v.Get("matcherAddress")
+	limit := v.Get("limit")
+
+	if matcherAddress != "" && !isValidAddress(matcherAddress) {
+		httputils.WriteError(w, http.StatusBadRequest, "Invalid matcher address")
+		return
+	}
+
+	lim := 100
+	if limit != "" {
+		lim, _ = strconv.Atoi(limit)
+	}
+
+	res, err := e.watchdogService.GetAlerts(matcherAddress, lim)
+	if err != nil {
+		logger.Error(err)
+		httputils.WriteError(w, http.StatusInternalServerError, "")
+		return
+	}
+
+	if res == nil {
+		httputils.WriteJSON(w, http.StatusOK, []*types.MatcherAlert{})
+		return
+	}
+
+	httputils.WriteJSON(w, http.StatusOK, res)
+}
+
+func (e *watchdogEndpoint) matcherAlertWebsocket(input interface{}, c *ws.Client) {
+	b, _ := json.Marshal(input)
+	var ev *types.WebsocketEvent
+	if err := json.Unmarshal(b, &ev); err != nil {
+		logger.Error(err)
+		return
+	}
+
+	socket := ws.GetMatcherAlertSocket()
+	if ev.Type != "SUBSCRIBE" && ev.Type != "UNSUBSCRIBE" {
+		err := map[string]string{"Message": "Invalid payload"}
+		socket.SendErrorMessage(c, err)
+		return
+	}
+
+	if ev.Type == "SUBSCRIBE" {
+		e.watchdogService.Subscribe(c)
+	}
+
+	if ev.Type == "UNSUBSCRIBE" {
+		e.watchdogService.Unsubscribe(c)
+	}
+}
@@ -0,0 +1,60 @@
+package endpoints
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils/testutils/mocks"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func SetupWatchdogTest() (*mux.Router, *mocks.WatchdogService) {
+	r := mux.NewRouter()
+	watchdogService := new(mocks.WatchdogService)
+
+	ServeWatchdogResource(r, watchdogService)
+
+	return r, watchdogService
+}
+
+func TestHandleGetMatcherAlerts(t *testing.T) {
+	router, watchdogService := SetupWatchdogTest()
+
+	matcherAddress := "MATCHERADDRESS000000000000000000"
+	alerts := []*types.MatcherAlert{
+		&types.MatcherAlert{Type: types.FeeOverchargeAlert, MatcherAddress: matcherAddress, OrderHash: "0xorder"},
+	}
+
+	watchdogService.On("GetAlerts", matcherAddress, 10).Return(alerts, nil)
+	watchdogService.On("GetAlerts", "", 100).Return(nil, nil)
+
+	req, _ := http.NewRequest("GET", "/matchers/alerts?matcherAddress="+matcherAddress+"&limit=10", nil)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	res := struct {
+		Data []*types.MatcherAlert `json:"data"`
+	}{}
+	json.NewDecoder(rr.Body).Decode(&res)
+	assert.Equal(t, 1, len(res.Data))
+	assert.Equal(t, types.FeeOverchargeAlert, res.Data[0].Type)
+	assert.Equal(t, "0xorder", res.Data[0].OrderHash)
+
+	req, _ = http.NewRequest("GET", "/matchers/alerts", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"data":[]}`, rr.Body.String())
+
+	req, _ = http.NewRequest("GET", "/matchers/alerts?matcherAddress=SHORT", nil)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	watchdogService.AssertExpectations(t)
+}
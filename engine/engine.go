@@ -6,33 +6,37 @@ import (
 
 	sync "github.com/sasha-s/go-deadlock"
 
+	"github.com/byteball/odex-backend/app"
 	"github.com/byteball/odex-backend/interfaces"
 	"github.com/byteball/odex-backend/rabbitmq"
 	"github.com/byteball/odex-backend/types"
 	"github.com/byteball/odex-backend/utils"
 )
 
-// Engine
+// Engine dispatches the engine messages to the workers running the orderbook of each pair
 type Engine struct {
-	orderbooks    map[string]*OrderBook
-	rabbitMQConn  *rabbitmq.Connection
+	workers       map[string]*worker
+	mutex         *sync.RWMutex
+	rabbitMQConn  interfaces.EnginePublisher
 	orderDao      interfaces.OrderDao
 	tradeDao      interfaces.TradeDao
 	pairDao       interfaces.PairDao
 	obyteProvider interfaces.ObyteProvider
 	orderService  interfaces.OrderService
+	journalDao    interfaces.JournalDao
 }
 
 var logger = utils.EngineLogger
 
 // NewEngine initializes the engine singleton instance
 func NewEngine(
-	rabbitMQConn *rabbitmq.Connection,
+	rabbitMQConn interfaces.EnginePublisher,
 	orderDao interfaces.OrderDao,
 	tradeDao interfaces.TradeDao,
 	pairDao interfaces.PairDao,
 	obyteProvider interfaces.ObyteProvider,
 	orderService interfaces.OrderService,
+	journalDao interfaces.JournalDao,
 ) *Engine {
 	pairs, err := pairDao.GetAll()
 
@@ -40,53 +44,91 @@ func NewEngine(
 		panic(err)
 	}
 
-	obs := map[string]*OrderBook{}
-	for _, p := range pairs {
-		ob := &OrderBook{
-			rabbitMQConn:  rabbitMQConn,
-			orderDao:      orderDao,
-			tradeDao:      tradeDao,
-			pair:          &p,
-			mutex:         &sync.Mutex{},
-			obyteProvider: obyteProvider,
-			orderService:  orderService,
-		}
-
-		obs[p.Code()] = ob
-	}
-
 	engine := &Engine{
-		obs,
+		map[string]*worker{},
+		&sync.RWMutex{},
 		rabbitMQConn,
 		orderDao,
 		tradeDao,
 		pairDao,
 		obyteProvider,
 		orderService,
+		journalDao,
+	}
+
+	for _, p := range pairs {
+		ob, err := engine.newOrderBook(p)
+		if err != nil {
+			panic(err)
+		}
+
+		engine.workers[p.Code()] = newWorker(ob)
 	}
 
 	return engine
 }
 
-// HandleOrders parses incoming rabbitmq order messages and redirects them to the appropriate
-// engine function
+// newOrderBook creates the orderbook of a pair and restores its state from the
+// engine journal
+func (e *Engine) newOrderBook(p types.Pair) (*OrderBook, error) {
+	ob := &OrderBook{
+		rabbitMQConn:  e.rabbitMQConn,
+		orderDao:      e.orderDao,
+		tradeDao:      e.tradeDao,
+		pair:          &p,
+		mutex:         &sync.Mutex{},
+		obyteProvider: e.obyteProvider,
+		orderService:  e.orderService,
+		book:          newPriceLevels(),
+		triggers:      newTriggerBook(),
+
+		selfTradePrevention: app.Config.SelfTradePrevention,
+
+		journalDao:       e.journalDao,
+		snapshotInterval: app.Config.EngineSnapshotInterval,
+	}
+
+	err := ob.restore()
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return ob, nil
+}
+
+// HandleOrders parses incoming rabbitmq order messages and dispatches them to the worker
+// of their pair. The worker acknowledges a message once it is journaled and processed, a
+// message that can't be dispatched is rejected by the subscriber on the returned error.
 func (e *Engine) HandleOrders(msg *rabbitmq.Message) error {
 	//logger.Info("HandleOrders", msg)
 	switch msg.Type {
 	case "NEW_ORDER":
-		err := e.handleNewOrder(msg.Data)
+		err := e.handleNewOrder(msg)
 		if err != nil {
 			logger.Error(err)
 			return err
 		}
 	case "ADD_ORDER":
-		err := e.handleAddOrder(msg.Data)
+		err := e.handleAddOrder(msg)
 		if err != nil {
 			logger.Error(err)
 			return err
 		}
 	case "CANCEL_ORDER":
-		err := e.handleCancelOrder(msg.Data)
+		err := e.handleCancelOrder(msg)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+	case "AMEND_ORDER":
+		err := e.handleAmendOrder(msg)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+	case "RESTORE_ORDER":
+		err := e.handleRestoreOrder(msg)
 		if err != nil {
 			logger.Error(err)
 			return err
@@ -105,14 +147,59 @@ func (e *Engine) HandleOrders(msg *rabbitmq.Message) error {
 	// 	}
 	default:
 		logger.Error("Unknown message", msg)
+		return errors.New("Unknown message")
 	}
 
 	return nil
 }
 
-func (e *Engine) handleAddOrder(bytes []byte) error {
+// worker returns the worker of the pair with the given code, nil if the pair is unknown
+func (e *Engine) worker(code string) *worker {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	return e.workers[code]
+}
+
+// orderbook returns the orderbook of the pair with the given code, nil if the pair is unknown
+func (e *Engine) orderbook(code string) *OrderBook {
+	w := e.worker(code)
+	if w == nil {
+		return nil
+	}
+
+	return w.orderbook
+}
+
+// addWorker starts the worker of a pair listed after the engine started
+func (e *Engine) addWorker(code string, o *types.Order) (*worker, error) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	w := e.workers[code]
+	if w != nil {
+		return w, nil
+	}
+
+	p, err := e.pairDao.GetByAsset(o.BaseToken, o.QuoteToken)
+	if err != nil || p == nil {
+		return nil, errors.New("Unknown pair")
+	}
+
+	ob, err := e.newOrderBook(*p)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	w = newWorker(ob)
+	e.workers[code] = w
+	return w, nil
+}
+
+func (e *Engine) handleAddOrder(msg *rabbitmq.Message) error {
 	o := &types.Order{}
-	err := json.Unmarshal(bytes, o)
+	err := json.Unmarshal(msg.Data, o)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -124,23 +211,18 @@ func (e *Engine) handleAddOrder(bytes []byte) error {
 		return err
 	}
 
-	ob := e.orderbooks[code]
-	if ob == nil {
+	w := e.worker(code)
+	if w == nil {
 		return errors.New("Orderbook error")
 	}
 
-	err = ob.addOrder(o)
-	if err != nil {
-		logger.Error(err)
-		return err
-	}
-
+	w.dispatch("ADD_ORDER", o, msg.ID, msg.Done)
 	return nil
 }
 
-func (e *Engine) handleNewOrder(bytes []byte) error {
+func (e *Engine) handleNewOrder(msg *rabbitmq.Message) error {
 	o := &types.Order{}
-	err := json.Unmarshal(bytes, o)
+	err := json.Unmarshal(msg.Data, o)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -152,38 +234,46 @@ func (e *Engine) handleNewOrder(bytes []byte) error {
 		return err
 	}
 
-	ob := e.orderbooks[code]
-	if ob == nil {
-		p, err := e.pairDao.GetByAsset(o.BaseToken, o.QuoteToken)
+	w := e.worker(code)
+	if w == nil {
+		w, err = e.addWorker(code, o)
 		if err != nil {
-			return errors.New("Unknown pair")
+			logger.Error(err)
+			return err
 		}
+	}
 
-		e.orderbooks[code] = &OrderBook{
-			rabbitMQConn:  e.rabbitMQConn,
-			orderDao:      e.orderDao,
-			tradeDao:      e.tradeDao,
-			pair:          p,
-			mutex:         &sync.Mutex{},
-			obyteProvider: e.obyteProvider,
-			orderService:  e.orderService,
-		}
+	w.dispatch("NEW_ORDER", o, msg.ID, msg.Done)
+	return nil
+}
 
-		ob = e.orderbooks[code]
+func (e *Engine) handleCancelOrder(msg *rabbitmq.Message) error {
+	o := &types.Order{}
+	err := json.Unmarshal(msg.Data, o)
+	if err != nil {
+		logger.Error(err)
+		return err
 	}
 
-	err = ob.newOrder(o)
+	code, err := o.PairCode()
 	if err != nil {
 		logger.Error(err)
 		return err
 	}
 
+	w := e.worker(code)
+	if w == nil {
+		return errors.New("Orderbook error")
+	}
+
+	w.dispatch("CANCEL_ORDER", o, msg.ID, msg.Done)
 	return nil
 }
 
-func (e *Engine) handleCancelOrder(bytes []byte) error {
+// handleAmendOrder dispatches an order replacing a resting order of the same pair
+func (e *Engine) handleAmendOrder(msg *rabbitmq.Message) error {
 	o := &types.Order{}
-	err := json.Unmarshal(bytes, o)
+	err := json.Unmarshal(msg.Data, o)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -195,17 +285,35 @@ func (e *Engine) handleCancelOrder(bytes []byte) error {
 		return err
 	}
 
-	ob := e.orderbooks[code]
-	if ob == nil {
+	w := e.worker(code)
+	if w == nil {
 		return errors.New("Orderbook error")
 	}
 
-	err = ob.cancelOrder(o)
+	w.dispatch("AMEND_ORDER", o, msg.ID, msg.Done)
+	return nil
+}
+
+func (e *Engine) handleRestoreOrder(msg *rabbitmq.Message) error {
+	t := &types.Trade{}
+	err := json.Unmarshal(msg.Data, t)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	code, err := t.PairCode()
 	if err != nil {
 		logger.Error(err)
 		return err
 	}
 
+	w := e.worker(code)
+	if w == nil {
+		return errors.New("Orderbook error")
+	}
+
+	w.restore(t, msg.ID, msg.Done)
 	return nil
 }
 
@@ -0,0 +1,390 @@
+package engine
+
+// Every input of an orderbook (NEW_ORDER, ADD_ORDER, CANCEL_ORDER, AMEND_ORDER and
+// RESTORE_ORDER) is appended to the engine journal with a per-pair sequence before being
+// processed, and the engine responses it produced are recorded once it is processed. Every
+// EngineSnapshotInterval inputs, the in-memory state of the orderbook is saved as a snapshot
+// which replaces the journal entries it covers.
+//
+// At startup, the orderbook is restored from its last snapshot and the journal entries
+// following it are replayed:
+// - processed entries are replayed in memory only, the database already holds their results
+// and their responses were already published. The replayed responses are checked against
+// the recorded ones.
+// - an entry that was not completed (the engine stopped while processing it) is processed
+// again, which persists the state of all the orders it touches and publishes its responses,
+// possibly for the second time.
+//
+// The engine clock and the orders found cancelled by the order service while matching are
+// recorded in the journal entries, so that replaying an entry produces the same matches.
+//
+// An engine message is acknowledged once processed, a message delivered again after a crash
+// may already be journaled. The IDs of the messages are recorded in the journal entries and
+// in the snapshots (the ones of the entries a snapshot replaces), the orderbook skips the
+// messages journaled since the snapshot before the last one.
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/byteball/odex-backend/types"
+)
+
+// process journals an input of the orderbook and processes it. The input of an engine
+// message already journaled is skipped.
+func (ob *OrderBook) process(entryType string, o *types.Order, messageID string) error {
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+
+	if ob.isJournaled(messageID) {
+		logger.Infof("Engine message %s of %s was already journaled, skipping it", messageID, ob.pair.Name())
+		return nil
+	}
+
+	e := &types.JournalEntry{
+		PairCode:  ob.pair.Code(),
+		Sequence:  ob.sequence + 1,
+		Type:      entryType,
+		Order:     o,
+		MessageID: messageID,
+		CreatedAt: time.Now(),
+	}
+
+	err := ob.journalDao.AppendEntry(e)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	ob.sequence = e.Sequence
+	ob.recordMessage(messageID)
+	return ob.run(e)
+}
+
+// isJournaled returns true if the engine message with the given ID was journaled
+func (ob *OrderBook) isJournaled(messageID string) bool {
+	if messageID == "" {
+		return false
+	}
+
+	return ob.messages[messageID] || ob.snapshotMessages[messageID]
+}
+
+// recordMessage records the ID of the engine message of a journal entry
+func (ob *OrderBook) recordMessage(messageID string) {
+	if messageID == "" {
+		return
+	}
+
+	if ob.messages == nil {
+		ob.messages = map[string]bool{}
+	}
+
+	ob.messages[messageID] = true
+}
+
+// run processes a journal entry, records its results and takes a snapshot when due
+func (ob *OrderBook) run(e *types.JournalEntry) error {
+	err := ob.apply(e)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	e.Responses = ob.responses
+	err = ob.journalDao.CompleteEntry(e)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if ob.sequence-ob.snapshotSequence >= ob.snapshotInterval {
+		err = ob.saveSnapshot()
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apply passes the input of a journal entry to the matching functions
+func (ob *OrderBook) apply(e *types.JournalEntry) error {
+	ob.entry = e
+	ob.now = e.CreatedAt
+	ob.responses = []*types.EngineResponse{}
+
+	switch e.Type {
+	case "NEW_ORDER":
+		return ob.newOrder(e.Order)
+	case "ADD_ORDER":
+		return ob.addOrder(e.Order)
+	case "CANCEL_ORDER":
+		return ob.cancelOrder(e.Order)
+	case "AMEND_ORDER":
+		return ob.amendOrder(e.Order)
+	case "RESTORE_ORDER":
+		return ob.addRestoredOrder(e.Order)
+	}
+
+	return errors.New("Unknown journal entry type: " + e.Type)
+}
+
+// replay applies a journal entry following the last snapshot
+func (ob *OrderBook) replay(e *types.JournalEntry) error {
+	ob.sequence = e.Sequence
+	ob.recordMessage(e.MessageID)
+
+	if !e.Processed {
+		logger.Warningf("Journal entry %d of %s was not completed, processing it again", e.Sequence, e.PairCode)
+		return ob.run(e)
+	}
+
+	ob.replaying = true
+	err := ob.apply(e)
+	ob.replaying = false
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if !sameResponses(ob.responses, e.Responses) {
+		logger.Errorf("Replay of journal entry %d of %s does not match the recorded engine responses", e.Sequence, e.PairCode)
+	}
+
+	return nil
+}
+
+// restore rebuilds the orderbook from its last snapshot and the journal. Without a snapshot,
+// the orderbook is loaded from the database and a first snapshot is taken.
+func (ob *OrderBook) restore() error {
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+
+	code := ob.pair.Code()
+	s, err := ob.journalDao.GetLatestSnapshot(code)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if s == nil {
+		err = ob.loadOrders()
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		// journal entries left without a snapshot can not be replayed, the new snapshot replaces them
+		entries, err := ob.journalDao.GetEntries(code, 0)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		for _, e := range entries {
+			ob.sequence = e.Sequence
+			ob.recordMessage(e.MessageID)
+		}
+
+		return ob.saveSnapshot()
+	}
+
+	ob.book.restore(s.Orders)
+	ob.triggers.restore(s.TriggerOrders, s.LastPrice, s.ReleasedOrders, s.CancelledOrders)
+	ob.sequence = s.Sequence
+	ob.snapshotSequence = s.Sequence
+	ob.messages = map[string]bool{}
+	ob.snapshotMessages = map[string]bool{}
+	for _, id := range s.Messages {
+		ob.snapshotMessages[id] = true
+	}
+
+	entries, err := ob.journalDao.GetEntries(code, s.Sequence)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	for _, e := range entries {
+		err := ob.replay(e)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+	}
+
+	logger.Infof("Restored %s from snapshot %d and %d journal entries", ob.pair.Name(), s.Sequence, len(entries))
+
+	if ob.sequence-ob.snapshotSequence >= ob.snapshotInterval {
+		return ob.saveSnapshot()
+	}
+
+	return nil
+}
+
+// saveSnapshot saves the current state of the orderbook
+func (ob *OrderBook) saveSnapshot() error {
+	s := &types.EngineSnapshot{
+		PairCode:        ob.pair.Code(),
+		Sequence:        ob.sequence,
+		Orders:          ob.book.all(),
+		TriggerOrders:   ob.triggers.all(),
+		LastPrice:       ob.triggers.lastPrice,
+		ReleasedOrders:  keys(ob.triggers.released),
+		CancelledOrders: keys(ob.triggers.cancelled),
+		Messages:        keys(ob.messages),
+	}
+
+	err := ob.journalDao.SaveSnapshot(s)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	ob.snapshotSequence = ob.sequence
+	ob.snapshotMessages = ob.messages
+	ob.messages = map[string]bool{}
+	return nil
+}
+
+// persist saves the state of an order, unless the journal is being replayed
+func (ob *OrderBook) persist(o *types.Order) error {
+	if ob.replaying {
+		return nil
+	}
+
+	_, err := ob.orderDao.FindAndModify(o.Hash, o)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// persistStatus saves the status of an order, unless the journal is being replayed
+func (ob *OrderBook) persistStatus(hash string, status string) error {
+	if ob.replaying {
+		return nil
+	}
+
+	err := ob.orderDao.UpdateOrderStatus(hash, status)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// publish records an engine response in the journal entry being processed and publishes it,
+// unless the journal is being replayed
+func (ob *OrderBook) publish(res *types.EngineResponse) error {
+	ob.responses = append(ob.responses, res)
+	if ob.replaying {
+		return nil
+	}
+
+	err := ob.rabbitMQConn.PublishEngineResponse(res)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// publishNewOrder sends an order back to the engine, unless the journal is being replayed.
+// The order comes back as a new journal entry.
+func (ob *OrderBook) publishNewOrder(o *types.Order) error {
+	if ob.replaying {
+		return nil
+	}
+
+	err := ob.rabbitMQConn.PublishNewOrderMessage(o)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// fixOrderStatus applies the cancellations received by the order service while the order was
+// in the pipeline. When replaying, the cancellations recorded in the journal entry are applied.
+func (ob *OrderBook) fixOrderStatus(o *types.Order) {
+	if ob.replaying {
+		for _, h := range ob.entry.CancelledOrders {
+			if h == o.Hash {
+				o.Status = "CANCELLED"
+			}
+		}
+
+		return
+	}
+
+	status := o.Status
+	ob.orderService.FixOrderStatus(o)
+
+	if ob.entry != nil && o.Status == "CANCELLED" && status != "CANCELLED" {
+		ob.entry.CancelledOrders = append(ob.entry.CancelledOrders, o.Hash)
+	}
+}
+
+// sameResponses returns true if two lists of engine responses have the same statuses,
+// orders and trades
+func sameResponses(a []*types.EngineResponse, b []*types.EngineResponse) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Status != b[i].Status {
+			return false
+		}
+
+		if (a[i].Order == nil) != (b[i].Order == nil) {
+			return false
+		}
+
+		if a[i].Order != nil && a[i].Order.Hash != b[i].Order.Hash {
+			return false
+		}
+
+		var tradesA, tradesB []*types.Trade
+		if a[i].Matches != nil {
+			tradesA = a[i].Matches.Trades
+		}
+
+		if b[i].Matches != nil {
+			tradesB = b[i].Matches.Trades
+		}
+
+		if len(tradesA) != len(tradesB) {
+			return false
+		}
+
+		for j := range tradesA {
+			if tradesA[j].Hash != tradesB[j].Hash ||
+				tradesA[j].Amount != tradesB[j].Amount ||
+				tradesA[j].QuoteAmount != tradesB[j].QuoteAmount {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func keys(m map[string]bool) []string {
+	res := []string{}
+	for k := range m {
+		res = append(res, k)
+	}
+
+	sort.Strings(res)
+	return res
+}
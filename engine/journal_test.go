@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	sync "github.com/sasha-s/go-deadlock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils/testutils"
+	"github.com/byteball/odex-backend/utils/testutils/mocks"
+)
+
+// newReplayTest returns an orderbook restored from a snapshot holding a sell order and a
+// journal entry with a buy order matching half of it. The orderbook has no order dao and
+// no rabbitmq connection: replaying processed entries must not write or publish anything.
+func newReplayTest(responses []*types.EngineResponse) (*OrderBook, *types.Order) {
+	pair := testutils.GetZRXWETHTestPair()
+	matcherAddress := testutils.GetTestAddress1()
+	factory1, _ := testutils.NewOrderFactory(pair, testutils.GetTestWallet1(), matcherAddress)
+	factory2, _ := testutils.NewOrderFactory(pair, testutils.GetTestWallet2(), matcherAddress)
+
+	sell, _ := factory1.NewSellOrder(1e3, 1e8)
+	buy, _ := factory2.NewBuyOrder(1e3, 5e7)
+
+	snapshot := &types.EngineSnapshot{
+		PairCode: pair.Code(),
+		Sequence: 10,
+		Orders:   []*types.Order{&sell},
+		Messages: []string{"m10"},
+	}
+
+	entries := []*types.JournalEntry{{
+		PairCode:  pair.Code(),
+		Sequence:  11,
+		Type:      "NEW_ORDER",
+		Order:     &buy,
+		MessageID: "m11",
+		Responses: responses,
+		Processed: true,
+		CreatedAt: time.Now(),
+	}}
+
+	journalDao := new(mocks.JournalDao)
+	journalDao.On("GetLatestSnapshot", pair.Code()).Return(snapshot, nil)
+	journalDao.On("GetEntries", pair.Code(), int64(10)).Return(entries, nil)
+
+	obyteProvider := new(mocks.ObyteProvider)
+	obyteProvider.On("GetOperatorAddress").Return(matcherAddress)
+
+	ob := &OrderBook{
+		pair:             pair,
+		mutex:            &sync.Mutex{},
+		obyteProvider:    obyteProvider,
+		orderService:     new(mocks.OrderService),
+		book:             newPriceLevels(),
+		triggers:         newTriggerBook(),
+		journalDao:       journalDao,
+		snapshotInterval: 1000,
+	}
+
+	return ob, &sell
+}
+
+func TestJournalReplay(t *testing.T) {
+	ob, sell := newReplayTest(nil)
+
+	err := ob.restore()
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Equal(t, int64(11), ob.sequence)
+	assert.Equal(t, int64(10), ob.snapshotSequence)
+	assert.Equal(t, 1, ob.book.len())
+	assert.Equal(t, int64(5e7), ob.book.get(sell.Hash).FilledAmount)
+
+	assert.Len(t, ob.responses, 1)
+	assert.Equal(t, "ORDER_FILLED", ob.responses[0].Status)
+	assert.Equal(t, []int64{5e7}, ob.responses[0].Matches.TradeAmounts())
+
+	// replaying the same journal gives the same matches
+	replayed, _ := newReplayTest(ob.responses)
+	err = replayed.restore()
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.True(t, sameResponses(ob.responses, replayed.responses))
+	assert.Equal(t, ob.book.all()[0].FilledAmount, replayed.book.all()[0].FilledAmount)
+}
+
+// TestJournalSkipsJournaledMessages checks that the engine messages delivered again after a
+// restore are acknowledged without being processed a second time
+func TestJournalSkipsJournaledMessages(t *testing.T) {
+	ob, sell := newReplayTest(nil)
+
+	err := ob.restore()
+	if err != nil {
+		t.Error(err)
+	}
+
+	pair := testutils.GetZRXWETHTestPair()
+	factory2, _ := testutils.NewOrderFactory(pair, testutils.GetTestWallet2(), testutils.GetTestAddress1())
+	w := newWorker(ob)
+
+	// m11 is the message of the replayed journal entry, m10 the one of an entry replaced by
+	// the snapshot
+	for _, id := range []string{"m11", "m10"} {
+		buy, _ := factory2.NewBuyOrder(1e3, 5e7)
+		done := make(chan error, 1)
+		w.dispatch("NEW_ORDER", &buy, id, func(err error) { done <- err })
+
+		assert.Nil(t, <-done)
+	}
+
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+
+	assert.Equal(t, int64(11), ob.sequence)
+	assert.Equal(t, int64(5e7), ob.book.get(sell.Hash).FilledAmount)
+	ob.journalDao.(*mocks.JournalDao).AssertNotCalled(t, "AppendEntry", mock.Anything)
+}
+
+func TestSameResponses(t *testing.T) {
+	trade := &types.Trade{Hash: "t1", Amount: 10, QuoteAmount: 20}
+	a := []*types.EngineResponse{{
+		Status:  "ORDER_FILLED",
+		Order:   &types.Order{Hash: "o1"},
+		Matches: &types.Matches{Trades: []*types.Trade{trade}},
+	}}
+
+	b := []*types.EngineResponse{{
+		Status:  "ORDER_FILLED",
+		Order:   &types.Order{Hash: "o1"},
+		Matches: &types.Matches{Trades: []*types.Trade{{Hash: "t1", Amount: 10, QuoteAmount: 20}}},
+	}}
+
+	assert.True(t, sameResponses(a, b))
+
+	b[0].Matches.Trades[0].Amount = 11
+	assert.False(t, sameResponses(a, b))
+
+	assert.False(t, sameResponses(a, nil))
+}
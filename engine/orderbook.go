@@ -1,59 +1,125 @@
 package engine
 
-// The orderbook currently uses the four following data structures to store engine
-// state in redis
-// 1. Pricepoints set
-// 2. Pricepoints volume set
-// 3. Pricepoints hashes set
-// 4. Orders map
-
-// 1. The pricepoints set is an ordered set that store all pricepoints.
-// Keys: ~ pair addresses + side (BUY or SELL)
-// Values: pricepoints set (sorted set but all ranks are actually 0)
-
-// 2. The pricepoints volume set is an order set that store the volume for a given pricepoint
-// Keys: pair addresses + side + pricepoint
-// Values: volume for corresponding (pair, pricepoint)
-
-// 3. The pricepoints hashes set is an ordered set that stores a set of hashes ranked by creation time for a given pricepoint
-// Keys: pair addresses + side + pricepoint
-// Values: hashes of orders with corresponding pricepoint
-
-// 4. The orders hashmap is a mapping that stores serialized orders
-// Keys: hash
-// Values: serialized order
+// The orderbook matches incoming orders against the resting orders kept in memory
+// (see pricelevels.go). Every change of the state of an order is persisted to MongoDB
+// but the database is not queried while matching. The inputs of the orderbook are
+// journaled so that its state can be restored after a crash (see journal.go).
 
 import (
+	"errors"
 	"fmt"
-	"math"
-	"strconv"
+	"time"
 
 	sync "github.com/sasha-s/go-deadlock"
 
 	"github.com/byteball/odex-backend/interfaces"
-	"github.com/byteball/odex-backend/rabbitmq"
 	"github.com/byteball/odex-backend/types"
 )
 
+// errMatchRejected is returned by execute when a taker and a maker order can't be filled consistently
+var errMatchRejected = errors.New("Match rejected")
+
 type OrderBook struct {
-	rabbitMQConn  *rabbitmq.Connection
+	rabbitMQConn  interfaces.EnginePublisher
 	orderDao      interfaces.OrderDao
 	tradeDao      interfaces.TradeDao
 	pair          *types.Pair
 	mutex         *sync.Mutex
 	obyteProvider interfaces.ObyteProvider
 	orderService  interfaces.OrderService
+	book          *priceLevels
+	triggers      *triggerBook
+
+	selfTradePrevention string
+
+	journalDao       interfaces.JournalDao
+	snapshotInterval int64
+	sequence         int64
+	snapshotSequence int64
+
+	// the journal entry being processed, its clock and the engine responses it produced
+	entry     *types.JournalEntry
+	now       time.Time
+	responses []*types.EngineResponse
+	replaying bool
+
+	// the IDs of the engine messages journaled since the last snapshot and of the ones
+	// journaled between the two last snapshots
+	messages         map[string]bool
+	snapshotMessages map[string]bool
+}
+
+// loadOrders rebuilds the in-memory orderbook from the orders stored in the database
+func (ob *OrderBook) loadOrders() error {
+	orders, err := ob.orderDao.GetRawOrderBook(ob.pair)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	ob.book.load(orders)
+
+	triggerOrders, err := ob.orderDao.GetUntriggeredOrders(ob.pair)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	trades, err := ob.tradeDao.GetSortedTrades(ob.pair.BaseAsset, ob.pair.QuoteAsset, 1)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	var lastPrice types.Decimal
+	if len(trades) > 0 {
+		lastPrice = trades[0].Price
+	}
+
+	ob.triggers.load(triggerOrders, lastPrice)
+
+	logger.Infof("Loaded %d resting orders and %d trigger orders for %s", ob.book.len(), ob.triggers.len(), ob.pair.Name())
+	return nil
 }
 
 // newOrder calls buyOrder/sellOrder based on type of order recieved and
 // publishes the response back to rabbitmq
 func (ob *OrderBook) newOrder(o *types.Order) (err error) {
-	// Attain lock on engineResource, so that recovery or cancel order function doesn't interfere
-	ob.mutex.Lock()
-	defer ob.mutex.Unlock()
+	if o.IsTriggerOrder() && o.Triggered && !ob.triggers.activate(o.Hash) {
+		logger.Info("triggered order " + o.Hash + " was cancelled before reaching the orderbook")
+		return nil
+	}
+
+	// market orders are matched up to the worst price allowed by their slippage from the
+	// price of the best order they can be matched against, the remainder is cancelled as for
+	// immediate-or-cancel orders
+	if o.IsMarketOrder() {
+		o.Price = o.WorstPrice(ob.book.bestMatchingPrice(o, ob.now))
+	}
 
 	res := &types.EngineResponse{}
-	if o.Side == "SELL" {
+	if o.IsTriggerOrder() && !o.Triggered {
+		res, err = ob.addTriggerOrder(o)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+	} else if o.PostOnly && ob.crosses(o) {
+		res, err = ob.rejectPostOnlyOrder(o)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+	} else if o.TimeInForce == "FOK" && !ob.canFill(o) {
+		res, err = ob.killOrder(o)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+	} else if o.Side == "SELL" {
 		res, err = ob.sellOrder(o)
 		if err != nil {
 			logger.Error(err)
@@ -68,13 +134,86 @@ func (ob *OrderBook) newOrder(o *types.Order) (err error) {
 		}
 	}
 
-	// Note: Plug the option for orders like FOC, Limit here (if needed)
-	err = ob.rabbitMQConn.PublishEngineResponse(res)
+	err = ob.publish(res)
 	if err != nil {
 		logger.Error(err)
 		return err
 	}
 
+	if res.Matches != nil && len(res.Matches.Trades) > 0 {
+		trades := res.Matches.Trades
+		err = ob.releaseTriggerOrders(trades[len(trades)-1].Price)
+	} else if res.Status == "TRIGGER_ORDER_ADDED" {
+		// the stop price might already be crossed by the last trade price
+		err = ob.releaseTriggerOrders(types.Decimal{})
+	}
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// addTriggerOrder stores a stop-loss or take-profit order in the trigger book until
+// the last trade price crosses its stop price
+func (ob *OrderBook) addTriggerOrder(o *types.Order) (*types.EngineResponse, error) {
+	o.Status = "UNTRIGGERED"
+	ob.fixOrderStatus(o)
+
+	err := ob.persist(o)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if o.Status == "UNTRIGGERED" {
+		ob.triggers.add(o)
+	}
+
+	res := &types.EngineResponse{
+		Status: "TRIGGER_ORDER_ADDED",
+		Order:  o,
+	}
+
+	return res, nil
+}
+
+// releaseTriggerOrders sends the trigger orders crossed by the given trade price back to
+// the engine as new orders. A zero price checks the trigger book against the last known price
+func (ob *OrderBook) releaseTriggerOrders(price types.Decimal) error {
+	for _, o := range ob.triggers.release(price) {
+		o.Triggered = true
+		// stop orders without limit take the available liquidity up to their signed price
+		if o.Type == "STOP_LOSS" || o.Type == "TAKE_PROFIT" {
+			o.TimeInForce = "IOC"
+		}
+
+		err := ob.persist(o)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		res := &types.EngineResponse{
+			Status: "ORDER_TRIGGERED",
+			Order:  o,
+		}
+
+		err = ob.publish(res)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		err = ob.publishNewOrder(o)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -83,15 +222,17 @@ func (ob *OrderBook) addOrder(o *types.Order) error {
 		o.Status = "OPEN"
 	}
 
-	ob.orderService.FixOrderStatus(o)
+	ob.fixOrderStatus(o)
+	o.RefillVisibleAmount()
 
-	_, err := ob.orderDao.FindAndModify(o.Hash, o)
+	err := ob.persist(o)
 	if err != nil {
 		// we add this condition in the case an order is re-run through the orderbook (in case of invalid counterpart order for example)
 		logger.Error(err)
 		return err
 	}
 
+	ob.book.sync(o)
 	return nil
 }
 
@@ -102,23 +243,35 @@ func (ob *OrderBook) addOrder(o *types.Order) error {
 func (ob *OrderBook) buyOrder(o *types.Order) (*types.EngineResponse, error) {
 	res := &types.EngineResponse{}
 
-	matchingOrders, err := ob.orderDao.GetMatchingSellOrders(o)
-	if err != nil {
-		logger.Error(err)
-		return nil, err
-	}
+	matchingOrders := ob.book.matchingOrders(o, ob.now)
 
 	// case where no order is matched
 	if len(matchingOrders) == 0 || o.MatcherAddress != ob.obyteProvider.GetOperatorAddress() {
-		ob.addOrder(o)
-		res.Status = "ORDER_ADDED"
-		res.Order = o
-		return res, nil
+		return ob.restOrder(o)
 	}
 
 	matches := types.Matches{TakerOrder: o}
-	for _, mo := range matchingOrders {
+	for i := 0; i < len(matchingOrders); i++ {
+		mo := matchingOrders[i]
+		if ob.isSelfTrade(o, mo) {
+			err := ob.preventSelfTrade(o, mo)
+			if err != nil {
+				logger.Error(err)
+				return nil, err
+			}
+
+			if o.Status == "AUTO_CANCELLED" {
+				return ob.selfTradeCancelled(o, &matches)
+			}
+
+			continue
+		}
+
 		trade, err := ob.execute(o, mo)
+		if err == errMatchRejected {
+			continue
+		}
+
 		if err != nil {
 			logger.Error(err)
 			return nil, err
@@ -127,7 +280,7 @@ func (ob *OrderBook) buyOrder(o *types.Order) (*types.EngineResponse, error) {
 		matches.AppendMatch(mo, trade)
 
 		if o.Status == "FILLED" {
-			_, err := ob.orderDao.FindAndModify(o.Hash, o)
+			err := ob.persist(o)
 			if err != nil {
 				logger.Error(err)
 				return nil, err
@@ -138,16 +291,36 @@ func (ob *OrderBook) buyOrder(o *types.Order) (*types.EngineResponse, error) {
 			res.Matches = &matches
 			return res, nil
 		}
+
+		// the next slice of an iceberg order went to the back of its price level, the
+		// matching orders are walked again from the best price
+		if mo.IsIceberg() && isResting(mo.Status) && mo.VisibleAmount > 0 {
+			matchingOrders = ob.book.matchingOrders(o, ob.now)
+			i = -1
+		}
+	}
+
+	// all the matching orders were cancelled by the self-trade prevention
+	if matches.Length() == 0 {
+		return ob.restOrder(o)
+	}
+
+	// the remainder of immediate-or-cancel orders is not added to the orderbook
+	if o.TimeInForce == "IOC" || o.TimeInForce == "FOK" {
+		return ob.cancelRemainder(o, &matches)
 	}
 
 	// the order can be partial filled and then immediately cancelled
-	ob.orderService.FixOrderStatus(o)
-	_, err = ob.orderDao.FindAndModify(o.Hash, o)
+	ob.fixOrderStatus(o)
+	o.RefillVisibleAmount()
+	err := ob.persist(o)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
 	}
 
+	ob.book.sync(o)
+
 	res.Status = "ORDER_PARTIALLY_FILLED"
 	res.Order = o
 	res.Matches = &matches
@@ -161,24 +334,35 @@ func (ob *OrderBook) buyOrder(o *types.Order) (*types.EngineResponse, error) {
 func (ob *OrderBook) sellOrder(o *types.Order) (*types.EngineResponse, error) {
 	res := &types.EngineResponse{}
 
-	matchingOrders, err := ob.orderDao.GetMatchingBuyOrders(o)
-	if err != nil {
-		logger.Error(err)
-		return nil, err
-	}
+	matchingOrders := ob.book.matchingOrders(o, ob.now)
 
 	if len(matchingOrders) == 0 || o.MatcherAddress != ob.obyteProvider.GetOperatorAddress() {
 		o.Status = "OPEN"
-		ob.addOrder(o)
-
-		res.Status = "ORDER_ADDED"
-		res.Order = o
-		return res, nil
+		return ob.restOrder(o)
 	}
 
 	matches := types.Matches{TakerOrder: o}
-	for _, mo := range matchingOrders {
+	for i := 0; i < len(matchingOrders); i++ {
+		mo := matchingOrders[i]
+		if ob.isSelfTrade(o, mo) {
+			err := ob.preventSelfTrade(o, mo)
+			if err != nil {
+				logger.Error(err)
+				return nil, err
+			}
+
+			if o.Status == "AUTO_CANCELLED" {
+				return ob.selfTradeCancelled(o, &matches)
+			}
+
+			continue
+		}
+
 		trade, err := ob.execute(o, mo)
+		if err == errMatchRejected {
+			continue
+		}
+
 		if err != nil {
 			logger.Error(err)
 			return nil, err
@@ -187,7 +371,7 @@ func (ob *OrderBook) sellOrder(o *types.Order) (*types.EngineResponse, error) {
 		matches.AppendMatch(mo, trade)
 
 		if o.Status == "FILLED" {
-			_, err := ob.orderDao.FindAndModify(o.Hash, o)
+			err := ob.persist(o)
 			if err != nil {
 				logger.Error(err)
 				return nil, err
@@ -198,40 +382,345 @@ func (ob *OrderBook) sellOrder(o *types.Order) (*types.EngineResponse, error) {
 			res.Matches = &matches
 			return res, nil
 		}
+
+		// the next slice of an iceberg order went to the back of its price level, the
+		// matching orders are walked again from the best price
+		if mo.IsIceberg() && isResting(mo.Status) && mo.VisibleAmount > 0 {
+			matchingOrders = ob.book.matchingOrders(o, ob.now)
+			i = -1
+		}
+	}
+
+	// all the matching orders were cancelled by the self-trade prevention
+	if matches.Length() == 0 {
+		return ob.restOrder(o)
+	}
+
+	// the remainder of immediate-or-cancel orders is not added to the orderbook
+	if o.TimeInForce == "IOC" || o.TimeInForce == "FOK" {
+		return ob.cancelRemainder(o, &matches)
 	}
 
 	// the order can be partial filled and then immediately cancelled
-	ob.orderService.FixOrderStatus(o)
-	_, err = ob.orderDao.FindAndModify(o.Hash, o)
+	ob.fixOrderStatus(o)
+	o.RefillVisibleAmount()
+	err := ob.persist(o)
 	if err != nil {
 		logger.Error(err)
 		return nil, err
 	}
 
+	ob.book.sync(o)
+
 	res.Status = "ORDER_PARTIALLY_FILLED"
 	res.Order = o
 	res.Matches = &matches
 	return res, nil
 }
 
+// restOrder adds an order that could not be matched to the orderbook, unless it is an
+// immediate-or-cancel order
+func (ob *OrderBook) restOrder(o *types.Order) (*types.EngineResponse, error) {
+	if o.TimeInForce == "IOC" || o.TimeInForce == "FOK" {
+		return ob.cancelRemainder(o, nil)
+	}
+
+	err := ob.addOrder(o)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	res := &types.EngineResponse{
+		Status: "ORDER_ADDED",
+		Order:  o,
+	}
+
+	return res, nil
+}
+
+// isSelfTrade returns true if the self-trade prevention applies to a taker order and a resting order
+func (ob *OrderBook) isSelfTrade(o *types.Order, mo *types.Order) bool {
+	if ob.selfTradePrevention == "" || ob.selfTradePrevention == "NONE" {
+		return false
+	}
+
+	return o.UserAddress == mo.UserAddress
+}
+
+// preventSelfTrade applies the self-trade prevention mode when a taker order meets a resting
+// order of the same user:
+// - CANCEL_NEWEST cancels the taker order
+// - CANCEL_OLDEST cancels the resting order
+// - CANCEL_BOTH cancels both orders
+// - DECREMENT decreases both orders by the amount they would have traded and cancels the ones
+// that have nothing left
+// Cancelled resting orders are published as ORDER_CANCELLED right away. A cancelled taker order
+// gets the AUTO_CANCELLED status and is handled by the caller.
+func (ob *OrderBook) preventSelfTrade(o *types.Order, mo *types.Order) error {
+	switch ob.selfTradePrevention {
+	case "CANCEL_NEWEST":
+		o.Status = "AUTO_CANCELLED"
+
+	case "CANCEL_OLDEST":
+		mo.Status = "AUTO_CANCELLED"
+		return ob.cancelOrder(mo)
+
+	case "CANCEL_BOTH":
+		o.Status = "AUTO_CANCELLED"
+		mo.Status = "AUTO_CANCELLED"
+		return ob.cancelOrder(mo)
+
+	case "DECREMENT":
+		taker, maker := *o, *mo
+		_, _, err := fill(&taker, &maker)
+		if err != nil {
+			// the orders are left as they are, the taker order moves on to the next resting order
+			logger.Error(err)
+			return nil
+		}
+
+		decrement(o, &taker)
+		decrement(mo, &maker)
+
+		if o.RemainingSellAmount == 0 {
+			o.Status = "AUTO_CANCELLED"
+		}
+
+		if mo.RemainingSellAmount == 0 {
+			mo.Status = "AUTO_CANCELLED"
+			return ob.cancelOrder(mo)
+		}
+
+		err = ob.persist(mo)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		ob.book.sync(mo)
+	}
+
+	return nil
+}
+
+// decrement reduces an order by the amounts a simulated fill took from it, without marking
+// them as filled
+func decrement(o *types.Order, filled *types.Order) {
+	o.Amount -= filled.FilledAmount - o.FilledAmount
+	o.RemainingSellAmount = filled.RemainingSellAmount
+}
+
+// selfTradeCancelled persists a taker order cancelled by the self-trade prevention and
+// publishes its cancellation. The trades matched before the cancellation are kept.
+func (ob *OrderBook) selfTradeCancelled(o *types.Order, matches *types.Matches) (*types.EngineResponse, error) {
+	err := ob.persist(o)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	ob.book.remove(o.Hash)
+
+	res := &types.EngineResponse{
+		Status: "ORDER_CANCELLED",
+		Order:  o,
+	}
+
+	if matches.Length() == 0 {
+		return res, nil
+	}
+
+	err = ob.publish(res)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	res = &types.EngineResponse{
+		Status:  "ORDER_PARTIALLY_FILLED",
+		Order:   o,
+		Matches: matches,
+	}
+
+	return res, nil
+}
+
+// crosses returns true if the order would be matched against resting orders, i.e. take liquidity
+func (ob *OrderBook) crosses(o *types.Order) bool {
+	if o.MatcherAddress != ob.obyteProvider.GetOperatorAddress() {
+		return false
+	}
+
+	return len(ob.book.matchingOrders(o, ob.now)) > 0
+}
+
+// canFill returns true if the order can be completely filled against the resting orders.
+// The matching is simulated on copies of the orders, the orderbook is left untouched
+func (ob *OrderBook) canFill(o *types.Order) bool {
+	if o.MatcherAddress != ob.obyteProvider.GetOperatorAddress() {
+		return false
+	}
+
+	taker := *o
+	for _, mo := range ob.book.matchingOrders(o, ob.now) {
+		if ob.isSelfTrade(o, mo) {
+			// resting orders cancelled by the self-trade prevention free the way to the next ones
+			if ob.selfTradePrevention == "CANCEL_OLDEST" {
+				continue
+			}
+
+			return false
+		}
+
+		maker := *mo
+		_, _, err := fill(&taker, &maker)
+		if err != nil {
+			// the match would be rejected
+			continue
+		}
+
+		if taker.Status == "FILLED" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cancelRemainder cancels the unfilled part of an immediate-or-cancel order instead of
+// adding it to the orderbook. The trades matched so far (if any) are kept
+func (ob *OrderBook) cancelRemainder(o *types.Order, matches *types.Matches) (*types.EngineResponse, error) {
+	ob.fixOrderStatus(o)
+	if o.Status != "CANCELLED" {
+		o.Status = "AUTO_CANCELLED"
+	}
+
+	err := ob.persist(o)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	ob.book.remove(o.Hash)
+
+	res := &types.EngineResponse{
+		Status:  "ORDER_REMAINDER_CANCELLED",
+		Order:   o,
+		Matches: matches,
+	}
+
+	return res, nil
+}
+
+// killOrder rejects a fill-or-kill order that cannot be completely filled
+func (ob *OrderBook) killOrder(o *types.Order) (*types.EngineResponse, error) {
+	res, err := ob.cancelRemainder(o, nil)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	res.Status = "ORDER_KILLED"
+	return res, nil
+}
+
+// rejectPostOnlyOrder rejects a post-only order that would take liquidity.
+// Post-only orders are never re-priced: the price is part of the signed order and
+// the trades would be settled at the signed price anyway
+func (ob *OrderBook) rejectPostOnlyOrder(o *types.Order) (*types.EngineResponse, error) {
+	res, err := ob.cancelRemainder(o, nil)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	res.Status = "ORDER_POST_ONLY_REJECTED"
+	return res, nil
+}
+
 // execute function is responsible for executing of matched orders
 // i.e it deletes/updates orders in case of order matching and responds
-// with trade instance and fillOrder
+// with trade instance and fillOrder.
+// It returns errMatchRejected and leaves both orders untouched if their amounts can't be filled
+// consistently, the taker order is then matched against the next resting orders
 func (ob *OrderBook) execute(takerOrder *types.Order, makerOrder *types.Order) (*types.Trade, error) {
-	trade := &types.Trade{}
+	var tradeAmount, tradeQuoteAmount int64
+	var err error
+	if makerOrder.IsIceberg() {
+		tradeAmount, tradeQuoteAmount, err = fillIceberg(takerOrder, makerOrder)
+	} else {
+		tradeAmount, tradeQuoteAmount, err = fill(takerOrder, makerOrder)
+	}
+
+	if err != nil {
+		logger.Error(err)
+		return nil, errMatchRejected
+	}
+
+	refilled := makerOrder.RefillVisibleAmount()
+
+	err = ob.persist(makerOrder)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	// the refilled slice of an iceberg order loses its time priority
+	if refilled {
+		ob.book.requeue(makerOrder)
+	} else {
+		ob.book.sync(makerOrder)
+	}
+
+	trade := &types.Trade{
+		Amount:                   tradeAmount,
+		QuoteAmount:              tradeQuoteAmount,
+		Price:                    makerOrder.Price, // maker price!
+		BaseToken:                takerOrder.BaseToken,
+		QuoteToken:               takerOrder.QuoteToken,
+		MakerOrderHash:           makerOrder.Hash,
+		TakerOrderHash:           takerOrder.Hash,
+		Taker:                    takerOrder.UserAddress,
+		PairName:                 takerOrder.PairName,
+		Maker:                    makerOrder.UserAddress,
+		RemainingTakerSellAmount: takerOrder.RemainingSellAmount,
+		RemainingMakerSellAmount: makerOrder.RemainingSellAmount,
+		Status:                   "PENDING",
+		MakerSide:                makerOrder.Side,
+	}
+
+	trade.Hash = trade.ComputeHash()
+	return trade, nil
+}
+
+// fill computes the amounts exchanged between a taker and a maker order and updates
+// the filled/remaining amounts and statuses of both orders accordingly.
+// It returns the trade amount (in base currency) and the trade quote amount (in quote currency).
+// The amounts are computed with the decimal arithmetic of the exchange AA (see types.Decimal).
+// If the amounts would become inconsistent, both orders are restored and an error is returned.
+func fill(takerOrder *types.Order, makerOrder *types.Order) (int64, int64, error) {
 	tradeAmount := int64(0)      // always in base currency
 	tradeQuoteAmount := int64(0) // always in quote currency
 
+	taker, maker := *takerOrder, *makerOrder
+	reject := func(format string, a ...interface{}) (int64, int64, error) {
+		*takerOrder, *makerOrder = taker, maker
+		return 0, 0, fmt.Errorf("Match of %s with %s rejected: "+format, append([]interface{}{takerOrder.Hash, makerOrder.Hash}, a...)...)
+	}
+
+	// the price signed by the maker, in units of its buy asset per unit of its sell asset
+	price := types.NewDecimal(makerOrder.OriginalPrice())
+
 	//TODO changes 'strictly greater than' condition. The orders that are almost completely filled
 	//TODO should be removed/skipped
 	if takerOrder.Side == "BUY" {
 		// sell the remaining taker's quote amount at maker's price
 		// takerOutput in base currency
-		//takerOutput := round(float64(takerOrder.RemainingSellAmount) / toOscriptPrecision(makerOrder.Price))
-		makerQuoteOutput := round(float64(makerOrder.RemainingSellAmount) * toOscriptPrecision(makerOrder.OriginalPrice()))
+		makerQuoteOutput := types.MulPrice(makerOrder.RemainingSellAmount, price)
 		//if makerOrder.RemainingSellAmount > takerOutput {
 		if makerQuoteOutput > takerOrder.RemainingSellAmount {
-			tradeAmount = round(float64(takerOrder.RemainingSellAmount) / toOscriptPrecision(makerOrder.OriginalPrice()))
+			tradeAmount = types.DivPrice(takerOrder.RemainingSellAmount, price)
 			tradeQuoteAmount = takerOrder.RemainingSellAmount
 
 			makerOrder.FilledAmount += tradeAmount
@@ -247,12 +736,12 @@ func (ob *OrderBook) execute(takerOrder *types.Order, makerOrder *types.Order) (
 			takerOrder.Status = "FILLED"
 		} else { // maker <= taker
 			tradeAmount = makerOrder.RemainingAmount()
-			tradeQuoteAmount = round(float64(tradeAmount) * toOscriptPrecision(makerOrder.OriginalPrice()))
+			tradeQuoteAmount = types.MulPrice(tradeAmount, price)
 
 			makerOrder.FilledAmount += tradeAmount
 			makerOrder.RemainingSellAmount -= tradeAmount
 			if makerOrder.RemainingSellAmount != 0 {
-				panic(fmt.Sprintf("smaller maker seller: remaining sell amount = %d", makerOrder.RemainingSellAmount))
+				return reject("smaller maker seller: remaining sell amount = %d", makerOrder.RemainingSellAmount)
 			}
 
 			takerOrder.FilledAmount += tradeAmount
@@ -264,14 +753,14 @@ func (ob *OrderBook) execute(takerOrder *types.Order, makerOrder *types.Order) (
 			} else if takerOrder.RemainingSellAmount == 0 {
 				takerOrder.Status = "FILLED"
 			} else {
-				panic(fmt.Sprintf("takerOrder.RemainingSellAmount = %d", takerOrder.RemainingSellAmount))
+				return reject("taker remaining sell amount = %d", takerOrder.RemainingSellAmount)
 			}
 		}
 	} else { // taker is seller
-		makerOutput := round(float64(makerOrder.RemainingSellAmount) * toOscriptPrecision(makerOrder.OriginalPrice()))
+		makerOutput := types.MulPrice(makerOrder.RemainingSellAmount, price)
 		if makerOutput > takerOrder.RemainingAmount() {
 			tradeAmount = takerOrder.RemainingAmount()
-			tradeQuoteAmount = round(float64(tradeAmount) / toOscriptPrecision(makerOrder.OriginalPrice()))
+			tradeQuoteAmount = types.DivPrice(tradeAmount, price)
 
 			makerOrder.FilledAmount += tradeAmount
 			makerOrder.RemainingSellAmount -= tradeQuoteAmount
@@ -279,7 +768,7 @@ func (ob *OrderBook) execute(takerOrder *types.Order, makerOrder *types.Order) (
 			takerOrder.FilledAmount += tradeAmount
 			takerOrder.RemainingSellAmount -= tradeAmount
 			if takerOrder.RemainingSellAmount != 0 {
-				panic(fmt.Sprintf("smaller taker seller: remaining sell amount = %d", takerOrder.RemainingSellAmount))
+				return reject("smaller taker seller: remaining sell amount = %d", takerOrder.RemainingSellAmount)
 			}
 
 			makerOrder.Status = "PARTIAL_FILLED"
@@ -303,66 +792,79 @@ func (ob *OrderBook) execute(takerOrder *types.Order, makerOrder *types.Order) (
 			} else if takerOrder.RemainingSellAmount == 0 {
 				takerOrder.Status = "FILLED"
 			} else {
-				panic(fmt.Sprintf("takerOrder.RemainingSellAmount = %d", takerOrder.RemainingSellAmount))
+				return reject("taker remaining sell amount = %d", takerOrder.RemainingSellAmount)
 			}
 		}
 	}
 
-	_, err := ob.orderDao.FindAndModify(makerOrder.Hash, makerOrder)
-	if err != nil {
-		logger.Error(err)
-		return nil, err
-	}
+	return tradeAmount, tradeQuoteAmount, nil
+}
 
-	trade = &types.Trade{
-		Amount:                   tradeAmount,
-		QuoteAmount:              tradeQuoteAmount,
-		Price:                    makerOrder.Price, // maker price!
-		BaseToken:                takerOrder.BaseToken,
-		QuoteToken:               takerOrder.QuoteToken,
-		MakerOrderHash:           makerOrder.Hash,
-		TakerOrderHash:           takerOrder.Hash,
-		Taker:                    takerOrder.UserAddress,
-		PairName:                 takerOrder.PairName,
-		Maker:                    makerOrder.UserAddress,
-		RemainingTakerSellAmount: takerOrder.RemainingSellAmount,
-		RemainingMakerSellAmount: makerOrder.RemainingSellAmount,
-		Status:                   "PENDING",
-		MakerSide:                makerOrder.Side,
+// fillIceberg fills a taker order against the visible slice of an iceberg order only. The slice
+// is matched as an order of its own and the exchanged amounts are carried over to the iceberg
+// order. The last slice (or a slice too small to be sold) is matched as a regular order.
+func fillIceberg(takerOrder *types.Order, makerOrder *types.Order) (int64, int64, error) {
+	slice := *makerOrder
+	slice.FilledAmount = slice.Amount - makerOrder.VisibleAmount
+	if makerOrder.Side == "SELL" {
+		slice.RemainingSellAmount = makerOrder.VisibleAmount
+	} else {
+		// the quote amount paid for the visible amount at the maker price
+		slice.RemainingSellAmount = types.DivPrice(makerOrder.VisibleAmount, types.NewDecimal(makerOrder.OriginalPrice()))
 	}
 
-	trade.Hash = trade.ComputeHash()
-	return trade, nil
-}
+	if makerOrder.VisibleAmount >= makerOrder.RemainingAmount() || slice.RemainingSellAmount <= 0 || slice.RemainingSellAmount >= makerOrder.RemainingSellAmount {
+		tradeAmount, tradeQuoteAmount, err := fill(takerOrder, makerOrder)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		makerOrder.VisibleAmount -= tradeAmount
+		if makerOrder.VisibleAmount < 0 {
+			makerOrder.VisibleAmount = 0
+		}
+
+		return tradeAmount, tradeQuoteAmount, nil
+	}
 
-func toOscriptPrecision(x float64) float64 {
-	f, err := strconv.ParseFloat(fmt.Sprintf("%.15g", x), 64)
+	sellAmount := slice.RemainingSellAmount
+	tradeAmount, tradeQuoteAmount, err := fill(takerOrder, &slice)
 	if err != nil {
-		panic(err)
+		return 0, 0, err
+	}
+
+	makerOrder.FilledAmount += tradeAmount
+	makerOrder.RemainingSellAmount -= sellAmount - slice.RemainingSellAmount
+	makerOrder.VisibleAmount -= tradeAmount
+	if slice.Status == "FILLED" || makerOrder.VisibleAmount < 0 {
+		makerOrder.VisibleAmount = 0
 	}
-	return f
-}
 
-func round(x float64) int64 {
-	return int64(math.RoundToEven(toOscriptPrecision(x)))
+	makerOrder.Status = "PARTIAL_FILLED"
+	if makerOrder.RemainingSellAmount == 0 {
+		makerOrder.Status = "FILLED"
+	}
+
+	return tradeAmount, tradeQuoteAmount, nil
 }
 
-// CancelOrder is used to cancel the order from orderbook
+// cancelOrder removes an order from the orderbook, persists its new status and publishes
+// the ORDER_CANCELLED engine response
 func (ob *OrderBook) cancelOrder(o *types.Order) error {
-	ob.mutex.Lock()
-	defer ob.mutex.Unlock()
-
 	if o.Status != "AUTO_CANCELLED" && o.Status != "FILLED" {
 		o.Status = "CANCELLED"
 	}
 	if o.Status == "AUTO_CANCELLED" || o.Status == "CANCELLED" {
-		err := ob.orderDao.UpdateOrderStatus(o.Hash, o.Status)
+		err := ob.persistStatus(o.Hash, o.Status)
 		if err != nil {
 			logger.Error(err, "when cancelling order", o.Hash)
 			return err
 		}
 	}
 
+	ob.book.remove(o.Hash)
+	ob.triggers.cancel(o.Hash)
+
 	// todo: another engine response when the order was already cancelled or filled
 	res := &types.EngineResponse{
 		Status:  "ORDER_CANCELLED",
@@ -370,7 +872,126 @@ func (ob *OrderBook) cancelOrder(o *types.Order) error {
 		Matches: nil,
 	}
 
-	err := ob.rabbitMQConn.PublishEngineResponse(res)
+	err := ob.publish(res)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// amendOrder replaces a resting order with a new order signed by the same address. The new order
+// takes the place of the amended order in the queue of its price level when it only reduces the
+// remaining amount, otherwise the amended order is removed and the new order is matched like any
+// new order, losing the priority. The amended order gets the AMENDED status.
+func (ob *OrderBook) amendOrder(o *types.Order) error {
+	ob.fixOrderStatus(o)
+
+	old := ob.book.get(o.AmendedOrderHash)
+	if old == nil || o.Status == "CANCELLED" {
+		// the amended order was filled or cancelled while the amendment was in the pipeline
+		res, err := ob.cancelRemainder(o, nil)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		res.Status = "ORDER_AMEND_REJECTED"
+		err = ob.publish(res)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		return nil
+	}
+
+	old.Status = "AMENDED"
+	err := ob.persistStatus(old.Hash, old.Status)
+	if err != nil {
+		logger.Error(err, "when amending order", old.Hash)
+		return err
+	}
+
+	keepsPriority := o.KeepsPriorityOf(old)
+	if keepsPriority {
+		o.Status = "OPEN"
+		o.RefillVisibleAmount()
+
+		err = ob.persist(o)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		ob.book.amend(old, o)
+	} else {
+		ob.book.remove(old.Hash)
+	}
+
+	res := &types.EngineResponse{
+		Status:       "ORDER_AMENDED",
+		Order:        o,
+		AmendedOrder: old,
+	}
+
+	err = ob.publish(res)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if keepsPriority {
+		return nil
+	}
+
+	return ob.newOrder(o)
+}
+
+// restoreOrder gives back to the maker order of a trade that could not be settled the amounts
+// of this trade and puts the order back in the orderbook. The restored order is journaled so
+// that replaying the journal does not depend on the database.
+func (ob *OrderBook) restoreOrder(t *types.Trade, messageID string) error {
+	ob.mutex.Lock()
+	o := ob.book.get(t.MakerOrderHash)
+	ob.mutex.Unlock()
+
+	if o == nil {
+		var err error
+		o, err = ob.orderDao.GetByHash(t.MakerOrderHash)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		if o == nil {
+			return errors.New("Order not found")
+		}
+	}
+
+	if o.Status != "OPEN" && o.Status != "PARTIAL_FILLED" && o.Status != "FILLED" {
+		logger.Infof("Order %v is %v, not restoring the amounts of trade %v", o.Hash, o.Status, t.Hash)
+		return nil
+	}
+
+	return ob.process("RESTORE_ORDER", restoredOrder(o, t), messageID)
+}
+
+// addRestoredOrder puts a restored order back in the orderbook
+func (ob *OrderBook) addRestoredOrder(o *types.Order) error {
+	err := ob.addOrder(o)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	res := &types.EngineResponse{
+		Status: "ORDER_ADDED",
+		Order:  o,
+	}
+
+	err = ob.publish(res)
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -379,6 +1000,30 @@ func (ob *OrderBook) cancelOrder(o *types.Order) error {
 	return nil
 }
 
+// restoredOrder returns a copy of a maker order with the amounts of a trade given back
+func restoredOrder(o *types.Order, t *types.Trade) *types.Order {
+	restored := *o
+
+	restored.FilledAmount -= t.Amount
+	if restored.FilledAmount < 0 {
+		restored.FilledAmount = 0
+	}
+
+	// the maker sells the base asset when it is on the sell side, the quote asset otherwise
+	if restored.Side == "SELL" {
+		restored.RemainingSellAmount += t.Amount
+	} else {
+		restored.RemainingSellAmount += t.QuoteAmount
+	}
+
+	restored.Status = "PARTIAL_FILLED"
+	if restored.FilledAmount == 0 {
+		restored.Status = "OPEN"
+	}
+
+	return &restored
+}
+
 // cancelTrades revertTrades and reintroduces the taker orders in the orderbook
 func (ob *OrderBook) invalidateMakerOrders(matches types.Matches) error {
 	ob.mutex.Lock()
@@ -409,6 +1054,10 @@ func (ob *OrderBook) invalidateMakerOrders(matches types.Matches) error {
 		return err
 	}
 
+	for _, h := range makerOrderHashes {
+		ob.book.remove(h)
+	}
+
 	//TODO in the case the trades are not in the database they should not be created.
 	cancelledTrades, err := ob.tradeDao.UpdateTradeStatusesByOrderHashes("CANCELLED", makerOrderHashes...)
 	if err != nil {
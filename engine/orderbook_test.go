@@ -4,8 +4,13 @@ import (
 	"io/ioutil"
 	"log"
 	"math"
+	"strconv"
 	"testing"
+	"testing/quick"
+	"time"
 
+	sync "github.com/sasha-s/go-deadlock"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/byteball/odex-backend/daos"
@@ -54,11 +59,16 @@ func setupTest() (
 	tradeDao := new(mocks.TradeDao)
 	obyteProvider := new(mocks.ObyteProvider)
 	orderService := new(mocks.OrderService)
+	journalDao := new(mocks.JournalDao)
 	pairDao.On("GetAll").Return([]types.Pair{*pair}, nil)
+	tradeDao.On("GetSortedTrades", pair.BaseAsset, pair.QuoteAsset, 1).Return([]*types.Trade{}, nil)
 	obyteProvider.On("GetOperatorAddress").Return(matcherAddress)
 	orderService.On("FixOrderStatus", mock.Anything).Return()
+	journalDao.On("GetLatestSnapshot", pair.Code()).Return(nil, nil)
+	journalDao.On("GetEntries", pair.Code(), int64(0)).Return([]*types.JournalEntry{}, nil)
+	journalDao.On("SaveSnapshot", mock.Anything).Return(nil)
 
-	eng := NewEngine(rabbitConn, orderDao, tradeDao, pairDao, obyteProvider, orderService)
+	eng := NewEngine(rabbitConn, orderDao, tradeDao, pairDao, obyteProvider, orderService, journalDao)
 	maker := testutils.GetTestWallet1()
 	taker := testutils.GetTestWallet2()
 	zrx := pair.BaseAsset
@@ -74,7 +84,7 @@ func setupTest() (
 		panic(err)
 	}
 
-	ob := eng.orderbooks[pair.Code()]
+	ob := eng.orderbook(pair.Code())
 	if ob == nil {
 		panic("Could not get orderbook")
 	}
@@ -129,7 +139,7 @@ func TestFillOrder1(t *testing.T) {
 
 	o1, _ := factory1.NewSellOrder(1e3, 1e8)
 	o2, _ := factory2.NewBuyOrder(1e3, 1e8)
-	expt1 := types.NewTrade(&o1, &o2, 1e8, 1e3)
+	expt1 := types.NewTrade(&o1, &o2, 1e8, types.NewDecimal(1e3))
 
 	expo1 := o1
 	expo1.Status = "OPEN"
@@ -176,7 +186,7 @@ func TestFillOrder2(t *testing.T) {
 
 	o1, _ := factory1.NewBuyOrder(1e3, 1e8)
 	o2, _ := factory2.NewSellOrder(1e3, 1e8)
-	expt1 := types.NewTrade(&o1, &o2, 1e8, 1e3)
+	expt1 := types.NewTrade(&o1, &o2, 1e8, types.NewDecimal(1e3))
 
 	expo1 := o1
 	expo1.Status = "OPEN"
@@ -251,9 +261,9 @@ func TestMultiMatchOrder1(t *testing.T) {
 	expbo1.FilledAmount = 3e8
 	expbo1.RemainingSellAmount = (1e3+4)*3e8 - (1e3+1)*1e8 - (1e3+2)*1e8 - (1e3+3)*1e8
 
-	expt1 := types.NewTrade(&so1, &bo1, 1e8, 1e3+1)
-	expt2 := types.NewTrade(&so2, &bo1, 1e8, 1e3+2)
-	expt3 := types.NewTrade(&so3, &bo1, 1e8, 1e3+3)
+	expt1 := types.NewTrade(&so1, &bo1, 1e8, types.NewDecimal(1e3+1))
+	expt2 := types.NewTrade(&so2, &bo1, 1e8, types.NewDecimal(1e3+2))
+	expt3 := types.NewTrade(&so3, &bo1, 1e8, types.NewDecimal(1e3+3))
 
 	expectedMatches := types.NewMatches(
 		[]*types.Order{&expso1, &expso2, &expso3},
@@ -305,9 +315,9 @@ func TestMultiMatchOrder2(t *testing.T) {
 	ob.buyOrder(&bo2)
 	ob.buyOrder(&bo3)
 
-	expt1 := types.NewTrade(&bo1, &so1, 1e8, 1000+1)
-	expt2 := types.NewTrade(&bo2, &so1, 1e8, 1000+2)
-	expt3 := types.NewTrade(&bo3, &so1, 1e8, 1000+3)
+	expt1 := types.NewTrade(&bo1, &so1, 1e8, types.NewDecimal(1000+1))
+	expt2 := types.NewTrade(&bo2, &so1, 1e8, types.NewDecimal(1000+2))
+	expt3 := types.NewTrade(&bo3, &so1, 1e8, types.NewDecimal(1000+3))
 
 	expectedMatches := types.NewMatches(
 		[]*types.Order{&expbo3, &expbo2, &expbo1},
@@ -363,10 +373,10 @@ func TestPartialMatchOrder1(t *testing.T) {
 	expbo1.RemainingSellAmount = 0
 	expbo1.Status = "FILLED"
 
-	expt1 := types.NewTrade(&so1, &bo1, 1e8, 1e3+1)
-	expt2 := types.NewTrade(&so2, &bo1, 1e8, 1e3+2)
-	expt3 := types.NewTrade(&so3, &bo1, 1e8, 1e3+3)
-	expt4 := types.NewTrade(&so4, &bo1, filled, 1e3+4)
+	expt1 := types.NewTrade(&so1, &bo1, 1e8, types.NewDecimal(1e3+1))
+	expt2 := types.NewTrade(&so2, &bo1, 1e8, types.NewDecimal(1e3+2))
+	expt3 := types.NewTrade(&so3, &bo1, 1e8, types.NewDecimal(1e3+3))
+	expt4 := types.NewTrade(&so4, &bo1, filled, types.NewDecimal(1e3+4))
 
 	ob.sellOrder(&so1)
 	ob.sellOrder(&so2)
@@ -424,10 +434,10 @@ func TestPartialMatchOrder2(t *testing.T) {
 	expso1.RemainingSellAmount = 0
 	expso1.Status = "FILLED"
 
-	expt1 := types.NewTrade(&bo1, &so1, 1e8, 1e3+5)
-	expt2 := types.NewTrade(&bo2, &so1, 1e8, 1e3+4)
-	expt3 := types.NewTrade(&bo3, &so1, 1e8, 1e3+3)
-	expt4 := types.NewTrade(&bo4, &so1, 1e8, 1e3+2)
+	expt1 := types.NewTrade(&bo1, &so1, 1e8, types.NewDecimal(1e3+5))
+	expt2 := types.NewTrade(&bo2, &so1, 1e8, types.NewDecimal(1e3+4))
+	expt3 := types.NewTrade(&bo3, &so1, 1e8, types.NewDecimal(1e3+3))
+	expt4 := types.NewTrade(&bo4, &so1, 1e8, types.NewDecimal(1e3+2))
 
 	ob.buyOrder(&bo1)
 	ob.buyOrder(&bo2)
@@ -453,3 +463,505 @@ func TestPartialMatchOrder2(t *testing.T) {
 
 	testutils.CompareEngineResponse(t, expectedResponse, res)
 }
+
+func TestImmediateOrCancelOrder(t *testing.T) {
+	_, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+
+	o1, _ := factory1.NewSellOrder(1e3, 1e8)
+	o2, _ := factory2.NewBuyOrder(1e3, 2e8)
+	o2.TimeInForce = "IOC"
+
+	_, err := ob.sellOrder(&o1)
+	if err != nil {
+		t.Errorf("Error when calling sell order")
+	}
+
+	res, err := ob.buyOrder(&o2)
+	if err != nil {
+		t.Errorf("Error when calling buy order")
+	}
+
+	assert.Equal(t, "ORDER_REMAINDER_CANCELLED", res.Status)
+	assert.Equal(t, "AUTO_CANCELLED", res.Order.Status)
+	assert.Equal(t, int64(1e8), res.Order.FilledAmount)
+	assert.Equal(t, 1, res.Matches.Length())
+	assert.Nil(t, ob.book.get(o2.Hash))
+	assert.Equal(t, 0, ob.book.len())
+}
+
+func TestFillOrKillOrder(t *testing.T) {
+	_, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+
+	o1, _ := factory1.NewSellOrder(1e3, 1e8)
+	_, err := ob.sellOrder(&o1)
+	if err != nil {
+		t.Errorf("Error when calling sell order")
+	}
+
+	o2, _ := factory2.NewBuyOrder(1e3, 2e8)
+	o2.TimeInForce = "FOK"
+	assert.False(t, ob.canFill(&o2))
+
+	res, err := ob.killOrder(&o2)
+	if err != nil {
+		t.Errorf("Error when killing order")
+	}
+
+	assert.Equal(t, "ORDER_KILLED", res.Status)
+	assert.Equal(t, "AUTO_CANCELLED", res.Order.Status)
+	assert.Nil(t, res.Matches)
+
+	// the resting order is left untouched
+	assert.Equal(t, int64(0), ob.book.get(o1.Hash).FilledAmount)
+
+	o3, _ := factory2.NewBuyOrder(1e3, 1e8)
+	o3.TimeInForce = "FOK"
+	assert.True(t, ob.canFill(&o3))
+}
+
+func TestPostOnlyOrder(t *testing.T) {
+	_, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+
+	o1, _ := factory1.NewSellOrder(1e3, 1e8)
+	_, err := ob.sellOrder(&o1)
+	if err != nil {
+		t.Errorf("Error when calling sell order")
+	}
+
+	o2, _ := factory2.NewBuyOrder(1e3, 1e8)
+	o2.PostOnly = true
+	assert.True(t, ob.crosses(&o2))
+
+	res, err := ob.rejectPostOnlyOrder(&o2)
+	if err != nil {
+		t.Errorf("Error when rejecting order")
+	}
+
+	assert.Equal(t, "ORDER_POST_ONLY_REJECTED", res.Status)
+	assert.Equal(t, "AUTO_CANCELLED", res.Order.Status)
+	assert.Equal(t, int64(0), ob.book.get(o1.Hash).FilledAmount)
+
+	o3, _ := factory2.NewBuyOrder(9e2, 1e8)
+	o3.PostOnly = true
+	assert.False(t, ob.crosses(&o3))
+}
+
+func TestMarketOrder(t *testing.T) {
+	_, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+
+	o1, _ := factory1.NewSellOrder(1e3, 1e8)
+	o2, _ := factory1.NewSellOrder(1.05e3, 1e8)
+	o3, _ := factory1.NewSellOrder(1.2e3, 1e8)
+	for _, o := range []*types.Order{&o1, &o2, &o3} {
+		_, err := ob.sellOrder(o)
+		if err != nil {
+			t.Errorf("Error when calling sell order")
+		}
+	}
+
+	o4, _ := factory2.NewBuyOrder(1.2e3, 3e8)
+	o4.Type = "MARKET"
+	o4.TimeInForce = "IOC"
+	o4.MaxSlippage = 0.1
+	o4.Price = o4.WorstPrice(ob.book.bestMatchingPrice(&o4, ob.now))
+	assert.Equal(t, types.NewDecimal(1.1e3), o4.Price)
+
+	res, err := ob.buyOrder(&o4)
+	if err != nil {
+		t.Errorf("Error when calling buy order")
+	}
+
+	// the order sweeps the asks up to 10% above the best ask and the remainder is not added to the orderbook
+	assert.Equal(t, "ORDER_REMAINDER_CANCELLED", res.Status)
+	assert.Equal(t, "AUTO_CANCELLED", res.Order.Status)
+	assert.Equal(t, int64(2e8), res.Order.FilledAmount)
+	assert.Equal(t, 2, res.Matches.Length())
+	assert.Nil(t, ob.book.get(o4.Hash))
+	assert.Equal(t, int64(0), ob.book.get(o3.Hash).FilledAmount)
+}
+
+func TestIcebergOrder(t *testing.T) {
+	_, ob, _, _, _, _, _, _, factory1, factory2 := setupTest()
+
+	o1, _ := factory1.NewSellOrder(1e3, 3e8)
+	o1.DisplayAmount = 1e8
+	o2, _ := factory1.NewSellOrder(1e3, 1e8)
+	for _, o := range []*types.Order{&o1, &o2} {
+		_, err := ob.sellOrder(o)
+		if err != nil {
+			t.Errorf("Error when calling sell order")
+		}
+	}
+
+	assert.Equal(t, int64(1e8), o1.VisibleAmount)
+	assert.Equal(t, int64(1e8), o1.DisplayedAmount())
+
+	o3, _ := factory2.NewBuyOrder(1e3, 25e7)
+	res, err := ob.buyOrder(&o3)
+	if err != nil {
+		t.Errorf("Error when calling buy order")
+	}
+
+	// the second slice of the iceberg order is queued behind the other order of the price level
+	assert.Equal(t, "ORDER_FILLED", res.Status)
+	assert.Equal(t, 3, res.Matches.Length())
+	assert.Equal(t, []string{o1.Hash, o2.Hash, o1.Hash}, []string{
+		res.Matches.Trades[0].MakerOrderHash,
+		res.Matches.Trades[1].MakerOrderHash,
+		res.Matches.Trades[2].MakerOrderHash,
+	})
+
+	assert.Equal(t, int64(15e7), o1.FilledAmount)
+	assert.Equal(t, int64(5e7), o1.VisibleAmount)
+	assert.Equal(t, "PARTIAL_FILLED", o1.Status)
+}
+
+func TestFillIceberg(t *testing.T) {
+	maker := newFillTestOrder("SELL", 1000, 1000, 2)
+	maker.DisplayAmount = 100
+	maker.RefillVisibleAmount()
+	taker := newFillTestOrder("BUY", 1000, 500, 0.5)
+
+	tradeAmount, tradeQuoteAmount, err := fillIceberg(taker, maker)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), tradeAmount)
+	assert.Equal(t, int64(200), tradeQuoteAmount)
+	assert.Equal(t, int64(900), maker.RemainingSellAmount)
+	assert.Equal(t, int64(0), maker.VisibleAmount)
+	assert.Equal(t, "PARTIAL_FILLED", maker.Status)
+	assert.Equal(t, int64(300), taker.RemainingSellAmount)
+
+	assert.True(t, maker.RefillVisibleAmount())
+	assert.Equal(t, int64(100), maker.VisibleAmount)
+
+	fillIceberg(taker, maker)
+	assert.True(t, maker.RefillVisibleAmount())
+
+	tradeAmount, tradeQuoteAmount, err = fillIceberg(taker, maker)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(50), tradeAmount)
+	assert.Equal(t, int64(100), tradeQuoteAmount)
+	assert.Equal(t, int64(250), maker.FilledAmount)
+	assert.Equal(t, int64(50), maker.VisibleAmount)
+	assert.Equal(t, "FILLED", taker.Status)
+}
+
+func TestFillRejected(t *testing.T) {
+	// the remaining sell amount of the maker doesn't match its filled amount
+	maker := newFillTestOrder("SELL", 1000, 900, 2)
+	taker := newFillTestOrder("BUY", 1000, 5000, 0.5)
+
+	tradeAmount, tradeQuoteAmount, err := fill(taker, maker)
+	assert.Error(t, err)
+	assert.Equal(t, int64(0), tradeAmount)
+	assert.Equal(t, int64(0), tradeQuoteAmount)
+	assert.Equal(t, int64(900), maker.RemainingSellAmount)
+	assert.Equal(t, int64(0), maker.FilledAmount)
+	assert.Equal(t, int64(5000), taker.RemainingSellAmount)
+	assert.Equal(t, int64(0), taker.FilledAmount)
+	assert.Equal(t, "", taker.Status)
+}
+
+func TestRestoredOrder(t *testing.T) {
+	maker := newFillTestOrder("SELL", 1000, 1000, 2)
+	taker := newFillTestOrder("BUY", 1000, 600, 0.5)
+
+	tradeAmount, tradeQuoteAmount, _ := fill(taker, maker)
+	trade := &types.Trade{Amount: tradeAmount, QuoteAmount: tradeQuoteAmount}
+
+	restored := restoredOrder(maker, trade)
+	assert.Equal(t, int64(0), restored.FilledAmount)
+	assert.Equal(t, int64(1000), restored.RemainingSellAmount)
+	assert.Equal(t, "OPEN", restored.Status)
+	assert.Equal(t, "PARTIAL_FILLED", maker.Status)
+
+	// a buy order filled by a first trade of 300 and a second trade of 700 at 2
+	maker = newFillTestOrder("BUY", 1000, 0, 0.5)
+	maker.FilledAmount = 1000
+	maker.Status = "FILLED"
+	trade = &types.Trade{Amount: 700, QuoteAmount: 1400}
+
+	restored = restoredOrder(maker, trade)
+	assert.Equal(t, int64(300), restored.FilledAmount)
+	assert.Equal(t, int64(1400), restored.RemainingSellAmount)
+	assert.Equal(t, "PARTIAL_FILLED", restored.Status)
+	assert.Equal(t, "FILLED", maker.Status)
+}
+
+func TestSelfTradePrevention(t *testing.T) {
+	_, ob, _, _, _, _, _, _, factory1, _ := setupTest()
+
+	ob.selfTradePrevention = "CANCEL_NEWEST"
+
+	o1, _ := factory1.NewSellOrder(1e3, 1e8)
+	_, err := ob.sellOrder(&o1)
+	if err != nil {
+		t.Errorf("Error when calling sell order")
+	}
+
+	o2, _ := factory1.NewBuyOrder(1e3, 1e8)
+	res, err := ob.buyOrder(&o2)
+	if err != nil {
+		t.Errorf("Error when calling buy order")
+	}
+
+	assert.Equal(t, "ORDER_CANCELLED", res.Status)
+	assert.Equal(t, "AUTO_CANCELLED", res.Order.Status)
+	assert.NotNil(t, ob.book.get(o1.Hash))
+
+	ob.selfTradePrevention = "CANCEL_OLDEST"
+
+	o3, _ := factory1.NewBuyOrder(1e3, 1e8)
+	res, err = ob.buyOrder(&o3)
+	if err != nil {
+		t.Errorf("Error when calling buy order")
+	}
+
+	assert.Equal(t, "ORDER_ADDED", res.Status)
+	assert.Nil(t, ob.book.get(o1.Hash))
+	assert.NotNil(t, ob.book.get(o3.Hash))
+
+	ob.selfTradePrevention = "DECREMENT"
+
+	o4, _ := factory1.NewSellOrder(1e3, 5e7)
+	res, err = ob.sellOrder(&o4)
+	if err != nil {
+		t.Errorf("Error when calling sell order")
+	}
+
+	assert.Equal(t, "AUTO_CANCELLED", res.Order.Status)
+	assert.Equal(t, int64(0), res.Order.FilledAmount)
+	assert.Equal(t, int64(5e7), ob.book.get(o3.Hash).Amount)
+	assert.Equal(t, int64(0), ob.book.get(o3.Hash).FilledAmount)
+}
+
+// TestMarketOrderBoundSkipsUnmatchableOrders checks that the worst price of a market order is
+// derived from the best order it can be matched against when orders of another matcher and
+// expiring orders are at the top of the book
+func TestMarketOrderBoundSkipsUnmatchableOrders(t *testing.T) {
+	ob, s1, s2 := newAmendTest()
+	pair := testutils.GetZRXWETHTestPair()
+	ob.now = time.Now()
+
+	otherMatcher, _ := testutils.NewOrderFactory(pair, testutils.GetTestWallet3(), testutils.GetTestAddress2())
+	foreign, _ := otherMatcher.NewSellOrder(5e2, 1e8)
+	ob.book.sync(&foreign)
+
+	factory3, _ := testutils.NewOrderFactory(pair, testutils.GetTestWallet3(), testutils.GetTestAddress1())
+	expiring, _ := factory3.NewSellOrder(6e2, 1e8)
+	expiring.OriginalOrder["signed_message"].(map[string]interface{})["expiry_ts"] = float64(ob.now.Unix() + 10)
+	ob.book.sync(&expiring)
+
+	factory4, _ := testutils.NewOrderFactory(pair, testutils.GetTestWallet4(), testutils.GetTestAddress1())
+	o, _ := factory4.NewBuyOrder(1.2e3, 3e8)
+	o.Type = "MARKET"
+	o.TimeInForce = "IOC"
+	o.MaxSlippage = 0.1
+
+	err := ob.newOrder(&o)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// the bound is 10% above the orders of the matcher at 1e3, not above the foreign order at 5e2
+	assert.Equal(t, types.NewDecimal(1.1e3), o.Price)
+	assert.Equal(t, int64(2e8), o.FilledAmount)
+	assert.Equal(t, "AUTO_CANCELLED", o.Status)
+	assert.Nil(t, ob.book.get(s1.Hash))
+	assert.Nil(t, ob.book.get(s2.Hash))
+	assert.Equal(t, int64(0), ob.book.get(foreign.Hash).FilledAmount)
+	assert.Equal(t, int64(0), ob.book.get(expiring.Hash).FilledAmount)
+}
+
+// newAmendTest returns an orderbook with mocked persistence holding two sell orders of
+// different makers at the same price
+func newAmendTest() (*OrderBook, *types.Order, *types.Order) {
+	pair := testutils.GetZRXWETHTestPair()
+	matcherAddress := testutils.GetTestAddress1()
+	factory1, _ := testutils.NewOrderFactory(pair, testutils.GetTestWallet1(), matcherAddress)
+	factory2, _ := testutils.NewOrderFactory(pair, testutils.GetTestWallet2(), matcherAddress)
+
+	s1, _ := factory1.NewSellOrder(1e3, 1e8)
+	s2, _ := factory2.NewSellOrder(1e3, 1e8)
+
+	orderDao := new(mocks.OrderDao)
+	orderDao.On("FindAndModify", mock.Anything, mock.Anything).Return(nil, nil)
+	orderDao.On("UpdateOrderStatus", mock.Anything, mock.Anything).Return(nil)
+
+	publisher := new(mocks.EnginePublisher)
+	publisher.On("PublishEngineResponse", mock.Anything).Return(nil)
+
+	obyteProvider := new(mocks.ObyteProvider)
+	obyteProvider.On("GetOperatorAddress").Return(matcherAddress)
+
+	orderService := new(mocks.OrderService)
+	orderService.On("FixOrderStatus", mock.Anything).Return()
+
+	ob := &OrderBook{
+		rabbitMQConn:  publisher,
+		orderDao:      orderDao,
+		pair:          pair,
+		mutex:         &sync.Mutex{},
+		obyteProvider: obyteProvider,
+		orderService:  orderService,
+		book:          newPriceLevels(),
+		triggers:      newTriggerBook(),
+	}
+
+	ob.book.load([]*types.Order{&s1, &s2})
+	return ob, &s1, &s2
+}
+
+// amendingOrder returns a copy of an order amending it with a new price and amount
+func amendingOrder(o *types.Order, hash string, price float64, amount int64) *types.Order {
+	amending := *o
+	amending.Hash = hash
+	amending.AmendedOrderHash = o.Hash
+	amending.Price = types.NewDecimal(price)
+	amending.Amount = amount
+	amending.FilledAmount = 0
+	amending.RemainingSellAmount = amount
+	amending.OriginalOrder = map[string]interface{}{
+		"signed_message": map[string]interface{}{
+			"sell_amount": float64(amount),
+			"price":       price,
+			"matcher_fee": 0,
+		},
+	}
+
+	return &amending
+}
+
+func TestAmendOrder(t *testing.T) {
+	ob, s1, s2 := newAmendTest()
+
+	// reducing the amount keeps the priority
+	a1 := amendingOrder(s1, "a1", 1e3, 5e7)
+	err := ob.amendOrder(a1)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Len(t, ob.responses, 1)
+	assert.Equal(t, "ORDER_AMENDED", ob.responses[0].Status)
+	assert.Equal(t, "AMENDED", ob.responses[0].AmendedOrder.Status)
+	assert.Equal(t, "OPEN", a1.Status)
+	assert.Nil(t, ob.book.get(s1.Hash))
+	assert.Equal(t, []string{"a1", s2.Hash}, hashes(ob.book.all()))
+
+	// increasing the amount loses the priority
+	ob.responses = nil
+	a2 := amendingOrder(a1, "a2", 1e3, 2e8)
+	err = ob.amendOrder(a2)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Len(t, ob.responses, 2)
+	assert.Equal(t, "ORDER_AMENDED", ob.responses[0].Status)
+	assert.Equal(t, "ORDER_ADDED", ob.responses[1].Status)
+	assert.Equal(t, []string{s2.Hash, "a2"}, hashes(ob.book.all()))
+
+	// a new price crossing the bids is matched like a new order
+	factory3, _ := testutils.NewOrderFactory(ob.pair, testutils.GetTestWallet3(), s1.MatcherAddress)
+	buy, _ := factory3.NewBuyOrder(9e2, 1e8)
+	ob.book.sync(&buy)
+
+	ob.responses = nil
+	a3 := amendingOrder(a2, "a3", 9e2, 2e8)
+	err = ob.amendOrder(a3)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Len(t, ob.responses, 2)
+	assert.Equal(t, "ORDER_AMENDED", ob.responses[0].Status)
+	assert.Equal(t, "ORDER_PARTIALLY_FILLED", ob.responses[1].Status)
+	assert.Nil(t, ob.book.get("a2"))
+	assert.Nil(t, ob.book.get(buy.Hash))
+	assert.Equal(t, int64(1e8), ob.book.get("a3").FilledAmount)
+
+	// an order filled or cancelled in the meantime cannot be amended
+	ob.responses = nil
+	a4 := amendingOrder(a2, "a4", 1e3, 1e8)
+	err = ob.amendOrder(a4)
+	if err != nil {
+		t.Error(err)
+	}
+
+	assert.Len(t, ob.responses, 1)
+	assert.Equal(t, "ORDER_AMEND_REJECTED", ob.responses[0].Status)
+	assert.Equal(t, "AUTO_CANCELLED", a4.Status)
+	assert.Nil(t, ob.book.get("a4"))
+}
+
+// oscriptPrice builds a price of 1 to 15 significant digits between 1e-6 and 1e6
+func oscriptPrice(mantissa uint64, exp uint8) float64 {
+	m := mantissa%999999999999999 + 1
+	digits := len(strconv.FormatUint(m, 10))
+	return float64(m) * math.Pow10(int(exp%13)-6-digits+1)
+}
+
+func newFillTestOrder(side string, amount int64, remainingSellAmount int64, price float64) *types.Order {
+	return &types.Order{
+		Side:                side,
+		Amount:              amount,
+		RemainingSellAmount: remainingSellAmount,
+		OriginalOrder: map[string]interface{}{
+			"signed_message": map[string]interface{}{"price": price},
+		},
+	}
+}
+
+// TestFillMatchesOscriptModel checks that the amounts computed by the engine are the ones the
+// exchange AA computes, using the reference model of the oscript arithmetic
+func TestFillMatchesOscriptModel(t *testing.T) {
+	takerBuys := func(makerAmount uint32, takerAmount uint64, mantissa uint64, exp uint8) bool {
+		price := oscriptPrice(mantissa, exp)
+		m := int64(makerAmount) + 1
+		q := int64(takerAmount%1e12) + 1
+
+		maker := newFillTestOrder("SELL", m, m, price)
+		taker := newFillTestOrder("BUY", math.MaxInt64, q, 1/price)
+
+		var amount, quoteAmount int64
+		if testutils.OscriptMul(m, price) > q {
+			amount, quoteAmount = testutils.OscriptDiv(q, price), q
+		} else {
+			amount, quoteAmount = m, testutils.OscriptMul(m, price)
+		}
+
+		tradeAmount, tradeQuoteAmount, err := fill(taker, maker)
+		return err == nil && tradeAmount == amount && tradeQuoteAmount == quoteAmount &&
+			maker.RemainingSellAmount >= 0 && taker.RemainingSellAmount >= 0
+	}
+
+	takerSells := func(makerAmount uint64, takerAmount uint32, mantissa uint64, exp uint8) bool {
+		price := oscriptPrice(mantissa, exp)
+		q := int64(makerAmount%1e12) + 1
+		b := int64(takerAmount) + 1
+
+		maker := newFillTestOrder("BUY", math.MaxInt64, q, price)
+		taker := newFillTestOrder("SELL", b, b, 1/price)
+
+		var amount, quoteAmount int64
+		if testutils.OscriptMul(q, price) > b {
+			amount, quoteAmount = b, testutils.OscriptDiv(b, price)
+		} else {
+			amount, quoteAmount = testutils.OscriptMul(q, price), q
+		}
+
+		tradeAmount, tradeQuoteAmount, err := fill(taker, maker)
+		return err == nil && tradeAmount == amount && tradeQuoteAmount == quoteAmount &&
+			maker.RemainingSellAmount >= 0 && taker.RemainingSellAmount >= 0
+	}
+
+	config := &quick.Config{MaxCount: 20000}
+	if err := quick.Check(takerBuys, config); err != nil {
+		t.Error(err)
+	}
+
+	if err := quick.Check(takerSells, config); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,320 @@
+package engine
+
+// The engine keeps the resting orders of every pair in memory so that incoming orders
+// can be matched without querying the database. MongoDB is only written to, the
+// in-memory state is rebuilt from it at startup.
+//
+// priceLevels holds two sides (bids and asks). Each side is a list of price levels
+// sorted from the best price to the worst one (descending for bids, ascending for asks).
+// Each price level is a FIFO queue of orders ranked by their arrival time, which gives
+// us price-time priority when walking the book.
+
+import (
+	"sort"
+	"time"
+
+	"github.com/spf13/cast"
+
+	"github.com/byteball/odex-backend/types"
+)
+
+// priceLevel is the queue of resting orders sharing the same price
+type priceLevel struct {
+	price  types.Decimal
+	orders []*types.Order
+}
+
+// bookSide holds the price levels of one side of the orderbook
+type bookSide struct {
+	side   string
+	levels []*priceLevel
+}
+
+// priceLevels is the resident state of the orderbook of a single pair
+type priceLevels struct {
+	bids   *bookSide
+	asks   *bookSide
+	orders map[string]*types.Order
+}
+
+func newPriceLevels() *priceLevels {
+	return &priceLevels{
+		bids:   &bookSide{side: "BUY"},
+		asks:   &bookSide{side: "SELL"},
+		orders: map[string]*types.Order{},
+	}
+}
+
+// isResting returns true if an order with the given status belongs in the orderbook
+func isResting(status string) bool {
+	return status == "OPEN" || status == "PARTIAL_FILLED"
+}
+
+// isExpiring returns true if the order expires within the minute following the given
+// time. Such orders are not matched anymore and are left to the expired orders cancellation job.
+func isExpiring(o *types.Order, now time.Time) bool {
+	signedMessage, ok := o.OriginalOrder["signed_message"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	expiry, ok := signedMessage["expiry_ts"]
+	if !ok {
+		return false
+	}
+
+	return cast.ToInt64(expiry) < now.Unix()+60
+}
+
+// load replaces the content of the book with the given orders. Orders within a
+// price level are queued by creation time.
+func (pl *priceLevels) load(orders []*types.Order) {
+	sorted := make([]*types.Order, len(orders))
+	copy(sorted, orders)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+	})
+
+	pl.restore(sorted)
+}
+
+// restore replaces the content of the book with the given orders, queued in the given order
+func (pl *priceLevels) restore(orders []*types.Order) {
+	pl.bids.levels = nil
+	pl.asks.levels = nil
+	pl.orders = map[string]*types.Order{}
+
+	for _, o := range orders {
+		pl.sync(o)
+	}
+}
+
+// all returns the resting orders of both sides by price-time priority
+func (pl *priceLevels) all() []*types.Order {
+	orders := []*types.Order{}
+	for _, s := range []*bookSide{pl.bids, pl.asks} {
+		for _, l := range s.levels {
+			orders = append(orders, l.orders...)
+		}
+	}
+
+	return orders
+}
+
+// sync reflects the current state of an order in the book: resting orders are
+// queued (or updated in place, keeping their priority) and other orders are removed.
+func (pl *priceLevels) sync(o *types.Order) {
+	if !isResting(o.Status) {
+		pl.remove(o.Hash)
+		return
+	}
+
+	existing := pl.orders[o.Hash]
+	if existing != nil && existing.Price.Cmp(o.Price) == 0 && existing.Side == o.Side {
+		if existing != o {
+			pl.side(o.Side).replace(existing, o)
+			pl.orders[o.Hash] = o
+		}
+		return
+	}
+
+	if existing != nil {
+		pl.remove(o.Hash)
+	}
+
+	s := pl.side(o.Side)
+	if s == nil {
+		return
+	}
+
+	s.push(o)
+	pl.orders[o.Hash] = o
+}
+
+// requeue moves a resting order to the back of its price level
+func (pl *priceLevels) requeue(o *types.Order) {
+	pl.remove(o.Hash)
+	pl.sync(o)
+}
+
+// amend replaces a resting order with the order amending it, at the same position in the
+// queue of its price level
+func (pl *priceLevels) amend(old *types.Order, o *types.Order) {
+	pl.side(old.Side).replace(old, o)
+	delete(pl.orders, old.Hash)
+	pl.orders[o.Hash] = o
+}
+
+// remove takes the order with the given hash out of the book and returns it
+func (pl *priceLevels) remove(hash string) *types.Order {
+	o := pl.orders[hash]
+	if o == nil {
+		return nil
+	}
+
+	pl.side(o.Side).remove(o)
+	delete(pl.orders, hash)
+	return o
+}
+
+// get returns the resting order with the given hash, if any
+func (pl *priceLevels) get(hash string) *types.Order {
+	return pl.orders[hash]
+}
+
+// len returns the number of resting orders
+func (pl *priceLevels) len() int {
+	return len(pl.orders)
+}
+
+// matchingOrders returns the orders of the opposite side that can be matched against
+// the given taker order at the given time, sorted by price-time priority
+func (pl *priceLevels) matchingOrders(taker *types.Order, now time.Time) []*types.Order {
+	var s *bookSide
+	var crosses func(price types.Decimal) bool
+
+	if taker.Side == "BUY" {
+		s = pl.asks
+		crosses = func(price types.Decimal) bool { return price.Cmp(taker.Price) <= 0 }
+	} else if taker.Side == "SELL" {
+		s = pl.bids
+		crosses = func(price types.Decimal) bool { return price.Cmp(taker.Price) >= 0 }
+	} else {
+		return nil
+	}
+
+	orders := []*types.Order{}
+	for _, l := range s.levels {
+		if !crosses(l.price) {
+			break
+		}
+
+		for _, o := range l.orders {
+			if canMatch(taker, o, now) {
+				orders = append(orders, o)
+			}
+		}
+	}
+
+	return orders
+}
+
+// bestMatchingPrice returns the price of the first order matchingOrders returns for the given
+// taker order when its price is not limited, 0 if there is none. The orders of other matchers
+// and the expiring orders at the top of the book are skipped.
+func (pl *priceLevels) bestMatchingPrice(taker *types.Order, now time.Time) types.Decimal {
+	s := pl.side(oppositeSide(taker.Side))
+	if s == nil {
+		return types.Decimal{}
+	}
+
+	for _, l := range s.levels {
+		for _, o := range l.orders {
+			if canMatch(taker, o, now) {
+				return l.price
+			}
+		}
+	}
+
+	return types.Decimal{}
+}
+
+// canMatch returns true if a resting order can be matched against the given taker order at
+// the given time, whatever their prices
+func canMatch(taker *types.Order, o *types.Order, now time.Time) bool {
+	return o.MatcherAddress == taker.MatcherAddress && !isExpiring(o, now)
+}
+
+func oppositeSide(side string) string {
+	if side == "BUY" {
+		return "SELL"
+	}
+
+	return "BUY"
+}
+
+func (pl *priceLevels) side(side string) *bookSide {
+	if side == "BUY" {
+		return pl.bids
+	}
+
+	if side == "SELL" {
+		return pl.asks
+	}
+
+	return nil
+}
+
+// better returns true if price a has priority over price b on this side of the book
+func (s *bookSide) better(a, b types.Decimal) bool {
+	if s.side == "BUY" {
+		return a.Cmp(b) > 0
+	}
+
+	return a.Cmp(b) < 0
+}
+
+// search returns the index of the first level whose price does not have priority over the given price
+func (s *bookSide) search(price types.Decimal) int {
+	return sort.Search(len(s.levels), func(i int) bool {
+		return !s.better(s.levels[i].price, price)
+	})
+}
+
+// push appends the order at the back of the queue of its price level
+func (s *bookSide) push(o *types.Order) {
+	i := s.search(o.Price)
+	if i < len(s.levels) && s.levels[i].price.Cmp(o.Price) == 0 {
+		s.levels[i].orders = append(s.levels[i].orders, o)
+		return
+	}
+
+	l := &priceLevel{price: o.Price, orders: []*types.Order{o}}
+	s.levels = append(s.levels, nil)
+	copy(s.levels[i+1:], s.levels[i:])
+	s.levels[i] = l
+}
+
+// replace swaps an order for its updated version without changing its position
+func (s *bookSide) replace(old, o *types.Order) {
+	l := s.level(old.Price)
+	if l == nil {
+		return
+	}
+
+	for i, lo := range l.orders {
+		if lo == old {
+			l.orders[i] = o
+			return
+		}
+	}
+}
+
+// remove takes an order out of its price level and drops the level once empty
+func (s *bookSide) remove(o *types.Order) {
+	i := s.search(o.Price)
+	if i >= len(s.levels) || s.levels[i].price.Cmp(o.Price) != 0 {
+		return
+	}
+
+	l := s.levels[i]
+	for j, lo := range l.orders {
+		if lo.Hash == o.Hash {
+			l.orders = append(l.orders[:j], l.orders[j+1:]...)
+			break
+		}
+	}
+
+	if len(l.orders) == 0 {
+		s.levels = append(s.levels[:i], s.levels[i+1:]...)
+	}
+}
+
+func (s *bookSide) level(price types.Decimal) *priceLevel {
+	i := s.search(price)
+	if i < len(s.levels) && s.levels[i].price.Cmp(price) == 0 {
+		return s.levels[i]
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils/testutils"
+)
+
+func newTestOrder(hash string, side string, price float64, createdAt time.Time) *types.Order {
+	return &types.Order{
+		Hash:           hash,
+		Side:           side,
+		Price:          types.NewDecimal(price),
+		Status:         "OPEN",
+		MatcherAddress: testutils.GetTestAddress1(),
+		CreatedAt:      createdAt,
+	}
+}
+
+func hashes(orders []*types.Order) []string {
+	res := []string{}
+	for _, o := range orders {
+		res = append(res, o.Hash)
+	}
+
+	return res
+}
+
+func TestPriceLevelsPriceTimePriority(t *testing.T) {
+	pl := newPriceLevels()
+	now := time.Now()
+
+	pl.load([]*types.Order{
+		newTestOrder("s3", "SELL", 1.2, now.Add(-1*time.Second)),
+		newTestOrder("s1", "SELL", 1.1, now.Add(-3*time.Second)),
+		newTestOrder("s2", "SELL", 1.1, now.Add(-2*time.Second)),
+		newTestOrder("b1", "BUY", 1.0, now.Add(-2*time.Second)),
+		newTestOrder("b2", "BUY", 1.05, now.Add(-1*time.Second)),
+	})
+
+	assert.Equal(t, 5, pl.len())
+
+	buy := newTestOrder("taker1", "BUY", 1.15, now)
+	assert.Equal(t, []string{"s1", "s2"}, hashes(pl.matchingOrders(buy, now)))
+
+	buy.Price = types.NewDecimal(1.3)
+	assert.Equal(t, []string{"s1", "s2", "s3"}, hashes(pl.matchingOrders(buy, now)))
+
+	sell := newTestOrder("taker2", "SELL", 0.9, now)
+	assert.Equal(t, []string{"b2", "b1"}, hashes(pl.matchingOrders(sell, now)))
+
+	sell.Price = types.NewDecimal(1.1)
+	assert.Empty(t, pl.matchingOrders(sell, now))
+
+	// new orders at an existing price level are queued at the back
+	pl.sync(newTestOrder("s0", "SELL", 1.1, now))
+	buy.Price = types.NewDecimal(1.1)
+	assert.Equal(t, []string{"s1", "s2", "s0"}, hashes(pl.matchingOrders(buy, now)))
+}
+
+func TestPriceLevelsRequeue(t *testing.T) {
+	pl := newPriceLevels()
+	now := time.Now()
+
+	s1 := newTestOrder("s1", "SELL", 1.1, now.Add(-2*time.Second))
+	pl.load([]*types.Order{s1, newTestOrder("s2", "SELL", 1.1, now.Add(-1*time.Second))})
+
+	pl.requeue(s1)
+	buy := newTestOrder("taker", "BUY", 1.1, now)
+	assert.Equal(t, []string{"s2", "s1"}, hashes(pl.matchingOrders(buy, now)))
+	assert.Equal(t, types.NewDecimal(1.1), pl.bestMatchingPrice(buy, now))
+	assert.Equal(t, types.Decimal{}, pl.bestMatchingPrice(newTestOrder("taker", "SELL", 1.1, now), now))
+}
+
+func TestPriceLevelsSync(t *testing.T) {
+	pl := newPriceLevels()
+	now := time.Now()
+
+	s1 := newTestOrder("s1", "SELL", 1.1, now)
+	s2 := newTestOrder("s2", "SELL", 1.1, now)
+	pl.sync(s1)
+	pl.sync(s2)
+
+	// partially filled orders keep their priority
+	updated := *s1
+	updated.Status = "PARTIAL_FILLED"
+	updated.FilledAmount = 10
+	pl.sync(&updated)
+
+	buy := newTestOrder("taker", "BUY", 1.1, now)
+	matches := pl.matchingOrders(buy, now)
+	assert.Equal(t, []string{"s1", "s2"}, hashes(matches))
+	assert.Equal(t, int64(10), matches[0].FilledAmount)
+
+	// filled and cancelled orders leave the book
+	updated.Status = "FILLED"
+	pl.sync(&updated)
+	assert.Equal(t, []string{"s2"}, hashes(pl.matchingOrders(buy, now)))
+
+	pl.remove("s2")
+	assert.Empty(t, pl.matchingOrders(buy, now))
+	assert.Equal(t, 0, pl.len())
+	assert.Empty(t, pl.asks.levels)
+}
+
+func TestPriceLevelsAmend(t *testing.T) {
+	pl := newPriceLevels()
+	now := time.Now()
+
+	s1 := newTestOrder("s1", "SELL", 1.1, now.Add(-2*time.Second))
+	pl.load([]*types.Order{s1, newTestOrder("s2", "SELL", 1.1, now.Add(-1*time.Second))})
+
+	// the amending order takes the place of the amended order
+	pl.amend(s1, newTestOrder("a1", "SELL", 1.1, now))
+	buy := newTestOrder("taker", "BUY", 1.1, now)
+	assert.Equal(t, []string{"a1", "s2"}, hashes(pl.matchingOrders(buy, now)))
+	assert.Nil(t, pl.get("s1"))
+	assert.Equal(t, 2, pl.len())
+}
+
+func TestPriceLevelsSkipsOtherMatchersAndExpiringOrders(t *testing.T) {
+	pl := newPriceLevels()
+	now := time.Now()
+
+	other := newTestOrder("s1", "SELL", 1.1, now)
+	other.MatcherAddress = testutils.GetTestAddress2()
+
+	expiring := newTestOrder("s2", "SELL", 1.1, now)
+	expiring.OriginalOrder = map[string]interface{}{
+		"signed_message": map[string]interface{}{"expiry_ts": float64(now.Unix() + 10)},
+	}
+
+	valid := newTestOrder("s3", "SELL", 1.1, now)
+	valid.OriginalOrder = map[string]interface{}{
+		"signed_message": map[string]interface{}{"expiry_ts": float64(now.Unix() + 3600)},
+	}
+
+	pl.load([]*types.Order{other, expiring, valid})
+
+	buy := newTestOrder("taker", "BUY", 1.1, now)
+	assert.Equal(t, []string{"s3"}, hashes(pl.matchingOrders(buy, now)))
+
+	// the price bounding a market order is the one of the first matching order
+	assert.Equal(t, types.NewDecimal(1.1), pl.bestMatchingPrice(buy, now))
+
+	pl.remove("s3")
+	pl.sync(newTestOrder("s4", "SELL", 1.2, now))
+	assert.Equal(t, types.NewDecimal(1.2), pl.bestMatchingPrice(buy, now))
+}
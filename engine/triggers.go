@@ -0,0 +1,149 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/byteball/odex-backend/types"
+)
+
+// triggerBook holds the stop-loss and take-profit orders of a pair until the last
+// trade price crosses their stop price. Released orders are sent back to the engine
+// as new orders.
+type triggerBook struct {
+	orders    map[string]*types.Order
+	lastPrice types.Decimal
+
+	// released orders are on their way back to the engine. An order cancelled in the
+	// meantime is recorded in the cancelled set and dropped when it comes back.
+	released  map[string]bool
+	cancelled map[string]bool
+}
+
+func newTriggerBook() *triggerBook {
+	return &triggerBook{
+		orders:    map[string]*types.Order{},
+		released:  map[string]bool{},
+		cancelled: map[string]bool{},
+	}
+}
+
+// load replaces the content of the trigger book with the given orders
+func (tb *triggerBook) load(orders []*types.Order, lastPrice types.Decimal) {
+	tb.orders = map[string]*types.Order{}
+	tb.lastPrice = lastPrice
+
+	for _, o := range orders {
+		tb.add(o)
+	}
+}
+
+// restore replaces the content of the trigger book with the state saved in a snapshot
+func (tb *triggerBook) restore(orders []*types.Order, lastPrice types.Decimal, released []string, cancelled []string) {
+	tb.load(orders, lastPrice)
+
+	tb.released = map[string]bool{}
+	for _, h := range released {
+		tb.released[h] = true
+	}
+
+	tb.cancelled = map[string]bool{}
+	for _, h := range cancelled {
+		tb.cancelled[h] = true
+	}
+}
+
+func (tb *triggerBook) add(o *types.Order) {
+	tb.orders[o.Hash] = o
+}
+
+// remove takes the order with the given hash out of the trigger book and returns it
+func (tb *triggerBook) remove(hash string) *types.Order {
+	o := tb.orders[hash]
+	if o == nil {
+		return nil
+	}
+
+	delete(tb.orders, hash)
+	return o
+}
+
+// cancel removes an order from the trigger book. If the order was already released,
+// it is marked as cancelled so that it is not added to the orderbook.
+func (tb *triggerBook) cancel(hash string) {
+	if tb.remove(hash) != nil {
+		return
+	}
+
+	if tb.released[hash] {
+		delete(tb.released, hash)
+		tb.cancelled[hash] = true
+	}
+}
+
+// activate is called when a released order comes back to the engine. It returns false
+// if the order was cancelled after being released.
+func (tb *triggerBook) activate(hash string) bool {
+	delete(tb.released, hash)
+
+	if tb.cancelled[hash] {
+		delete(tb.cancelled, hash)
+		return false
+	}
+
+	return true
+}
+
+func (tb *triggerBook) get(hash string) *types.Order {
+	return tb.orders[hash]
+}
+
+// all returns the orders of the trigger book by creation time
+func (tb *triggerBook) all() []*types.Order {
+	orders := []*types.Order{}
+	for _, o := range tb.orders {
+		orders = append(orders, o)
+	}
+
+	sortByCreation(orders)
+	return orders
+}
+
+func (tb *triggerBook) len() int {
+	return len(tb.orders)
+}
+
+// release records a new last trade price and removes the orders triggered by it from
+// the trigger book. The released orders are returned by creation time.
+func (tb *triggerBook) release(price types.Decimal) []*types.Order {
+	if !price.IsZero() {
+		tb.lastPrice = price
+	}
+
+	released := []*types.Order{}
+	for _, o := range tb.orders {
+		if o.IsTriggeredBy(tb.lastPrice) {
+			released = append(released, o)
+		}
+	}
+
+	sortByCreation(released)
+
+	for _, o := range released {
+		delete(tb.orders, o.Hash)
+		tb.released[o.Hash] = true
+	}
+
+	return released
+}
+
+// sortByCreation sorts orders by creation time, then by hash so that the order does not
+// depend on the map iteration
+func sortByCreation(orders []*types.Order) {
+	sort.Slice(orders, func(i, j int) bool {
+		if orders[i].CreatedAt.Equal(orders[j].CreatedAt) {
+			return orders[i].Hash < orders[j].Hash
+		}
+
+		return orders[i].CreatedAt.Before(orders[j].CreatedAt)
+	})
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/byteball/odex-backend/types"
+)
+
+func newTestTriggerOrder(hash string, side string, orderType string, stopPrice float64, createdAt time.Time) *types.Order {
+	o := newTestOrder(hash, side, stopPrice, createdAt)
+	o.Type = orderType
+	o.StopPrice = types.NewDecimal(stopPrice)
+	o.Status = "UNTRIGGERED"
+	return o
+}
+
+func TestTriggerBookRelease(t *testing.T) {
+	tb := newTriggerBook()
+	now := time.Now()
+
+	tb.load([]*types.Order{
+		newTestTriggerOrder("sl2", "SELL", "STOP_LOSS", 90, now.Add(-1*time.Second)),
+		newTestTriggerOrder("sl1", "SELL", "STOP_LOSS_LIMIT", 95, now.Add(-2*time.Second)),
+		newTestTriggerOrder("tp1", "SELL", "TAKE_PROFIT", 110, now),
+	}, types.NewDecimal(100))
+
+	assert.Empty(t, tb.release(types.NewDecimal(0)))
+	assert.Empty(t, tb.release(types.NewDecimal(96)))
+	assert.Equal(t, []string{"sl1"}, hashes(tb.release(types.NewDecimal(95))))
+	assert.Equal(t, types.NewDecimal(95), tb.lastPrice)
+	assert.Equal(t, []string{"tp1"}, hashes(tb.release(types.NewDecimal(120))))
+	assert.Equal(t, 1, tb.len())
+
+	tb.add(newTestTriggerOrder("sl3", "SELL", "STOP_LOSS", 95, now.Add(-3*time.Second)))
+	assert.Equal(t, []string{"sl3", "sl2"}, hashes(tb.release(types.NewDecimal(80))))
+	assert.Equal(t, 0, tb.len())
+}
+
+func TestTriggerBookCancel(t *testing.T) {
+	tb := newTriggerBook()
+	now := time.Now()
+
+	tb.add(newTestTriggerOrder("sl1", "SELL", "STOP_LOSS", 95, now))
+	tb.add(newTestTriggerOrder("sl2", "SELL", "STOP_LOSS", 95, now))
+	tb.add(newTestTriggerOrder("sl3", "SELL", "STOP_LOSS", 90, now))
+
+	tb.cancel("sl3")
+	assert.Nil(t, tb.get("sl3"))
+
+	released := tb.release(types.NewDecimal(90))
+	assert.Equal(t, 2, len(released))
+
+	// sl1 is cancelled while on its way back to the engine
+	tb.cancel("sl1")
+	assert.False(t, tb.activate("sl1"))
+	assert.True(t, tb.activate("sl2"))
+}
@@ -0,0 +1,99 @@
+package engine
+
+import (
+	"sync"
+
+	"github.com/byteball/odex-backend/types"
+)
+
+// orderMessage is an engine message routed to the worker of a pair. RESTORE_ORDER messages
+// carry the trade whose amounts are given back to its maker order. done (if any) is called
+// once the message is processed, with the processing error.
+type orderMessage struct {
+	ID    string
+	Type  string
+	Order *types.Order
+	Trade *types.Trade
+	done  func(error)
+}
+
+// worker runs the orderbook of a pair in its own goroutine. The messages of a pair are
+// processed one at a time in the order they were dispatched while the different pairs
+// are matched in parallel.
+// Each worker consumes its own queue of pending messages: dispatching never waits for the
+// worker, a busy pair doesn't hold back the messages of the other pairs.
+type worker struct {
+	orderbook *OrderBook
+	mutex     *sync.Mutex
+	cond      *sync.Cond
+	pending   []*orderMessage
+}
+
+func newWorker(ob *OrderBook) *worker {
+	mutex := &sync.Mutex{}
+	w := &worker{
+		orderbook: ob,
+		mutex:     mutex,
+		cond:      sync.NewCond(mutex),
+	}
+
+	go w.run()
+	return w
+}
+
+func (w *worker) run() {
+	for {
+		m := w.next()
+
+		var err error
+		if m.Type == "RESTORE_ORDER" {
+			err = w.orderbook.restoreOrder(m.Trade, m.ID)
+		} else {
+			err = w.orderbook.process(m.Type, m.Order, m.ID)
+		}
+
+		if err != nil {
+			logger.Error(err)
+		}
+
+		if m.done != nil {
+			m.done(err)
+		}
+	}
+}
+
+// next waits for the next pending message and removes it from the queue
+func (w *worker) next() *orderMessage {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for len(w.pending) == 0 {
+		w.cond.Wait()
+	}
+
+	m := w.pending[0]
+	w.pending[0] = nil
+	w.pending = w.pending[1:]
+	return m
+}
+
+// push queues a message for the worker
+func (w *worker) push(m *orderMessage) {
+	w.mutex.Lock()
+	w.pending = append(w.pending, m)
+	w.mutex.Unlock()
+
+	w.cond.Signal()
+}
+
+// dispatch queues the engine message with the given ID for the worker, done is called once
+// it is processed
+func (w *worker) dispatch(msgType string, o *types.Order, messageID string, done func(error)) {
+	w.push(&orderMessage{ID: messageID, Type: msgType, Order: o, done: done})
+}
+
+// restore queues the restoration of the maker order of a trade, done is called once it
+// is processed
+func (w *worker) restore(t *types.Trade, messageID string, done func(error)) {
+	w.push(&orderMessage{ID: messageID, Type: "RESTORE_ORDER", Trade: t, done: done})
+}
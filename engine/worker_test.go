@@ -0,0 +1,231 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/byteball/odex-backend/app"
+	"github.com/byteball/odex-backend/interfaces"
+	"github.com/byteball/odex-backend/types"
+)
+
+// The stubs below stand in for the database and rabbitmq in the benchmark. They are
+// not mocks so that the benchmark does not measure the locking of the mock objects.
+
+type benchOrderDao struct {
+	interfaces.OrderDao
+}
+
+func (d *benchOrderDao) GetRawOrderBook(*types.Pair) ([]*types.Order, error) {
+	return nil, nil
+}
+
+func (d *benchOrderDao) GetUntriggeredOrders(*types.Pair) ([]*types.Order, error) {
+	return nil, nil
+}
+
+func (d *benchOrderDao) FindAndModify(h string, o *types.Order) (*types.Order, error) {
+	return o, nil
+}
+
+func (d *benchOrderDao) UpdateOrderStatus(h string, status string) error {
+	return nil
+}
+
+type benchTradeDao struct {
+	interfaces.TradeDao
+}
+
+func (d *benchTradeDao) GetSortedTrades(bt, qt string, n int) ([]*types.Trade, error) {
+	return nil, nil
+}
+
+type benchPairDao struct {
+	interfaces.PairDao
+	pairs []types.Pair
+}
+
+func (d *benchPairDao) GetAll() ([]types.Pair, error) {
+	return d.pairs, nil
+}
+
+// benchJournalDao marks the processed inputs as done in a wait group
+type benchJournalDao struct {
+	interfaces.JournalDao
+	processed *sync.WaitGroup
+}
+
+func (d *benchJournalDao) AppendEntry(e *types.JournalEntry) error {
+	return nil
+}
+
+func (d *benchJournalDao) CompleteEntry(e *types.JournalEntry) error {
+	d.processed.Done()
+	return nil
+}
+
+func (d *benchJournalDao) GetEntries(pairCode string, after int64) ([]*types.JournalEntry, error) {
+	return nil, nil
+}
+
+func (d *benchJournalDao) GetLatestSnapshot(pairCode string) (*types.EngineSnapshot, error) {
+	return nil, nil
+}
+
+func (d *benchJournalDao) SaveSnapshot(s *types.EngineSnapshot) error {
+	return nil
+}
+
+type benchObyteProvider struct {
+	interfaces.ObyteProvider
+}
+
+func (p *benchObyteProvider) GetOperatorAddress() string {
+	return "OPERATOR"
+}
+
+type benchOrderService struct {
+	interfaces.OrderService
+}
+
+func (s *benchOrderService) FixOrderStatus(o *types.Order) {}
+
+type benchPublisher struct{}
+
+func (p *benchPublisher) PublishEngineResponse(res *types.EngineResponse) error {
+	return nil
+}
+
+func (p *benchPublisher) PublishNewOrderMessage(o *types.Order) error {
+	return nil
+}
+
+func newBenchPair(i int) types.Pair {
+	return types.Pair{
+		BaseTokenSymbol:  fmt.Sprintf("BASE%d", i),
+		QuoteTokenSymbol: "GBYTE",
+		BaseAsset:        fmt.Sprintf("base%d", i),
+		QuoteAsset:       "base",
+	}
+}
+
+// newBenchOrder returns an order of 1e6 units of base asset at 1.5. A sell order is fully
+// filled by the next buy order of the pair.
+func newBenchOrder(p *types.Pair, side string, n int) *types.Order {
+	o := &types.Order{
+		Hash:           fmt.Sprintf("%s %d", p.Code(), n),
+		UserAddress:    fmt.Sprintf("USER%d", n%2),
+		MatcherAddress: "OPERATOR",
+		BaseToken:      p.BaseAsset,
+		QuoteToken:     p.QuoteAsset,
+		PairName:       p.Name(),
+		Side:           side,
+		Price:          types.NewDecimal(1.5),
+		Amount:         1e6,
+		CreatedAt:      time.Now(),
+	}
+
+	price := 1.5
+	o.RemainingSellAmount = 1e6
+	if side == "BUY" {
+		price = 1 / 1.5
+		o.RemainingSellAmount = 1.5e6
+	}
+
+	o.OriginalOrder = map[string]interface{}{
+		"signed_message": map[string]interface{}{"price": price},
+	}
+
+	return o
+}
+
+func benchmarkEngine(b *testing.B, n int) {
+	app.Config.EngineSnapshotInterval = 1000
+
+	pairs := []types.Pair{}
+	for i := 0; i < n; i++ {
+		pairs = append(pairs, newBenchPair(i))
+	}
+
+	processed := &sync.WaitGroup{}
+	e := NewEngine(
+		&benchPublisher{},
+		&benchOrderDao{},
+		&benchTradeDao{},
+		&benchPairDao{pairs: pairs},
+		&benchObyteProvider{},
+		&benchOrderService{},
+		&benchJournalDao{processed: processed},
+	)
+
+	orders := make([]*types.Order, b.N)
+	for i := range orders {
+		side := "SELL"
+		if (i/n)%2 == 1 {
+			side = "BUY"
+		}
+
+		orders[i] = newBenchOrder(&pairs[i%n], side, i)
+	}
+
+	processed.Add(b.N)
+	b.ResetTimer()
+
+	for i, o := range orders {
+		e.worker(pairs[i%n].Code()).dispatch("NEW_ORDER", o, "", nil)
+	}
+
+	processed.Wait()
+}
+
+// BenchmarkEngineManyPairs measures the matching throughput when the orders are spread
+// over several pairs, each pair being matched by its own worker
+func BenchmarkEngineManyPairs(b *testing.B) {
+	for _, n := range []int{1, 4, 16, 64} {
+		b.Run(fmt.Sprintf("pairs=%d", n), func(b *testing.B) {
+			benchmarkEngine(b, n)
+		})
+	}
+}
+
+// TestWorkerDone checks that the messages of a pair are marked as done in the order they
+// were dispatched, once processed
+func TestWorkerDone(t *testing.T) {
+	app.Config.EngineSnapshotInterval = 1000
+
+	p := newBenchPair(0)
+	processed := &sync.WaitGroup{}
+	e := NewEngine(
+		&benchPublisher{},
+		&benchOrderDao{},
+		&benchTradeDao{},
+		&benchPairDao{pairs: []types.Pair{p}},
+		&benchObyteProvider{},
+		&benchOrderService{},
+		&benchJournalDao{processed: processed},
+	)
+
+	done := make(chan string, 2)
+	processed.Add(2)
+	for i, side := range []string{"SELL", "BUY"} {
+		o := newBenchOrder(&p, side, i)
+		e.worker(p.Code()).dispatch("NEW_ORDER", o, "", func(err error) {
+			if err != nil {
+				t.Error(err)
+			}
+
+			done <- o.Hash
+		})
+	}
+
+	processed.Wait()
+	if h := <-done; h != p.Code()+" 0" {
+		t.Errorf("unexpected first message %v", h)
+	}
+
+	if h := <-done; h != p.Code()+" 1" {
+		t.Errorf("unexpected second message %v", h)
+	}
+}
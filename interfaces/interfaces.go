@@ -26,8 +26,11 @@ type OrderDao interface {
 	GetCurrentByUserAddress(a string, limit ...int) ([]*types.Order, error)
 	GetCurrentByUserAddressAndSignerAddress(address string, signer string) ([]*types.Order, error)
 	GetHistoryByUserAddress(a string, limit ...int) ([]*types.Order, error)
+	GetPageByUserAddress(q *types.HistoryQuery) ([]*types.Order, error)
+	GetHistoryPageByUserAddress(q *types.HistoryQuery) ([]*types.Order, error)
 	GetMatchingBuyOrders(o *types.Order) ([]*types.Order, error)
 	GetMatchingSellOrders(o *types.Order) ([]*types.Order, error)
+	GetBestPrice(p *types.Pair, side string, matcherAddress string) (types.Decimal, error)
 	GetExpiredOrders() ([]*types.Order, error)
 	UpdateOrderFilledAmount(h string, value int64) error
 	UpdateOrderFilledAmounts(h []string, values []int64) ([]*types.Order, error)
@@ -35,8 +38,9 @@ type OrderDao interface {
 	GetUserLockedBalance(account string, token string) (int64, []*types.Order, error)
 	UpdateOrderStatus(h string, status string) error
 	GetRawOrderBook(*types.Pair) ([]*types.Order, error)
+	GetUntriggeredOrders(p *types.Pair) ([]*types.Order, error)
 	GetOrderBook(*types.Pair) ([]map[string]interface{}, []map[string]interface{}, error)
-	GetOrderBookPrice(p *types.Pair, pp float64, side string) (int64, string, float64, error)
+	GetOrderBookPrice(p *types.Pair, pp types.Decimal, side string) (int64, string, float64, error)
 	FindAndModify(h string, o *types.Order) (*types.Order, error)
 	Drop() error
 	Aggregate(q []bson.M) ([]*types.OrderData, error)
@@ -84,6 +88,7 @@ type TradeDao interface {
 	GetByOrderHashes(hashes []string) ([]*types.Trade, error)
 	GetSortedTrades(bt, qt string, n int) ([]*types.Trade, error)
 	GetSortedTradesByUserAddress(a string, limit ...int) ([]*types.Trade, error)
+	GetPageByUserAddress(q *types.HistoryQuery) ([]*types.Trade, error)
 	GetUncommittedTradesByUserAddress(a string) []*types.Trade
 	GetNTradesByPairAssets(bt, qt string, n int) ([]*types.Trade, error)
 	GetTradesByPairAssets(bt, qt string, n int) ([]*types.Trade, error)
@@ -93,6 +98,8 @@ type TradeDao interface {
 	UpdateTradeStatus(h string, status string) error
 	UpdateTradeStatuses(status string, hashes ...string) ([]*types.Trade, error)
 	UpdateTradeStatusesByOrderHashes(status string, hashes ...string) ([]*types.Trade, error)
+	GetUnsettledTrades(before time.Time) ([]*types.Trade, error)
+	GetUserVolume(address string, pairName string, since time.Time) (int64, error)
 	Drop()
 }
 
@@ -111,6 +118,44 @@ type TokenDao interface {
 	Drop() error
 }
 
+type JournalDao interface {
+	AppendEntry(e *types.JournalEntry) error
+	CompleteEntry(e *types.JournalEntry) error
+	GetEntries(pairCode string, after int64) ([]*types.JournalEntry, error)
+	GetLatestSnapshot(pairCode string) (*types.EngineSnapshot, error)
+	SaveSnapshot(s *types.EngineSnapshot) error
+	Drop()
+}
+
+type AffiliateFeeDao interface {
+	Upsert(f *types.AffiliateFee) error
+	GetByAffiliate(address string, from, to time.Time) ([]*types.AffiliateFee, error)
+	GetRevenue(address string, unit string, from, to time.Time) ([]*types.AffiliateRevenue, error)
+	Drop()
+}
+
+type FeeScheduleDao interface {
+	Upsert(s *types.FeeSchedule) error
+	GetAll() ([]*types.FeeSchedule, error)
+	GetByPairName(pairName string) (*types.FeeSchedule, error)
+	DeleteByPairName(pairName string) error
+	Drop()
+}
+
+type MatcherAlertDao interface {
+	Create(a *types.MatcherAlert) error
+	GetLatest(matcherAddress string, limit int) ([]*types.MatcherAlert, error)
+	Drop()
+}
+
+type APIKeyDao interface {
+	Create(k *types.APIKey) error
+	GetByKey(key string) (*types.APIKey, error)
+	GetByAddress(address string) ([]*types.APIKey, error)
+	Delete(address string, key string) error
+	Drop()
+}
+
 type Engine interface {
 	HandleOrders(msg *rabbitmq.Message) error
 	// RecoverOrders(matches types.Matches) error
@@ -118,6 +163,24 @@ type Engine interface {
 	// DeleteOrder(o *types.Order) error
 }
 
+// TxQueueBroker holds the matches waiting in the transaction queues (rabbitmq.Connection)
+type TxQueueBroker interface {
+	QueueLength(name string) int
+	PublishPendingTrades(name string, m *types.Matches) error
+}
+
+// TxErrorPublisher publishes the rejections of the trades not settled by the exchange AA
+// (rabbitmq.Connection)
+type TxErrorPublisher interface {
+	PublishTxErrorMessage(m *types.Matches, errType string) error
+}
+
+// EnginePublisher publishes the messages of the matching engine (rabbitmq.Connection)
+type EnginePublisher interface {
+	PublishEngineResponse(res *types.EngineResponse) error
+	PublishNewOrderMessage(o *types.Order) error
+}
+
 type InfoService interface {
 	GetExchangeData() (*types.ExchangeData, error)
 	GetExchangeStats() (*types.ExchangeStats, error)
@@ -138,13 +201,21 @@ type OrderService interface {
 	GetByUserAddress(a string, limit ...int) ([]*types.Order, error)
 	GetCurrentByUserAddress(a string, limit ...int) ([]*types.Order, error)
 	GetHistoryByUserAddress(a string, limit ...int) ([]*types.Order, error)
+	GetPageByUserAddress(q *types.HistoryQuery) ([]*types.Order, string, error)
+	GetHistoryPageByUserAddress(q *types.HistoryQuery) ([]*types.Order, string, error)
 	NewOrder(o *types.Order) error
+	AmendOrder(o *types.Order) error
 	CancelOrder(oc *types.OrderCancel) error
+	CancelAllOrders(oca *types.OrderCancelAll) (*types.OrderCancelAllSummary, error)
 	HandleEngineResponse(res *types.EngineResponse) error
 	GetSenderAddresses(oc *types.OrderCancel) (string, string, error)
 	CheckIfBalancesAreSufficientAndCancel(address string, balances map[string]int64)
 	CancelOrdersSignedByRevokedSigner(address string, signer string)
 	CancelExpiredOrders()
+	AutoCancelOrders(address string) error
+	EnableCancelOnDisconnect(c *ws.Client, address string, timeout time.Duration)
+	DisableCancelOnDisconnect(c *ws.Client, address string)
+	Heartbeat(c *ws.Client, address string) bool
 	AdjustBalancesForUncommittedTrades(address string, balances map[string]int64) map[string]int64
 	FixOrderStatus(o *types.Order)
 }
@@ -194,6 +265,7 @@ type TradeService interface {
 	GetAllTradesByPairAssets(bt, qt string) ([]*types.Trade, error)
 	GetSortedTrades(bt, qt string, n int) ([]*types.Trade, error)
 	GetSortedTradesByUserAddress(a string, limit ...int) ([]*types.Trade, error)
+	GetPageByUserAddress(q *types.HistoryQuery) ([]*types.Trade, string, error)
 	GetByUserAddress(a string) ([]*types.Trade, error)
 	GetByHash(h string) (*types.Trade, error)
 	GetByOrderHashes(h []string) ([]*types.Trade, error)
@@ -201,6 +273,7 @@ type TradeService interface {
 	GetByTakerOrderHash(h string) ([]*types.Trade, error)
 	GetByTriggerUnitHash(h string) ([]*types.Trade, error)
 	GetByHashes(hashes []string) ([]*types.Trade, error)
+	GetUnsettledTrades(before time.Time) ([]*types.Trade, error)
 	UpdateTradeTxHash(tr *types.Trade, txh string) error
 	UpdateSuccessfulTrade(t *types.Trade) (*types.Trade, error)
 	UpdateTradeStatus(t *types.Trade, status string) (*types.Trade, error)
@@ -210,6 +283,34 @@ type TradeService interface {
 	Unsubscribe(c *ws.Client)
 }
 
+type FeeService interface {
+	GetSchedules() ([]*types.FeeSchedule, error)
+	GetSchedule(pairName string) (*types.FeeSchedule, error)
+	UpdateSchedule(s *types.FeeSchedule) error
+	DeleteSchedule(pairName string) error
+	GetUserFees(address string, pairName string) (*types.UserFees, error)
+}
+
+type AffiliateService interface {
+	RecordTrade(t *types.Trade, orders ...*types.Order) error
+	GetFees(address string, from, to time.Time) ([]*types.AffiliateFee, error)
+	GetRevenue(address string, unit string, from, to time.Time) ([]*types.AffiliateRevenue, error)
+}
+
+type WatchdogService interface {
+	CheckTrade(t *types.MatcherTrade) ([]*types.MatcherAlert, error)
+	GetAlerts(matcherAddress string, limit int) ([]*types.MatcherAlert, error)
+	Subscribe(c *ws.Client)
+	Unsubscribe(c *ws.Client)
+}
+
+type APIKeyService interface {
+	CreateKey(address string, label string) (*types.APIKey, error)
+	GetKeys(address string) ([]*types.APIKey, error)
+	RevokeKey(address string, key string) error
+	Authenticate(key, timestamp, signature, method, uri string, body []byte) (*types.APIKey, error)
+}
+
 type AccountService interface {
 	GetAll() ([]types.Account, error)
 	Create(account *types.Account) error
@@ -224,6 +325,7 @@ type ValidatorService interface {
 	ValidateOperatorAddress(o *types.Order) error
 	ValidateBalance(o *types.Order) error
 	ValidateAvailableBalance(o *types.Order, uncommittedDeltas map[string]int64, balanceLockedInMemoryOrders int64) error
+	ValidateMatcherFee(o *types.Order) error
 	//VerifySignature(o *types.Order) (string, error)
 	//VerifyCancelSignature(oc *types.OrderCancel) (string, error)
 }
@@ -233,6 +335,12 @@ type PriceService interface {
 	GetMultipleMarketPrices(baseCurrencies []string, quoteCurrencies []string) (map[string]map[string]float64, error)
 }
 
+type DeadLetterQueue interface {
+	GetDeadLetters() ([]*types.DeadLetter, error)
+	ReplayDeadLetter(id string) error
+	DiscardDeadLetter(id string) error
+}
+
 type ObyteProvider interface {
 	BalanceOf(owner string, token string) (int64, error)
 	GetBalances(owner string) map[string]int64
@@ -244,8 +352,13 @@ type ObyteProvider interface {
 	//VerifySignature(order *types.Order) (string, error)
 	//VerifyCancelSignature(oc *types.OrderCancel) (string, error)
 	AddOrder(signedOrder *interface{}) (string, error)
+	AmendOrder(signedAmend *interface{}) (string, error)
 	CancelOrder(signedCancel *interface{}) error
+	CancelAllOrders(signedCancel *interface{}) error
 	GetAuthorizedAddresses(address string) ([]string, error)
 	ExecuteTrade(m *types.Matches) ([]string, error)
+	ExecuteTrades(batch []*types.Matches) ([]string, error)
+	GetTriggerUnitStatus(unit string) (*types.TriggerUnitStatus, error)
 	ListenToEvents() (chan map[string]interface{}, error)
+	ConnectionState() types.NodeConnectionState
 }
@@ -0,0 +1,126 @@
+package obyte
+
+// The events of the node are received on a websocket. When the connection drops (wallet restart,
+// network error), the provider reconnects with an exponential backoff and subscribes again with
+// the sequence number of the last event it received: the node first replays the events emitted
+// while the provider was disconnected, the events already received are skipped.
+//
+// The first subscription has no lastEventId, so nodes that do not replay events still accept it.
+// Resuming after a reconnection requires a node that numbers its events and handles lastEventId.
+
+import (
+	"expvar"
+	"log"
+	"time"
+
+	"github.com/byteball/odex-backend/app"
+	"github.com/byteball/odex-backend/types"
+	"github.com/gorilla/websocket"
+)
+
+// delays between the reconnection attempts, variables so that the tests can shorten them
+var (
+	minReconnectDelay = 1 * time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// metrics published on /debug/vars
+var (
+	nodeConnected  = expvar.NewInt("obyte_node_connected")
+	nodeReconnects = expvar.NewInt("obyte_node_reconnects")
+)
+
+// ConnectionState returns the state of the websocket connection to the node
+func (o *ObyteProvider) ConnectionState() types.NodeConnectionState {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.state
+}
+
+// connect opens the websocket to the node and subscribes to its events. When resuming after a
+// reconnection, the subscription asks for the events following the last event received.
+func (o *ObyteProvider) connect(resume bool) error {
+	wsconn, _, err := websocket.DefaultDialer.Dial(app.Config.Obyte["ws_url"], nil)
+	if err != nil {
+		return err
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	params := map[string]interface{}{}
+	if resume {
+		params["lastEventId"] = o.state.LastEventId
+	}
+
+	subscription := map[string]interface{}{
+		"event": "subscribe",
+		"data":  []interface{}{params},
+	}
+
+	err = wsconn.WriteJSON(subscription)
+	if err != nil {
+		wsconn.Close()
+		return err
+	}
+
+	o.WSClient = wsconn
+	o.state.Connected = true
+	o.state.Since = time.Now()
+	nodeConnected.Set(1)
+
+	return nil
+}
+
+// reconnect closes the broken websocket and retries to connect until it succeeds
+func (o *ObyteProvider) reconnect() {
+	o.mutex.Lock()
+	o.WSClient.Close()
+	o.state.Connected = false
+	o.state.Since = time.Now()
+	nodeConnected.Set(0)
+	o.mutex.Unlock()
+
+	delay := minReconnectDelay
+	for {
+		time.Sleep(delay)
+
+		err := o.connect(true)
+		if err == nil {
+			break
+		}
+
+		log.Println("could not reconnect to the wallet:", err)
+		delay *= 2
+		if delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+
+	o.mutex.Lock()
+	o.state.Reconnects++
+	o.mutex.Unlock()
+	nodeReconnects.Add(1)
+
+	log.Println("reconnected to the wallet")
+}
+
+// acceptEvent records the sequence number of an event and returns false if the event was already
+// received before a reconnection. The events without sequence number are always accepted.
+func (o *ObyteProvider) acceptEvent(ev map[string]interface{}) bool {
+	id, ok := ev["id"].(float64)
+	if !ok {
+		return true
+	}
+
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if int64(id) <= o.state.LastEventId {
+		return false
+	}
+
+	o.state.LastEventId = int64(id)
+	return true
+}
@@ -0,0 +1,166 @@
+package obyte
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/byteball/odex-backend/app"
+	"github.com/gorilla/websocket"
+	sync "github.com/sasha-s/go-deadlock"
+	"github.com/stretchr/testify/assert"
+)
+
+// testNode is a websocket server standing for the node. It records the subscriptions it
+// receives and hands each connection to the test.
+type testNode struct {
+	server        *httptest.Server
+	subscriptions chan map[string]interface{}
+	conns         chan *websocket.Conn
+}
+
+func newTestNode(t *testing.T) *testNode {
+	n := &testNode{
+		subscriptions: make(chan map[string]interface{}, 10),
+		conns:         make(chan *websocket.Conn, 10),
+	}
+
+	upgrader := websocket.Upgrader{}
+	n.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		var sub map[string]interface{}
+		err = conn.ReadJSON(&sub)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		n.subscriptions <- sub
+		n.conns <- conn
+	}))
+
+	return n
+}
+
+// nextConn returns the next connection of the provider and the parameters of its subscription
+func (n *testNode) nextConn(t *testing.T) (*websocket.Conn, map[string]interface{}) {
+	select {
+	case sub := <-n.subscriptions:
+		assert.Equal(t, "subscribe", sub["event"])
+		params := sub["data"].([]interface{})[0].(map[string]interface{})
+		return <-n.conns, params
+	case <-time.After(5 * time.Second):
+		t.Fatal("the provider did not subscribe")
+		return nil, nil
+	}
+}
+
+func (n *testNode) send(t *testing.T, conn *websocket.Conn, id int) {
+	err := conn.WriteJSON(map[string]interface{}{"id": id, "event": "trade_status"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestProvider(t *testing.T, n *testNode) *ObyteProvider {
+	minReconnectDelay = 10 * time.Millisecond
+	maxReconnectDelay = 50 * time.Millisecond
+
+	app.Config.Obyte = map[string]string{
+		"ws_url": "ws" + strings.TrimPrefix(n.server.URL, "http"),
+	}
+
+	o := &ObyteProvider{mutex: &sync.Mutex{}}
+	err := o.connect(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return o
+}
+
+// nextEventID returns the sequence number of the next event forwarded by the provider
+func nextEventID(t *testing.T, events chan map[string]interface{}) int {
+	select {
+	case ev := <-events:
+		return int(ev["id"].(float64))
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		return 0
+	}
+}
+
+func TestConnectSubscribesWithoutLastEventId(t *testing.T) {
+	n := newTestNode(t)
+	defer n.server.Close()
+
+	o := newTestProvider(t, n)
+	defer o.WSClient.Close()
+
+	_, params := n.nextConn(t)
+	assert.NotContains(t, params, "lastEventId")
+
+	state := o.ConnectionState()
+	assert.True(t, state.Connected)
+	assert.Equal(t, 0, state.Reconnects)
+}
+
+func TestReconnectResumesFromLastEvent(t *testing.T) {
+	n := newTestNode(t)
+	defer n.server.Close()
+
+	o := newTestProvider(t, n)
+	conn, _ := n.nextConn(t)
+
+	events, err := o.ListenToEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n.send(t, conn, 1)
+	n.send(t, conn, 2)
+	assert.Equal(t, 1, nextEventID(t, events))
+	assert.Equal(t, 2, nextEventID(t, events))
+
+	// the node drops the connection, the provider subscribes again from the last event
+	conn.Close()
+	conn, params := n.nextConn(t)
+	assert.Equal(t, float64(2), params["lastEventId"])
+
+	// the node replays from an older event, the events already received are dropped
+	n.send(t, conn, 2)
+	n.send(t, conn, 3)
+	n.send(t, conn, 3)
+	n.send(t, conn, 4)
+	assert.Equal(t, 3, nextEventID(t, events))
+	assert.Equal(t, 4, nextEventID(t, events))
+
+	state := o.ConnectionState()
+	assert.True(t, state.Connected)
+	assert.Equal(t, 1, state.Reconnects)
+	assert.Equal(t, int64(4), state.LastEventId)
+
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected event %v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestAcceptEvent(t *testing.T) {
+	o := &ObyteProvider{mutex: &sync.Mutex{}}
+
+	assert.True(t, o.acceptEvent(map[string]interface{}{"id": float64(1)}))
+	assert.True(t, o.acceptEvent(map[string]interface{}{"id": float64(3)}))
+	assert.False(t, o.acceptEvent(map[string]interface{}{"id": float64(3)}))
+	assert.False(t, o.acceptEvent(map[string]interface{}{"id": float64(2)}))
+	assert.True(t, o.acceptEvent(map[string]interface{}{"event": "no_id"}))
+	assert.Equal(t, int64(3), o.ConnectionState().LastEventId)
+}
@@ -3,17 +3,24 @@ package obyte
 import (
 	"encoding/json"
 	"log"
+	"strings"
 
 	"github.com/byteball/odex-backend/app"
 	"github.com/byteball/odex-backend/types"
 	"github.com/byteball/odex-backend/utils"
 	"github.com/gorilla/websocket"
 	"github.com/ybbus/jsonrpc"
+
+	sync "github.com/sasha-s/go-deadlock"
 )
 
 type ObyteProvider struct {
 	Client   jsonrpc.RPCClient
 	WSClient *websocket.Conn
+
+	// state of the websocket connection to the node (see connection.go)
+	mutex *sync.Mutex
+	state types.NodeConnectionState
 }
 
 var operatorAddress string
@@ -22,17 +29,17 @@ var affiliateFee float64
 var feesUpdated bool
 
 func NewObyteProvider() *ObyteProvider {
-	client := jsonrpc.NewClient(app.Config.Obyte["http_url"])
-	wsconn, _, err := websocket.DefaultDialer.Dial(app.Config.Obyte["ws_url"], nil)
+	o := &ObyteProvider{
+		Client: jsonrpc.NewClient(app.Config.Obyte["http_url"]),
+		mutex:  &sync.Mutex{},
+	}
 
+	err := o.connect(false)
 	if err != nil {
 		panic(err)
 	}
 
-	return &ObyteProvider{
-		Client:   client,
-		WSClient: wsconn,
-	}
+	return o
 }
 
 func (o *ObyteProvider) BalanceOf(owner string, token string) (int64, error) {
@@ -141,6 +148,22 @@ func (o *ObyteProvider) AddOrder(signedOrder *interface{}) (string, error) {
 	return hash, err
 }
 
+// AmendOrder sends to the node an order replacing an open order, as {orderHash, order}. The node
+// checks the signed order and sends it back in an amend_order event.
+func (o *ObyteProvider) AmendOrder(signedAmend *interface{}) (string, error) {
+	log.Println("will rpc amendOrder", utils.JSON(signedAmend))
+	var hash string // hash of the new order
+	err := utils.Retry(3, func() error {
+		err := o.Client.CallFor(&hash, "amendOrder", signedAmend)
+		if err != nil {
+			log.Println("error from amendOrder: ", err)
+		}
+		return err
+	})
+
+	return hash, err
+}
+
 func (o *ObyteProvider) CancelOrder(signedCancel *interface{}) error {
 	log.Println("will rpc cancelOrder", utils.JSON(signedCancel))
 	var resp string
@@ -155,6 +178,20 @@ func (o *ObyteProvider) CancelOrder(signedCancel *interface{}) error {
 	return err
 }
 
+func (o *ObyteProvider) CancelAllOrders(signedCancel *interface{}) error {
+	log.Println("will rpc cancelAllOrders", utils.JSON(signedCancel))
+	var resp string
+	err := utils.Retry(3, func() error {
+		err := o.Client.CallFor(&resp, "cancelAllOrders", signedCancel)
+		if err != nil {
+			log.Println("error from cancelAllOrders: ", err)
+		}
+		return err
+	})
+
+	return err
+}
+
 func (o *ObyteProvider) GetAuthorizedAddresses(address string) ([]string, error) {
 	var authorizedAddresses []string
 	err := o.Client.CallFor(&authorizedAddresses, "getAuthorizedAddresses", address)
@@ -180,32 +217,75 @@ func (o *ObyteProvider) ExecuteTrade(m *types.Matches) ([]string, error) {
 	var arrTriggerUnits []string
 	err := o.Client.CallFor(&arrTriggerUnits, "executeTrade", m)
 
-	return arrTriggerUnits, err
+	return arrTriggerUnits, tradeExecutionError(err)
+}
+
+// ExecuteTrades settles a batch of matches with a single trigger of the exchange AA. The node
+// returns the trigger unit of every trade of the batch, in the order of the matches.
+func (o *ObyteProvider) ExecuteTrades(batch []*types.Matches) ([]string, error) {
+	var arrTriggerUnits []string
+	err := o.Client.CallFor(&arrTriggerUnits, "executeTrades", batch)
+
+	return arrTriggerUnits, tradeExecutionError(err)
+}
+
+// tradeExecutionError marks the errors of a trade execution order meaning that no trade was
+// sent: the node answered with an error, or it could not be connected to. After any other error
+// (a timeout, a connection dropped while waiting for the response) the trades may have been sent.
+func tradeExecutionError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := err.(*jsonrpc.RPCError); ok {
+		return &types.TradesNotSentError{Err: err}
+	}
+
+	msg := err.Error()
+	if strings.Contains(msg, "connection refused") || strings.Contains(msg, "no such host") {
+		return &types.TradesNotSentError{Err: err}
+	}
+
+	return err
+}
+
+// GetTriggerUnitStatus asks the node whether the exchange AA responded to a trade trigger unit
+func (o *ObyteProvider) GetTriggerUnitStatus(unit string) (*types.TriggerUnitStatus, error) {
+	var status *types.TriggerUnitStatus
+	err := o.Client.CallFor(&status, "getTriggerUnitStatus", unit)
+
+	return status, err
 }
 
+// ListenToEvents returns the channel of the events sent by the node. The websocket is reconnected
+// whenever it drops and the events missed in the meantime are replayed, so that the channel
+// receives every event once and in order.
 func (o *ObyteProvider) ListenToEvents() (chan map[string]interface{}, error) {
 	events := make(chan map[string]interface{})
 
-	done := make(chan struct{})
-
 	go func() {
-		defer close(done)
 		for {
 			_, message, err := o.WSClient.ReadMessage()
 			if err != nil {
-				panic(err)
-				//log.Println("read:", err)
-				//return
+				log.Println("read from wallet:", err)
+				o.reconnect()
+				continue
 			}
 			log.Printf("recv from wallet: %s", message)
 			var ev map[string]interface{}
 			err = json.Unmarshal(message, &ev)
 			if err != nil {
-				panic(err)
+				log.Println("invalid event from wallet:", err)
+				continue
+			}
+
+			if !o.acceptEvent(ev) {
+				continue
 			}
+
 			events <- ev
 		}
 	}()
-	// to do
+
 	return events, nil
 }
@@ -0,0 +1,514 @@
+package obyte
+
+// The simulator is a settlement backend that replaces the Obyte node in integration tests and
+// local demos. It keeps the balances of the users in memory, settles the trades itself and emits
+// the same events as the wallet of the node (new_order, cancel_order, submitted_trades,
+// exchange_response and balances_update) on the channel returned by ListenToEvents.
+//
+// The orders are not signed: AddOrder expects the JSON of the order as it is sent by the node in
+// its new_order event. The matcher and affiliate fees are not charged.
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/byteball/odex-backend/app"
+	"github.com/byteball/odex-backend/types"
+
+	sync "github.com/sasha-s/go-deadlock"
+)
+
+type simulatedToken struct {
+	Symbol   string
+	Decimals uint8
+}
+
+type Simulator struct {
+	mutex *sync.Mutex
+
+	operatorAddress string
+	matcherFee      float64
+	affiliateFee    float64
+	initialBalance  int64
+	responseDelay   time.Duration
+
+	tokens     map[string]*simulatedToken
+	balances   map[string]map[string]int64
+	authorized map[string][]string
+	units      map[string]*types.TriggerUnitStatus
+	unitCount  int64
+
+	// returns the reason for which a trade bounces, or an empty string to settle it
+	bounce func(t *types.Trade) string
+
+	// held while an event is emitted, so that the events are received in the order of their id
+	emitMutex   *sync.Mutex
+	events      chan map[string]interface{}
+	lastEventId int64
+	since       time.Time
+}
+
+// NewSimulator returns a simulator knowing only the base asset (GBYTE). The trades bounce with the
+// configured probability, or when the users do not hold the traded amounts.
+func NewSimulator(operatorAddress string) *Simulator {
+	s := &Simulator{
+		mutex:           &sync.Mutex{},
+		operatorAddress: operatorAddress,
+		initialBalance:  app.Config.SimulatorInitialBalance,
+		responseDelay:   time.Duration(app.Config.SimulatorResponseDelay) * time.Millisecond,
+		tokens:          map[string]*simulatedToken{},
+		balances:        map[string]map[string]int64{},
+		authorized:      map[string][]string{},
+		units:           map[string]*types.TriggerUnitStatus{},
+		emitMutex:       &sync.Mutex{},
+		events:          make(chan map[string]interface{}, 1000),
+		since:           time.Now(),
+	}
+
+	bounceRate := app.Config.SimulatorBounceRate
+	s.bounce = func(t *types.Trade) string {
+		if rand.Float64() < bounceRate {
+			return "simulated bounce"
+		}
+
+		return ""
+	}
+
+	s.RegisterToken("base", "GBYTE", 9)
+
+	return s
+}
+
+// RegisterToken makes an asset known to the simulator
+func (s *Simulator) RegisterToken(asset string, symbol string, decimals uint8) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.tokens[asset] = &simulatedToken{Symbol: symbol, Decimals: decimals}
+}
+
+// SetFees sets the fees returned by GetFees
+func (s *Simulator) SetFees(matcherFee float64, affiliateFee float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.matcherFee = matcherFee
+	s.affiliateFee = affiliateFee
+}
+
+// SetBounce replaces the configured bounce rate by a function returning the reason for which a
+// trade bounces, or an empty string to settle it. The function is called with the simulator locked.
+func (s *Simulator) SetBounce(bounce func(t *types.Trade) string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.bounce = bounce
+}
+
+// Authorize allows a signer to sign orders on behalf of an owner
+func (s *Simulator) Authorize(owner string, signer string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.authorized[owner] = append(s.authorized[owner], signer)
+}
+
+// Deposit credits an address and emits the balances_update event of the deposit
+func (s *Simulator) Deposit(address string, asset string, amount int64) {
+	s.mutex.Lock()
+	s.account(address)[asset] += amount
+	s.mutex.Unlock()
+
+	s.emitBalances(address, "deposit")
+}
+
+func (s *Simulator) BalanceOf(owner string, token string) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.account(owner)[token], nil
+}
+
+func (s *Simulator) GetBalances(owner string) map[string]int64 {
+	balancesBySymbol, _ := s.balancesOf(owner)
+	return balancesBySymbol
+}
+
+func (s *Simulator) GetOperatorAddress() string {
+	return s.operatorAddress
+}
+
+func (s *Simulator) GetFees() (float64, float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.matcherFee, s.affiliateFee
+}
+
+func (s *Simulator) Decimals(token string) (uint8, error) {
+	t, err := s.token(token)
+	if err != nil {
+		return 0, err
+	}
+
+	return t.Decimals, nil
+}
+
+func (s *Simulator) Symbol(token string) (string, error) {
+	t, err := s.token(token)
+	if err != nil {
+		return "", err
+	}
+
+	return t.Symbol, nil
+}
+
+func (s *Simulator) Asset(symbol string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for asset, t := range s.tokens {
+		if t.Symbol == symbol {
+			return asset, nil
+		}
+	}
+
+	return "", errors.New("Unknown symbol " + symbol)
+}
+
+// AddOrder emits the new_order event of an order, given as the JSON sent by the node in this event
+func (s *Simulator) AddOrder(signedOrder *interface{}) (string, error) {
+	o := &types.Order{}
+	err := convert(*signedOrder, o)
+	if err != nil {
+		return "", err
+	}
+
+	data := map[string]interface{}{}
+	err = convert(*signedOrder, &data)
+	if err != nil {
+		return "", err
+	}
+
+	if o.Hash == "" {
+		o.Hash = simulatedHash(data)
+		data["hash"] = o.Hash
+	}
+
+	s.emit("new_order", data)
+
+	return o.Hash, nil
+}
+
+// AmendOrder emits the amend_order event of an order replacing an open order: the JSON of the
+// new order with the hash of the amended order
+func (s *Simulator) AmendOrder(signedAmend *interface{}) (string, error) {
+	p := &types.AmendOrderPayload{}
+	err := convert(*signedAmend, p)
+	if err != nil {
+		return "", err
+	}
+
+	err = p.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	data := map[string]interface{}{}
+	err = convert(p.Order, &data)
+	if err != nil {
+		return "", err
+	}
+
+	hash, _ := data["hash"].(string)
+	if hash == "" {
+		hash = simulatedHash(data)
+		data["hash"] = hash
+	}
+
+	data["amendedOrderHash"] = p.OrderHash
+	s.emit("amend_order", data)
+
+	return hash, nil
+}
+
+// CancelOrder emits the cancel_order event of an order cancel
+func (s *Simulator) CancelOrder(signedCancel *interface{}) error {
+	oc := &types.OrderCancel{}
+	err := convert(*signedCancel, oc)
+	if err != nil {
+		return err
+	}
+
+	s.emit("cancel_order", *signedCancel)
+
+	return nil
+}
+
+// CancelAllOrders emits the cancel_all_orders event of a cancel of all the orders of an address
+func (s *Simulator) CancelAllOrders(signedCancel *interface{}) error {
+	oca := &types.OrderCancelAll{}
+	err := convert(*signedCancel, oca)
+	if err != nil {
+		return err
+	}
+
+	err = oca.Validate()
+	if err != nil {
+		return err
+	}
+
+	s.emit("cancel_all_orders", *signedCancel)
+
+	return nil
+}
+
+func (s *Simulator) GetAuthorizedAddresses(address string) ([]string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]string{}, s.authorized[address]...), nil
+}
+
+// ExecuteTrade sends the trades of the matches with one trigger unit per trade. The trades are
+// settled after the configured delay.
+func (s *Simulator) ExecuteTrade(m *types.Matches) ([]string, error) {
+	return s.ExecuteTrades([]*types.Matches{m})
+}
+
+func (s *Simulator) ExecuteTrades(batch []*types.Matches) ([]string, error) {
+	s.mutex.Lock()
+	units := []string{}
+	for _, m := range batch {
+		for range m.Trades {
+			s.unitCount++
+			unit := simulatedHash(fmt.Sprintf("unit-%d", s.unitCount))
+			s.units[unit] = &types.TriggerUnitStatus{Unit: unit, Status: "PENDING"}
+			units = append(units, unit)
+		}
+	}
+	s.mutex.Unlock()
+
+	// copied since the operator updates the trades once they are sent
+	settled := [][]types.Trade{}
+	for _, m := range batch {
+		trades := []types.Trade{}
+		for _, t := range m.Trades {
+			trades = append(trades, *t)
+		}
+
+		settled = append(settled, trades)
+	}
+
+	go func() {
+		time.Sleep(s.responseDelay)
+
+		remaining := units
+		for _, trades := range settled {
+			s.settle(trades, remaining[:len(trades)])
+			remaining = remaining[len(trades):]
+		}
+	}()
+
+	return units, nil
+}
+
+// settle emits the submitted_trades event of the trades of some matches, then responds to their
+// trigger units
+func (s *Simulator) settle(trades []types.Trade, units []string) {
+	hashes := []string{}
+	for _, t := range trades {
+		hashes = append(hashes, t.Hash)
+	}
+
+	s.emit("submitted_trades", map[string]interface{}{"trade_hashes": hashes})
+
+	for i := range trades {
+		t := &trades[i]
+
+		s.mutex.Lock()
+		reason := s.bounce(t)
+		if reason == "" {
+			reason = s.transfer(t)
+		}
+
+		status := s.units[units[i]]
+		status.Status = "DONE"
+		status.Bounced = reason != ""
+		status.Error = reason
+		s.mutex.Unlock()
+
+		response := map[string]interface{}{}
+		if reason != "" {
+			response["error"] = reason
+		}
+
+		s.emit("exchange_response", map[string]interface{}{
+			"trigger_unit": units[i],
+			"bounced":      reason != "",
+			"response":     response,
+		})
+
+		if reason == "" {
+			s.emitBalances(t.Maker, "trade")
+			s.emitBalances(t.Taker, "trade")
+		}
+	}
+}
+
+// transfer exchanges the amounts of a trade between the maker and the taker. It returns the reason
+// for which the trade bounces if one of them does not hold the amount they sell.
+func (s *Simulator) transfer(t *types.Trade) string {
+	seller, buyer := t.Maker, t.Taker
+	if t.MakerSide == "BUY" {
+		seller, buyer = t.Taker, t.Maker
+	}
+
+	if s.account(seller)[t.BaseToken] < t.Amount || s.account(buyer)[t.QuoteToken] < t.QuoteAmount {
+		return "not enough balance"
+	}
+
+	s.account(seller)[t.BaseToken] -= t.Amount
+	s.account(seller)[t.QuoteToken] += t.QuoteAmount
+	s.account(buyer)[t.QuoteToken] -= t.QuoteAmount
+	s.account(buyer)[t.BaseToken] += t.Amount
+
+	return ""
+}
+
+func (s *Simulator) GetTriggerUnitStatus(unit string) (*types.TriggerUnitStatus, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	status := s.units[unit]
+	if status == nil {
+		return &types.TriggerUnitStatus{Unit: unit, Status: "UNKNOWN"}, nil
+	}
+
+	copied := *status
+	return &copied, nil
+}
+
+// ListenToEvents returns the channel of the events emitted by the simulator. There is a single
+// channel shared by all the listeners.
+func (s *Simulator) ListenToEvents() (chan map[string]interface{}, error) {
+	return s.events, nil
+}
+
+// ConnectionState returns a connection that never dropped
+func (s *Simulator) ConnectionState() types.NodeConnectionState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return types.NodeConnectionState{
+		Connected:   true,
+		Since:       s.since,
+		LastEventId: s.lastEventId,
+	}
+}
+
+// account returns the balances of an address by asset, crediting the initial balance of every
+// token to a new address. The mutex must be held.
+func (s *Simulator) account(address string) map[string]int64 {
+	balances := s.balances[address]
+	if balances == nil {
+		balances = map[string]int64{}
+		if s.initialBalance > 0 {
+			for asset := range s.tokens {
+				balances[asset] = s.initialBalance
+			}
+		}
+
+		s.balances[address] = balances
+	}
+
+	return balances
+}
+
+func (s *Simulator) balancesOf(address string) (map[string]int64, map[string]int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	balancesBySymbol := map[string]int64{}
+	balancesByAsset := map[string]int64{}
+	for asset, amount := range s.account(address) {
+		balancesByAsset[asset] = amount
+		if t := s.tokens[asset]; t != nil {
+			balancesBySymbol[t.Symbol] = amount
+		}
+	}
+
+	return balancesBySymbol, balancesByAsset
+}
+
+func (s *Simulator) token(asset string) (*simulatedToken, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	t := s.tokens[asset]
+	if t == nil {
+		return nil, errors.New("Unknown asset " + asset)
+	}
+
+	return t, nil
+}
+
+func (s *Simulator) emitBalances(address string, event string) {
+	balancesBySymbol, balancesByAsset := s.balancesOf(address)
+
+	s.emit("balances_update", map[string]interface{}{
+		"address":            address,
+		"event":              event,
+		"balances_by_symbol": balancesBySymbol,
+		"balances_by_asset":  balancesByAsset,
+	})
+}
+
+// emit sends an event shaped like the events of the wallet, after a JSON round trip so that the
+// listeners decode the same values as with a real node
+func (s *Simulator) emit(event string, data interface{}) {
+	s.emitMutex.Lock()
+	defer s.emitMutex.Unlock()
+
+	s.mutex.Lock()
+	s.lastEventId++
+	ev := map[string]interface{}{
+		"id":    s.lastEventId,
+		"event": event,
+		"data":  []interface{}{data},
+	}
+	s.mutex.Unlock()
+
+	raw := map[string]interface{}{}
+	err := convert(ev, &raw)
+	if err != nil {
+		log.Println("invalid simulated event:", err)
+		return
+	}
+
+	s.events <- raw
+}
+
+// convert copies a value into another type through its JSON encoding
+func convert(from interface{}, to interface{}) error {
+	b, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, to)
+}
+
+// simulatedHash returns a base64 sha256 hash, like the hashes of the units of the Obyte DAG
+func simulatedHash(v interface{}) string {
+	b, _ := json.Marshal(v)
+	h := sha256.Sum256(b)
+
+	return base64.StdEncoding.EncodeToString(h[:])
+}
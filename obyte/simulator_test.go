@@ -0,0 +1,150 @@
+package obyte
+
+import (
+	"testing"
+	"time"
+
+	"github.com/byteball/odex-backend/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestSimulator() *Simulator {
+	s := NewSimulator("OPERATOR")
+	s.RegisterToken("quote", "QUOTE", 6)
+	return s
+}
+
+func newTestTradeMatches(hash string, amount int64, quoteAmount int64) *types.Matches {
+	return &types.Matches{
+		TakerOrder:  &types.Order{Hash: "taker-" + hash},
+		MakerOrders: []*types.Order{{Hash: "maker-" + hash}},
+		Trades: []*types.Trade{{
+			Hash:           hash,
+			Maker:          "MAKER",
+			Taker:          "TAKER",
+			MakerSide:      "SELL",
+			MakerOrderHash: "maker-" + hash,
+			TakerOrderHash: "taker-" + hash,
+			BaseToken:      "base",
+			QuoteToken:     "quote",
+			Amount:         amount,
+			QuoteAmount:    quoteAmount,
+		}},
+	}
+}
+
+// nextEvent returns the name and the data of the next event emitted by the simulator
+func nextEvent(t *testing.T, events chan map[string]interface{}) (string, map[string]interface{}) {
+	select {
+	case ev := <-events:
+		data := ev["data"].([]interface{})
+		return ev["event"].(string), data[0].(map[string]interface{})
+	case <-time.After(time.Second):
+		t.Fatal("no event emitted")
+		return "", nil
+	}
+}
+
+func assertNoEvent(t *testing.T, events chan map[string]interface{}) {
+	select {
+	case ev := <-events:
+		assert.Fail(t, "unexpected event", "%v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func depositTestBalances(t *testing.T, s *Simulator, events chan map[string]interface{}) {
+	s.Deposit("MAKER", "base", 1000)
+	s.Deposit("TAKER", "quote", 5000)
+
+	for _, address := range []string{"MAKER", "TAKER"} {
+		event, data := nextEvent(t, events)
+		assert.Equal(t, "balances_update", event)
+		assert.Equal(t, address, data["address"])
+		assert.Equal(t, "deposit", data["event"])
+	}
+}
+
+func TestSimulatorExecuteTrades(t *testing.T) {
+	s := newTestSimulator()
+	events, _ := s.ListenToEvents()
+	depositTestBalances(t, s, events)
+
+	units, err := s.ExecuteTrades([]*types.Matches{newTestTradeMatches("trade1", 100, 200)})
+	assert.Nil(t, err)
+	if !assert.Len(t, units, 1) {
+		return
+	}
+
+	event, data := nextEvent(t, events)
+	assert.Equal(t, "submitted_trades", event)
+	assert.Equal(t, []interface{}{"trade1"}, data["trade_hashes"])
+
+	event, data = nextEvent(t, events)
+	assert.Equal(t, "exchange_response", event)
+	assert.Equal(t, units[0], data["trigger_unit"])
+	assert.Equal(t, false, data["bounced"])
+
+	for _, address := range []string{"MAKER", "TAKER"} {
+		event, data = nextEvent(t, events)
+		assert.Equal(t, "balances_update", event)
+		assert.Equal(t, address, data["address"])
+		assert.Equal(t, "trade", data["event"])
+	}
+
+	assert.Equal(t, map[string]int64{"GBYTE": 900, "QUOTE": 200}, s.GetBalances("MAKER"))
+	assert.Equal(t, map[string]int64{"GBYTE": 100, "QUOTE": 4800}, s.GetBalances("TAKER"))
+
+	status, err := s.GetTriggerUnitStatus(units[0])
+	assert.Nil(t, err)
+	assert.Equal(t, "DONE", status.Status)
+	assert.False(t, status.Bounced)
+}
+
+func TestSimulatorBounce(t *testing.T) {
+	s := newTestSimulator()
+	events, _ := s.ListenToEvents()
+	depositTestBalances(t, s, events)
+
+	s.SetBounce(func(tr *types.Trade) string {
+		if tr.Hash == "trade1" {
+			return "simulated bounce"
+		}
+
+		return ""
+	})
+
+	// the first trade bounces on purpose, the second one because the maker lacks the amount
+	units, err := s.ExecuteTrades([]*types.Matches{
+		newTestTradeMatches("trade1", 100, 200),
+		newTestTradeMatches("trade2", 2000, 200),
+	})
+	assert.Nil(t, err)
+	if !assert.Len(t, units, 2) {
+		return
+	}
+
+	reasons := []string{"simulated bounce", "not enough balance"}
+	for i, hash := range []string{"trade1", "trade2"} {
+		event, data := nextEvent(t, events)
+		assert.Equal(t, "submitted_trades", event)
+		assert.Equal(t, []interface{}{hash}, data["trade_hashes"])
+
+		event, data = nextEvent(t, events)
+		assert.Equal(t, "exchange_response", event)
+		assert.Equal(t, units[i], data["trigger_unit"])
+		assert.Equal(t, true, data["bounced"])
+		assert.Equal(t, map[string]interface{}{"error": reasons[i]}, data["response"])
+
+		status, err := s.GetTriggerUnitStatus(units[i])
+		assert.Nil(t, err)
+		assert.Equal(t, "DONE", status.Status)
+		assert.True(t, status.Bounced)
+		assert.Equal(t, reasons[i], status.Error)
+	}
+
+	// the balances are untouched
+	assertNoEvent(t, events)
+	assert.Equal(t, map[string]int64{"GBYTE": 1000}, s.GetBalances("MAKER"))
+	assert.Equal(t, map[string]int64{"QUOTE": 5000}, s.GetBalances("TAKER"))
+}
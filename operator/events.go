@@ -0,0 +1,364 @@
+package operator
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils"
+	"github.com/byteball/odex-backend/ws"
+)
+
+// EventHandler handles the JSON payload of a wallet event
+type EventHandler func(data []byte) error
+
+// EventRegistry dispatches the wallet events to the handler registered for their type
+type EventRegistry struct {
+	handlers map[string]EventHandler
+}
+
+func NewEventRegistry() *EventRegistry {
+	return &EventRegistry{handlers: map[string]EventHandler{}}
+}
+
+// Register sets the handler of a type of wallet event
+func (r *EventRegistry) Register(event string, h EventHandler) {
+	r.handlers[event] = h
+}
+
+// Dispatch decodes a raw wallet event and passes its payload to the handler of its type.
+// The events without handler are ignored.
+func (r *EventRegistry) Dispatch(raw map[string]interface{}) error {
+	ev, err := types.NewWalletEvent(raw)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	h := r.handlers[ev.Event]
+	if h == nil {
+		logger.Info("Unhandled wallet event", ev.Event)
+		return nil
+	}
+
+	return h(ev.Data)
+}
+
+// decodeEvent unmarshals the payload of a wallet event and checks its required fields
+func decodeEvent(data []byte, ev interface{ Validate() error }) error {
+	err := json.Unmarshal(data, ev)
+	if err != nil {
+		return err
+	}
+
+	return ev.Validate()
+}
+
+// registerEventHandlers sets the handlers of the wallet events processed by the operator
+func (op *Operator) registerEventHandlers() {
+	op.events.Register("loggedin", func(data []byte) error {
+		ev := &types.LoggedInEvent{}
+		err := decodeEvent(data, ev)
+		if err != nil {
+			return err
+		}
+
+		return op.handleLoggedIn(ev)
+	})
+
+	op.events.Register("new_order", func(data []byte) error {
+		o := &types.Order{}
+		err := json.Unmarshal(data, o)
+		if err != nil {
+			return err
+		}
+
+		return op.handleNewOrder(o)
+	})
+
+	op.events.Register("amend_order", func(data []byte) error {
+		o := &types.Order{}
+		err := json.Unmarshal(data, o)
+		if err != nil {
+			return err
+		}
+
+		if o.AmendedOrderHash == "" {
+			return errors.New("amendedOrderHash is missing")
+		}
+
+		return op.handleAmendOrder(o)
+	})
+
+	op.events.Register("cancel_order", func(data []byte) error {
+		oc := &types.OrderCancel{}
+		err := json.Unmarshal(data, oc)
+		if err != nil {
+			return err
+		}
+
+		return op.handleCancelOrder(oc)
+	})
+
+	op.events.Register("cancel_all_orders", func(data []byte) error {
+		oca := &types.OrderCancelAll{}
+		err := decodeEvent(data, oca)
+		if err != nil {
+			return err
+		}
+
+		return op.handleCancelAllOrders(oca)
+	})
+
+	op.events.Register("revoke", func(data []byte) error {
+		ev := &types.RevokeEvent{}
+		err := decodeEvent(data, ev)
+		if err != nil {
+			return err
+		}
+
+		return op.handleRevoke(ev)
+	})
+
+	op.events.Register("balances_update", func(data []byte) error {
+		ev := &types.BalancesUpdateEvent{}
+		err := decodeEvent(data, ev)
+		if err != nil {
+			return err
+		}
+
+		return op.handleBalancesUpdate(ev)
+	})
+
+	op.events.Register("exchange_response", func(data []byte) error {
+		ev := &types.ExchangeResponseEvent{}
+		err := decodeEvent(data, ev)
+		if err != nil {
+			return err
+		}
+
+		return op.handleExchangeResponseEvent(ev)
+	})
+
+	op.events.Register("submitted_trades", func(data []byte) error {
+		ev := &types.SubmittedTradesEvent{}
+		err := decodeEvent(data, ev)
+		if err != nil {
+			return err
+		}
+
+		return op.handleSubmittedTrades(ev)
+	})
+}
+
+func (op *Operator) handleLoggedIn(ev *types.LoggedInEvent) error {
+	logger.Info("Logged in", ev.SessionId, ev.Address)
+	go ws.GetLoginSocket().SendMessageBySession(ev.SessionId, ev.Address)
+	ws.GetLoginSocket().LinkAddressToClient(ev.SessionId, ev.Address)
+
+	return nil
+}
+
+func (op *Operator) handleNewOrder(o *types.Order) error {
+	logger.Info("new order event from wallet", utils.JSON(o))
+
+	acc, err := op.AccountService.FindOrCreate(o.UserAddress)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if acc.IsBlocked {
+		go ws.SendOrderMessage("ERROR", o.UserAddress, "Account is blocked")
+		return errors.New("Account is blocked")
+	}
+
+	err = op.OrderService.NewOrder(o)
+	if err != nil {
+		logger.Error(err)
+		go ws.SendOrderMessage("ERROR", o.UserAddress, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (op *Operator) handleAmendOrder(o *types.Order) error {
+	logger.Info("amend order event from wallet", utils.JSON(o))
+
+	acc, err := op.AccountService.FindOrCreate(o.UserAddress)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if acc.IsBlocked {
+		go ws.SendOrderMessage("ERROR", o.UserAddress, "Account is blocked")
+		return errors.New("Account is blocked")
+	}
+
+	err = op.OrderService.AmendOrder(o)
+	if err != nil {
+		logger.Error(err)
+		go ws.SendOrderMessage("ERROR", o.UserAddress, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (op *Operator) handleCancelOrder(oc *types.OrderCancel) error {
+	logger.Info("cancel order event from wallet", utils.JSON(oc))
+
+	ownerAddress, signerAddress, err := op.OrderService.GetSenderAddresses(oc)
+	if err != nil {
+		logger.Error(err)
+		go ws.SendOrderMessage("ERROR", oc.UserAddress, err.Error())
+		return err
+	}
+
+	if ownerAddress != oc.UserAddress && signerAddress != oc.UserAddress {
+		authorizedAddresses, err := op.ObyteProvider.GetAuthorizedAddresses(ownerAddress)
+		if err != nil {
+			logger.Error(err)
+			go ws.SendOrderMessage("ERROR", oc.UserAddress, err.Error())
+			return err
+		}
+
+		if !utils.Contains(authorizedAddresses, oc.UserAddress) {
+			go ws.SendOrderMessage("ERROR", oc.UserAddress, "Not your order")
+			return errors.New("Not your order")
+		}
+	}
+
+	err = op.OrderService.CancelOrder(oc)
+	if err != nil {
+		logger.Error(err)
+		go ws.SendOrderMessage("ERROR", ownerAddress, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (op *Operator) handleCancelAllOrders(oca *types.OrderCancelAll) error {
+	logger.Info("cancel all orders event from wallet", utils.JSON(oca))
+
+	if oca.Address != oca.UserAddress {
+		authorizedAddresses, err := op.ObyteProvider.GetAuthorizedAddresses(oca.Address)
+		if err != nil {
+			logger.Error(err)
+			go ws.SendOrderMessage("ERROR", oca.UserAddress, err.Error())
+			return err
+		}
+
+		if !utils.Contains(authorizedAddresses, oca.UserAddress) {
+			go ws.SendOrderMessage("ERROR", oca.UserAddress, "Not your orders")
+			return errors.New("Not your orders")
+		}
+	}
+
+	summary, err := op.OrderService.CancelAllOrders(oca)
+	if err != nil {
+		logger.Error(err)
+		go ws.SendOrderMessage("ERROR", oca.Address, err.Error())
+		return err
+	}
+
+	logger.Info("cancelled", len(summary.Cancelled), "orders of", oca.Address)
+
+	return nil
+}
+
+func (op *Operator) handleRevoke(ev *types.RevokeEvent) error {
+	logger.Info("revoke authorization on owner", ev.UserAddress, "from signer", ev.SignerAddress)
+	op.OrderService.CancelOrdersSignedByRevokedSigner(ev.UserAddress, ev.SignerAddress)
+
+	return nil
+}
+
+func (op *Operator) handleBalancesUpdate(ev *types.BalancesUpdateEvent) error {
+	balances := op.OrderService.AdjustBalancesForUncommittedTrades(ev.Address, ev.BalancesBySymbol)
+	op.OrderService.CheckIfBalancesAreSufficientAndCancel(ev.Address, ev.BalancesByAsset)
+	go ws.SendBalancesMessage("UPDATE", ev.Address, balances, ev.Event)
+
+	return nil
+}
+
+func (op *Operator) handleExchangeResponseEvent(ev *types.ExchangeResponseEvent) error {
+	trades, err := op.TradeService.GetByTriggerUnitHash(ev.TriggerUnit)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if len(trades) == 0 {
+		// a trade by another matcher is checked by the watchdog
+		if ev.Trade != nil && !ev.Bounced {
+			_, err := op.WatchdogService.CheckTrade(ev.Trade)
+			if err != nil {
+				logger.Error(err)
+			}
+
+			return nil
+		}
+
+		logger.Error("trade not found by trigger unit") // could be a trade by another matcher or bounced withdrawal
+		return nil
+	}
+
+	return op.handleExchangeResponse(trades, ev.Bounced, ev.Error)
+}
+
+func (op *Operator) handleSubmittedTrades(ev *types.SubmittedTradesEvent) error {
+	trades, err := op.TradeService.GetByHashes(ev.TradeHashes)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if len(trades) == 0 {
+		logger.Error("trades not found by trade hashes") // could be a trade by another matcher
+		return nil
+	}
+
+	takerOrder, err := op.OrderService.GetByHash(trades[0].TakerOrderHash)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	matches := &types.Matches{
+		MakerOrders: []*types.Order{},
+		TakerOrder:  takerOrder,
+		Trades:      trades,
+	}
+
+	for _, trade := range trades {
+		if trade.TakerOrderHash != trades[0].TakerOrderHash {
+			return errors.New("different takers")
+		}
+
+		makerOrder, err := op.OrderService.GetByHash(trade.MakerOrderHash)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		matches.MakerOrders = append(matches.MakerOrders, makerOrder)
+	}
+
+	txq, _, err := op.GetShortestQueue()
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	err = txq.HandleTxSuccess(matches)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils/testutils/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newEventsTestOperator() (*Operator, *mocks.AccountService, *mocks.OrderService) {
+	accountService := new(mocks.AccountService)
+	orderService := new(mocks.OrderService)
+
+	op := &Operator{
+		AccountService: accountService,
+		OrderService:   orderService,
+		events:         NewEventRegistry(),
+	}
+
+	op.registerEventHandlers()
+	return op, accountService, orderService
+}
+
+func walletEvent(event string, data interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"event": event,
+		"data":  []interface{}{data},
+	}
+}
+
+func TestDispatchNewOrder(t *testing.T) {
+	op, accountService, orderService := newEventsTestOperator()
+
+	accountService.On("FindOrCreate", "ADDRESS").Return(&types.Account{Address: "ADDRESS"}, nil)
+	orderService.On("NewOrder", mock.MatchedBy(func(o *types.Order) bool {
+		return o.UserAddress == "ADDRESS" && o.Price.Cmp(types.NewDecimal(1.5)) == 0
+	})).Return(nil)
+
+	err := op.events.Dispatch(walletEvent("new_order", map[string]interface{}{
+		"userAddress": "ADDRESS",
+		"price":       1.5,
+	}))
+
+	assert.Nil(t, err)
+	orderService.AssertExpectations(t)
+}
+
+func TestDispatchInvalidEvents(t *testing.T) {
+	op, accountService, orderService := newEventsTestOperator()
+
+	invalid := []map[string]interface{}{
+		walletEvent("new_order", map[string]interface{}{"price": "1"}),
+		walletEvent("new_order", map[string]interface{}{"userAddress": 1}),
+		walletEvent("new_order", "order"),
+		walletEvent("amend_order", map[string]interface{}{"price": "1", "amendedOrderHash": "hash"}),
+		walletEvent("amend_order", map[string]interface{}{"userAddress": "ADDRESS"}),
+		walletEvent("cancel_order", map[string]interface{}{"orderHash": 1, "userAddress": "ADDRESS"}),
+		walletEvent("cancel_order", map[string]interface{}{"orderHash": "hash"}),
+		walletEvent("cancel_all_orders", map[string]interface{}{"userAddress": "ADDRESS", "side": "BOTH"}),
+		walletEvent("revoke", map[string]interface{}{"userAddress": "ADDRESS"}),
+		{"event": "new_order"},
+		{"data": []interface{}{map[string]interface{}{}}},
+	}
+
+	for _, ev := range invalid {
+		assert.NotPanics(t, func() {
+			assert.Error(t, op.events.Dispatch(ev), "%v", ev)
+		})
+	}
+
+	accountService.AssertNotCalled(t, "FindOrCreate", mock.Anything)
+	orderService.AssertNotCalled(t, "NewOrder", mock.Anything)
+	orderService.AssertNotCalled(t, "AmendOrder", mock.Anything)
+	orderService.AssertNotCalled(t, "GetSenderAddresses", mock.Anything)
+}
+
+func TestDispatchUnhandledEvent(t *testing.T) {
+	op, _, _ := newEventsTestOperator()
+
+	assert.Nil(t, op.events.Dispatch(walletEvent("unknown", map[string]interface{}{})))
+}
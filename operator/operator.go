@@ -1,12 +1,12 @@
 package operator
 
 import (
-	"encoding/json"
 	"errors"
-	sync "github.com/sasha-s/go-deadlock"
+	"fmt"
 
-	"github.com/spf13/cast"
+	sync "github.com/sasha-s/go-deadlock"
 
+	"github.com/byteball/odex-backend/app"
 	"github.com/byteball/odex-backend/interfaces"
 	"github.com/byteball/odex-backend/rabbitmq"
 	"github.com/byteball/odex-backend/types"
@@ -16,17 +16,31 @@ import (
 
 var logger = utils.Logger
 
-// Operator manages the transaction queue that will eventually be
+// Operator manages the transaction queues that will eventually be
 // sent to the exchange AA. The Operator Wallet must be equal to the matcher of submitted orders
 type Operator struct {
 	AccountService    interfaces.AccountService
+	AffiliateService  interfaces.AffiliateService
+	WatchdogService   interfaces.WatchdogService
 	TradeService      interfaces.TradeService
 	OrderService      interfaces.OrderService
 	ObyteProvider     interfaces.ObyteProvider
 	TxQueues          []*TxQueue
 	QueueAddressIndex map[string]*TxQueue
 	Broker            *rabbitmq.Connection
-	mutex             *sync.Mutex
+
+	// publisher of the rejected trades, the broker except in the tests
+	txErrors interfaces.TxErrorPublisher
+
+	// routing of the trades to the queues (see routing.go)
+	routing       string
+	pendingTrades map[string]int
+	pendingMutex  *sync.Mutex
+	held          []*types.Matches
+	queued        map[string]bool
+
+	// handlers of the wallet events (see events.go)
+	events *EventRegistry
 }
 
 type OperatorInterface interface {
@@ -43,46 +57,63 @@ func NewOperator(
 	tradeService interfaces.TradeService,
 	orderService interfaces.OrderService,
 	accountService interfaces.AccountService,
+	affiliateService interfaces.AffiliateService,
+	watchdogService interfaces.WatchdogService,
 	provider interfaces.ObyteProvider,
 	conn *rabbitmq.Connection,
 ) (*Operator, error) {
-	txqueues := []*TxQueue{}
-	addressIndex := make(map[string]*TxQueue)
-
-	name := "oper"
-	ch := conn.GetChannel("TX_QUEUES:" + name)
-
-	err := conn.DeclareThrottledQueue(ch, "TX_QUEUES:"+name)
-	if err != nil {
-		panic(err)
-	}
-
-	txq, err := NewTxQueue(
-		name,
-		tradeService,
-		provider,
-		orderService,
-		conn,
-	)
-
-	if err != nil {
-		panic(err)
-	}
-
-	txqueues = append(txqueues, txq)
-
 	op := &Operator{
 		TradeService:      tradeService,
 		OrderService:      orderService,
 		AccountService:    accountService,
+		AffiliateService:  affiliateService,
+		WatchdogService:   watchdogService,
 		ObyteProvider:     provider,
-		TxQueues:          txqueues,
-		QueueAddressIndex: addressIndex,
-		mutex:             &sync.Mutex{},
+		TxQueues:          []*TxQueue{},
+		QueueAddressIndex: make(map[string]*TxQueue),
 		Broker:            conn,
+		txErrors:          conn,
+		routing:           app.Config.TxQueueRouting,
+		pendingTrades:     make(map[string]int),
+		pendingMutex:      &sync.Mutex{},
+		queued:            make(map[string]bool),
+		events:            NewEventRegistry(),
+	}
+
+	op.registerEventHandlers()
+
+	for i := 0; i < app.Config.TxQueues; i++ {
+		// the first queue keeps the name of the single queue of the previous versions
+		name := "oper"
+		if i > 0 {
+			name = fmt.Sprintf("oper%d", i)
+		}
+
+		ch := conn.GetChannel("TX_QUEUES:" + name)
+
+		err := conn.DeclareThrottledQueue(ch, "TX_QUEUES:"+name)
+		if err != nil {
+			panic(err)
+		}
+
+		txq, err := NewTxQueue(
+			name,
+			tradeService,
+			provider,
+			orderService,
+			conn,
+			op.releaseTrades,
+		)
+
+		if err != nil {
+			panic(err)
+		}
+
+		op.TxQueues = append(op.TxQueues, txq)
 	}
 
 	go op.HandleEvents()
+	go op.reconcileTrades()
 	return op, nil
 }
 
@@ -94,7 +125,7 @@ func (op *Operator) HandleError(m *types.Matches) {
 }
 
 func (op *Operator) HandleTxError(m *types.Matches, errType string) {
-	err := op.Broker.PublishTxErrorMessage(m, errType)
+	err := op.txErrors.PublishTxErrorMessage(m, errType)
 	if err != nil {
 		logger.Error(err)
 	}
@@ -119,239 +150,110 @@ func (op *Operator) HandleEvents() error {
 		return err
 	}
 
-	for {
-		select {
-		case event := <-events:
-			logger.Info("Receiving event from wallet", utils.JSON(event))
-			arrData := event["data"].([]interface{})
-			data := arrData[0].(map[string]interface{})
-
-			switch event["event"] {
-
-			case "loggedin":
-				logger.Info("Loggedin event", utils.JSON(data))
-				sessionId := data["sessionId"].(string)
-				address := data["address"].(string)
-				logger.Info("Logged in", sessionId, address)
-				go ws.GetLoginSocket().SendMessageBySession(sessionId, address)
-				ws.GetLoginSocket().LinkAddressToClient(sessionId, address)
-
-			case "new_order":
-				logger.Info("new order event from wallet", utils.JSON(data))
-				o := &types.Order{}
-
-				bytes, err := json.Marshal(arrData[0])
-				if err != nil {
-					logger.Error(err)
-					break
-				}
-				logger.Info("order bytes", string(bytes))
-
-				err = json.Unmarshal(bytes, &o)
-				if err != nil {
-					logger.Error(err)
-					break
-				}
-				logger.Info("order struct", o)
-				logger.Info("order fields", o.UserAddress, o.MatcherAddress, o.BaseToken)
-
-				acc, err := op.AccountService.FindOrCreate(o.UserAddress)
-				if err != nil {
-					logger.Error(err)
-					break
-				}
-				logger.Info("acc", acc)
-
-				if acc.IsBlocked {
-					go ws.SendOrderMessage("ERROR", o.UserAddress, "Account is blocked")
-					break
-				}
-
-				err = op.OrderService.NewOrder(o)
-				if err != nil {
-					logger.Error(err)
-					go ws.SendOrderMessage("ERROR", o.UserAddress, err.Error())
-					break
-				}
-
-			case "cancel_order":
-				logger.Info("cancel order event from wallet", utils.JSON(data))
-				oc := &types.OrderCancel{}
-
-				bytes, err := json.Marshal(arrData[0])
-				if err != nil {
-					logger.Error(err)
-					break
-				}
-				logger.Info("cancel order bytes", string(bytes))
-
-				err = json.Unmarshal(bytes, &oc)
-				if err != nil {
-					logger.Error(err)
-					break
-				}
-
-				ownerAddress, signerAddress, err := op.OrderService.GetSenderAddresses(oc)
-				if err != nil {
-					logger.Error(err)
-					go ws.SendOrderMessage("ERROR", oc.UserAddress, err.Error())
-					break
-				}
-
-				if ownerAddress != oc.UserAddress && signerAddress != oc.UserAddress {
-					authorizedAddresses, err := op.ObyteProvider.GetAuthorizedAddresses(ownerAddress)
-					if err != nil {
-						logger.Error(err)
-						go ws.SendOrderMessage("ERROR", oc.UserAddress, err.Error())
-						break
-					}
-					if !utils.Contains(authorizedAddresses, oc.UserAddress) {
-						logger.Error("Not your order")
-						go ws.SendOrderMessage("ERROR", oc.UserAddress, "Not your order")
-						break
-					}
-				}
-
-				err = op.OrderService.CancelOrder(oc)
-				if err != nil {
-					logger.Error(err)
-					go ws.SendOrderMessage("ERROR", ownerAddress, err.Error())
-					break
-				}
-
-			case "revoke":
-				logger.Info("revoke event", utils.JSON(data))
-				userAddress := data["userAddress"].(string)
-				signerAddress := data["signerAddress"].(string)
-				logger.Info("revoke authorization on owner", userAddress, "from signer", signerAddress)
-				op.OrderService.CancelOrdersSignedByRevokedSigner(userAddress, signerAddress)
-
-			case "balances_update":
-				//logger.Info("balances update event", utils.JSON(data))
-				address := data["address"].(string)
-				ev := data["event"].(string)
-				balances_by_symbol := cast.ToStringMapInt64(data["balances_by_symbol"])
-				balances_by_asset := cast.ToStringMapInt64(data["balances_by_asset"])
-				//logger.Info(address, balances_by_symbol)
-				balances_by_symbol = op.OrderService.AdjustBalancesForUncommittedTrades(address, balances_by_symbol)
-				//logger.Info("adjusted balances", address, balances_by_symbol)
-				op.OrderService.CheckIfBalancesAreSufficientAndCancel(address, balances_by_asset)
-				go ws.SendBalancesMessage("UPDATE", address, balances_by_symbol, ev)
-
-			case "exchange_response":
-				if data["trigger_unit"] == nil {
-					logger.Error("no trigger unit")
-					break
-				}
-				trigger_unit := data["trigger_unit"].(string)
-				bounced := false
-				switch data["bounced"].(type) {
-				case float64:
-					bounced = (int(data["bounced"].(float64)) == 1)
-				case bool:
-					bounced = data["bounced"].(bool)
-				default:
-					panic("unrecognized type of bounced")
-				}
-				response := data["response"].(map[string]interface{})
-
-				trades, err := op.TradeService.GetByTriggerUnitHash(trigger_unit)
-				if err != nil {
-					logger.Error(err)
-					break
-				}
-
-				if len(trades) == 0 {
-					logger.Error("trade not found by trigger unit") // could be a trade by another matcher or bounced withdrawal
-					break
-				}
-				trade := trades[0]
-
-				takerOrder, err := op.OrderService.GetByHash(trade.TakerOrderHash)
-				if err != nil {
-					logger.Error(err)
-					break
-				}
-
-				makerOrder, err := op.OrderService.GetByHash(trade.MakerOrderHash)
-				if err != nil {
-					logger.Error(err)
-					break
-				}
-
-				matches := &types.Matches{
-					MakerOrders: []*types.Order{makerOrder},
-					TakerOrder:  takerOrder,
-					Trades:      trades,
-				}
-
-				if bounced {
-					bounceMessage := response["error"].(string)
-					op.HandleTxError(matches, bounceMessage) // will also update status to REJECTED
-					// the wallet sends balance updates only after successful trades
-					go op.sendBalancesUpdateAfterTrade(makerOrder.UserAddress)
-					go op.sendBalancesUpdateAfterTrade(takerOrder.UserAddress)
-				} else {
-					op.TradeService.UpdateTradeStatus(trade, "COMMITTED")
-				}
-
-			case "submitted_trades":
-				if data["trade_hashes"] == nil {
-					logger.Error("no trade hashes")
-					break
-				}
-				trade_hashes := cast.ToStringSlice(data["trade_hashes"])
-
-				trades, err := op.TradeService.GetByHashes(trade_hashes)
-				if err != nil {
-					logger.Error(err)
-					break
-				}
-
-				if len(trades) == 0 {
-					logger.Error("trades not found by trade hashes") // could be a trade by another matcher or bounced withdrawal
-					break
-				}
-
-				takerOrder, err := op.OrderService.GetByHash(trades[0].TakerOrderHash)
-				if err != nil {
-					logger.Error(err)
-					break
-				}
-
-				matches := &types.Matches{
-					MakerOrders: []*types.Order{},
-					TakerOrder:  takerOrder,
-					Trades:      trades,
-				}
-
-				for _, trade := range trades {
-					if trade.TakerOrderHash != trades[0].TakerOrderHash {
-						err := errors.New("different takers")
-						logger.Error(err)
-						return err
-					}
-					makerOrder, err := op.OrderService.GetByHash(trade.MakerOrderHash)
-					if err != nil {
-						logger.Error(err)
-						break
-					}
-					matches.MakerOrders = append(matches.MakerOrders, makerOrder)
-				}
-
-				txq, _, err := op.GetShortestQueue()
-				if err != nil {
-					logger.Error(err)
-					return err
-				}
-				err = txq.HandleTxSuccess(matches)
-				if err != nil {
-					logger.Error(err)
-					return err
-				}
+	for event := range events {
+		logger.Info("Receiving event from wallet", utils.JSON(event))
+
+		err := op.events.Dispatch(event)
+		if err != nil {
+			logger.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// handleExchangeResponse settles the trades sent with a trigger unit once the exchange AA responded:
+// they are committed, or rejected if the AA bounced the trigger unit. The trades of a batch can
+// belong to several taker orders.
+func (op *Operator) handleExchangeResponse(trades []*types.Trade, bounced bool, bounceMessage string) error {
+	batch, err := op.matchesOfTrades(trades)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if bounced {
+		addresses := map[string]bool{}
+		for _, m := range batch {
+			op.HandleTxError(m, bounceMessage) // will also update status to REJECTED
+			for _, a := range tradeAddresses(m) {
+				addresses[a] = true
+			}
+		}
+
+		// the wallet sends balance updates only after successful trades
+		for a := range addresses {
+			go op.sendBalancesUpdateAfterTrade(a)
+		}
+
+		return nil
+	}
+
+	for _, m := range batch {
+		for i, trade := range m.Trades {
+			_, err = op.TradeService.UpdateTradeStatus(trade, "COMMITTED")
+			if err != nil {
+				logger.Error(err)
+				return err
+			}
+
+			// the trade is settled anyway, a failure to account the affiliate fees is only logged
+			err = op.AffiliateService.RecordTrade(trade, m.MakerOrders[i], m.TakerOrder)
+			if err != nil {
+				logger.Error(err)
 			}
 		}
 	}
+
+	return nil
+}
+
+// matchesOfTrades groups trades by taker order, along with their maker orders
+func (op *Operator) matchesOfTrades(trades []*types.Trade) ([]*types.Matches, error) {
+	orders := map[string]*types.Order{}
+	getOrder := func(hash string) (*types.Order, error) {
+		if orders[hash] != nil {
+			return orders[hash], nil
+		}
+
+		o, err := op.OrderService.GetByHash(hash)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		if o == nil {
+			return nil, fmt.Errorf("Order %v not found", hash)
+		}
+
+		orders[hash] = o
+		return o, nil
+	}
+
+	batch := []*types.Matches{}
+	byTaker := map[string]*types.Matches{}
+	for _, t := range trades {
+		makerOrder, err := getOrder(t.MakerOrderHash)
+		if err != nil {
+			return nil, err
+		}
+
+		m := byTaker[t.TakerOrderHash]
+		if m == nil {
+			takerOrder, err := getOrder(t.TakerOrderHash)
+			if err != nil {
+				return nil, err
+			}
+
+			m = &types.Matches{TakerOrder: takerOrder}
+			byTaker[t.TakerOrderHash] = m
+			batch = append(batch, m)
+		}
+
+		m.AppendMatch(makerOrder, t)
+	}
+
+	return batch, nil
 }
 
 func (op *Operator) sendBalancesUpdateAfterTrade(address string) {
@@ -371,36 +273,99 @@ func (op *Operator) HandleTrades(msg *types.OperatorMessage) error {
 	return nil
 }
 
-// QueueTrade
+// QueueTrade routes the matches to a transaction queue. The trades are queued in the order they
+// are received so that the trades of a user are settled in the order they were matched.
 func (op *Operator) QueueTrade(m *types.Matches) error {
-	op.mutex.Lock()
-	defer op.mutex.Unlock()
+	err := m.Validate()
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	op.setQueued(m, true)
+	err = op.routeTrades(m)
+	if err != nil {
+		logger.Error(err)
+		op.setQueued(m, false)
+		return err
+	}
+
+	return nil
+}
+
+// GetDeadLetters returns the matches that could not be executed
+func (op *Operator) GetDeadLetters() ([]*types.DeadLetter, error) {
+	return op.Broker.GetDeadLetters()
+}
 
-	txq, len, err := op.GetShortestQueue()
+// ReplayDeadLetter takes matches out of the dead-letter queue and queues their trades again.
+// The trades are pending again until they are settled.
+func (op *Operator) ReplayDeadLetter(id string) error {
+	d, err := op.Broker.TakeDeadLetter(id)
 	if err != nil {
 		logger.Error(err)
 		return err
 	}
 
-	if len > 10 {
-		logger.Warning("Transaction queue is overloaded")
-		//return errors.New("Transaction queue is full")
+	if d == nil {
+		return errors.New("Dead letter not found")
+	}
+
+	err = op.setTradesStatus(d.Matches, "PENDING")
+	if err == nil {
+		err = op.QueueTrade(d.Matches)
+	}
+
+	if err != nil {
+		logger.Error(err)
+		// put the matches back in the dead-letter queue
+		op.setTradesStatus(d.Matches, "ERROR")
+		op.Broker.PublishDeadLetter(d)
+		return err
 	}
 
-	logger.Infof("Queuing Trade on queue: %v (previous queue length = %v)", txq.Name, len)
+	return nil
+}
 
-	err = txq.PublishPendingTrades(m)
+// DiscardDeadLetter takes matches out of the dead-letter queue for good. Their trades stay
+// errored and the maker orders are given the amounts of these trades back.
+func (op *Operator) DiscardDeadLetter(id string) error {
+	d, err := op.Broker.TakeDeadLetter(id)
 	if err != nil {
 		logger.Error(err)
 		return err
 	}
 
+	if d == nil {
+		return errors.New("Dead letter not found")
+	}
+
+	for _, t := range d.Matches.Trades {
+		err := op.Broker.PublishRestoreOrderMessage(t)
+		if err != nil {
+			logger.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// setTradesStatus updates the status of the trades of some matches
+func (op *Operator) setTradesStatus(m *types.Matches, status string) error {
+	for _, t := range m.Trades {
+		_, err := op.TradeService.UpdateTradeStatus(t, status)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+	}
+
 	return nil
 }
 
 // GetShortestQueue
 func (op *Operator) GetShortestQueue() (*TxQueue, int, error) {
-	shortest := &TxQueue{}
+	var shortest *TxQueue
 	min := 1000
 
 	for _, txq := range op.TxQueues {
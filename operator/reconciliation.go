@@ -0,0 +1,96 @@
+package operator
+
+// The settlement of a trade normally ends with the exchange_response event sent by the wallet once
+// the exchange AA responded to the trigger unit of the trade. When this event is lost (node restart,
+// dropped websocket), the trade would stay uncommitted forever and keep the balances of its users
+// locked. The reconciliation job periodically looks for the trades not settled after a timeout and
+// asks the node for the status of their trigger unit to commit or reject them. The trades that were
+// never sent (and are no longer waiting in a transaction queue) are rejected.
+
+import (
+	"time"
+
+	"github.com/byteball/odex-backend/app"
+	"github.com/byteball/odex-backend/types"
+)
+
+// reconcileTrades runs the reconciliation of the unsettled trades at the configured interval
+func (op *Operator) reconcileTrades() {
+	interval := time.Duration(app.Config.SettlementReconciliationInterval) * time.Second
+	timeout := time.Duration(app.Config.SettlementTimeout) * time.Second
+
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		err := op.ReconcileTrades(time.Now().Add(-timeout))
+		if err != nil {
+			logger.Error(err)
+		}
+	}
+}
+
+// ReconcileTrades drives the trades created before the given time that are still waiting for the
+// response of the exchange AA to COMMITTED or REJECTED, depending on the status of their trigger
+// unit on the Obyte node
+func (op *Operator) ReconcileTrades(before time.Time) error {
+	trades, err := op.TradeService.GetUnsettledTrades(before)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	// the trades sent with the same trigger unit are settled together
+	units := []string{}
+	tradesByUnit := map[string][]*types.Trade{}
+	unsent := []*types.Trade{}
+	for _, t := range trades {
+		if t.TxHash == "" {
+			if op.isQueued(t) {
+				logger.Infof("Trade %v is still waiting in a transaction queue", t.Hash)
+				continue
+			}
+
+			logger.Warningf("Trade %v was never sent to the exchange AA, rejecting it", t.Hash)
+			unsent = append(unsent, t)
+			continue
+		}
+
+		if tradesByUnit[t.TxHash] == nil {
+			units = append(units, t.TxHash)
+		}
+
+		tradesByUnit[t.TxHash] = append(tradesByUnit[t.TxHash], t)
+	}
+
+	if len(unsent) > 0 {
+		err = op.handleExchangeResponse(unsent, true, "Trade not sent to the exchange AA")
+		if err != nil {
+			logger.Error(err)
+		}
+	}
+
+	for _, unit := range units {
+		status, err := op.ObyteProvider.GetTriggerUnitStatus(unit)
+		if err != nil {
+			logger.Error(err)
+			continue
+		}
+
+		switch status.Status {
+		case "DONE":
+			logger.Infof("Reconciling trades of trigger unit %v (bounced: %v)", unit, status.Bounced)
+			err = op.handleExchangeResponse(tradesByUnit[unit], status.Bounced, status.Error)
+		case "UNKNOWN":
+			// the trigger unit never made it to the node, the trades will not be settled
+			logger.Warningf("Trigger unit %v is unknown to the node, rejecting its trades", unit)
+			err = op.handleExchangeResponse(tradesByUnit[unit], true, "Trigger unit not found")
+		default:
+			logger.Infof("Trigger unit %v is still pending", unit)
+		}
+
+		if err != nil {
+			logger.Error(err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,151 @@
+package operator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils/testutils/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// fakeTxErrorPublisher records the error type of each trade rejected by the operator
+type fakeTxErrorPublisher struct {
+	rejected map[string]string
+}
+
+func (p *fakeTxErrorPublisher) PublishTxErrorMessage(m *types.Matches, errType string) error {
+	for _, t := range m.Trades {
+		p.rejected[t.Hash] = errType
+	}
+
+	return nil
+}
+
+type reconciliationTest struct {
+	op           *Operator
+	provider     *mocks.ObyteProvider
+	tradeService *mocks.TradeService
+	txErrors     *fakeTxErrorPublisher
+}
+
+func newReconciliationTest() *reconciliationTest {
+	op, _ := newRoutingTestOperator("SHORTEST", 1)
+	rt := &reconciliationTest{
+		op:           op,
+		provider:     new(mocks.ObyteProvider),
+		tradeService: new(mocks.TradeService),
+		txErrors:     &fakeTxErrorPublisher{rejected: map[string]string{}},
+	}
+
+	orderService := new(mocks.OrderService)
+	orderService.On("GetByHash", mock.AnythingOfType("string")).Return(func(h string) *types.Order {
+		return &types.Order{Hash: h, UserAddress: "USER-" + h}
+	}, nil)
+	orderService.On("AdjustBalancesForUncommittedTrades", mock.Anything, mock.Anything).Return(map[string]int64{})
+
+	affiliateService := new(mocks.AffiliateService)
+	affiliateService.On("RecordTrade", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	// the balances are sent to the users of the rejected trades in the background
+	rt.provider.On("GetBalances", mock.Anything).Return(map[string]int64{})
+
+	op.ObyteProvider = rt.provider
+	op.TradeService = rt.tradeService
+	op.OrderService = orderService
+	op.AffiliateService = affiliateService
+	op.txErrors = rt.txErrors
+	return rt
+}
+
+func newReconciliationTestTrade(hash string, unit string) *types.Trade {
+	return &types.Trade{
+		Hash:           hash,
+		TxHash:         unit,
+		MakerOrderHash: "maker-" + hash,
+		TakerOrderHash: "taker-" + hash,
+		Status:         "SUCCESS",
+	}
+}
+
+func (rt *reconciliationTest) triggerUnit(unit string, status string, bounced bool, errType string) {
+	rt.provider.On("GetTriggerUnitStatus", unit).Return(&types.TriggerUnitStatus{
+		Unit:    unit,
+		Status:  status,
+		Bounced: bounced,
+		Error:   errType,
+	}, nil)
+}
+
+func (rt *reconciliationTest) reconcile(t *testing.T, trades ...*types.Trade) {
+	before := time.Now()
+	rt.tradeService.On("GetUnsettledTrades", before).Return(trades, nil)
+
+	err := rt.op.ReconcileTrades(before)
+	assert.Nil(t, err)
+}
+
+func TestReconcileDoneTrades(t *testing.T) {
+	rt := newReconciliationTest()
+
+	committed1 := newReconciliationTestTrade("committed1", "UNIT1")
+	committed2 := newReconciliationTestTrade("committed2", "UNIT1")
+	bounced := newReconciliationTestTrade("bounced", "UNIT2")
+	rt.triggerUnit("UNIT1", "DONE", false, "")
+	rt.triggerUnit("UNIT2", "DONE", true, "not enough balance")
+	rt.tradeService.On("UpdateTradeStatus", mock.Anything, "COMMITTED").Return(nil, nil)
+
+	rt.reconcile(t, committed1, bounced, committed2)
+
+	// the status of each trigger unit is asked once for all its trades
+	rt.provider.AssertNumberOfCalls(t, "GetTriggerUnitStatus", 2)
+	rt.tradeService.AssertCalled(t, "UpdateTradeStatus", committed1, "COMMITTED")
+	rt.tradeService.AssertCalled(t, "UpdateTradeStatus", committed2, "COMMITTED")
+	rt.tradeService.AssertNotCalled(t, "UpdateTradeStatus", bounced, mock.Anything)
+
+	// the bounced trades are rejected with the error of the exchange AA
+	assert.Equal(t, map[string]string{"bounced": "not enough balance"}, rt.txErrors.rejected)
+}
+
+func TestReconcileUnknownTriggerUnit(t *testing.T) {
+	rt := newReconciliationTest()
+
+	unknown := newReconciliationTestTrade("unknown", "UNIT1")
+	pending := newReconciliationTestTrade("pending", "UNIT2")
+	rt.triggerUnit("UNIT1", "UNKNOWN", false, "")
+	rt.triggerUnit("UNIT2", "PENDING", false, "")
+
+	rt.reconcile(t, unknown, pending)
+
+	// the trades of an unknown trigger unit are rejected, the pending ones are left alone
+	assert.Equal(t, map[string]string{"unknown": "Trigger unit not found"}, rt.txErrors.rejected)
+	rt.tradeService.AssertNotCalled(t, "UpdateTradeStatus", mock.Anything, mock.Anything)
+}
+
+func TestReconcileUnsentTrades(t *testing.T) {
+	rt := newReconciliationTest()
+
+	// the execution of these trades failed with a TradesNotSentError, the queue released them
+	// without trigger unit
+	notSent := newReconciliationTestTrade("notSent", "")
+	failed := &types.Matches{
+		TakerOrder:  &types.Order{Hash: notSent.TakerOrderHash},
+		MakerOrders: []*types.Order{{Hash: notSent.MakerOrderHash}},
+		Trades:      []*types.Trade{notSent},
+	}
+	rt.op.setQueued(failed, true)
+	rt.op.releaseTrades(failed)
+
+	// these trades are still waiting in a transaction queue
+	queued := newReconciliationTestTrade("queued", "")
+	rt.op.setQueued(&types.Matches{Trades: []*types.Trade{queued}}, true)
+
+	rt.reconcile(t, notSent, queued)
+
+	assert.False(t, rt.op.isQueued(notSent))
+	assert.True(t, rt.op.isQueued(queued))
+	assert.Equal(t, map[string]string{"notSent": "Trade not sent to the exchange AA"}, rt.txErrors.rejected)
+	rt.provider.AssertNotCalled(t, "GetTriggerUnitStatus", mock.Anything)
+	rt.tradeService.AssertNotCalled(t, "UpdateTradeStatus", mock.Anything, mock.Anything)
+}
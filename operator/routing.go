@@ -0,0 +1,226 @@
+package operator
+
+// The trades are settled by several transaction queues in parallel. With the ADDRESS routing
+// policy, the trades of a user address are settled in the order they were matched: while an
+// address has trades waiting in a queue, its next trades are routed to the same queue. Trades
+// touching addresses pending on different queues are held until at most one of these queues
+// still holds them. Unrelated trades go to the shortest queue.
+//
+// The SHORTEST routing policy always picks the shortest queue, the trades are only kept in order
+// with a single queue.
+
+import (
+	"github.com/byteball/odex-backend/types"
+)
+
+// tradeAddresses returns the user addresses whose balances are changed by the matches
+func tradeAddresses(m *types.Matches) []string {
+	addresses := []string{}
+	seen := map[string]bool{}
+
+	orders := append([]*types.Order{m.TakerOrder}, m.MakerOrders...)
+	for _, o := range orders {
+		if o == nil || seen[o.UserAddress] {
+			continue
+		}
+
+		seen[o.UserAddress] = true
+		addresses = append(addresses, o.UserAddress)
+	}
+
+	return addresses
+}
+
+// routeTrades publishes the matches on a transaction queue. The addresses of the matches are
+// pending on the queue until releaseTrades is called.
+// Matches whose addresses are pending on several queues are held, without blocking the queuing
+// of the other matches, and published by releaseTrades once they can be routed. The matches
+// touching the addresses of held matches are held behind them to keep their order.
+func (op *Operator) routeTrades(m *types.Matches) error {
+	if op.routing == "SHORTEST" {
+		txq, _, err := op.GetShortestQueue()
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		return op.publishTrades(txq, m)
+	}
+
+	op.pendingMutex.Lock()
+	defer op.pendingMutex.Unlock()
+
+	addresses := tradeAddresses(m)
+	if op.isHeld(addresses) || len(op.pendingQueues(addresses)) > 1 {
+		logger.Infof("Trades of %v are pending on several queues, holding them until they settle", addresses)
+		op.held = append(op.held, m)
+		return nil
+	}
+
+	return op.routePendingTrades(m, addresses)
+}
+
+// routePendingTrades publishes the matches on the queue their addresses are pending on, or on
+// the shortest queue, and marks their addresses as pending on this queue
+func (op *Operator) routePendingTrades(m *types.Matches, addresses []string) error {
+	queues := op.pendingQueues(addresses)
+
+	var txq *TxQueue
+	if len(queues) == 1 {
+		txq = queues[0]
+	} else {
+		var err error
+		txq, _, err = op.GetShortestQueue()
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+	}
+
+	err := op.publishTrades(txq, m)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	for _, a := range addresses {
+		op.QueueAddressIndex[a] = txq
+		op.pendingTrades[a]++
+	}
+
+	return nil
+}
+
+// publishTrades publishes the matches on a transaction queue
+func (op *Operator) publishTrades(txq *TxQueue, m *types.Matches) error {
+	ln := txq.Length()
+	if ln > 10 {
+		logger.Warning("Transaction queue is overloaded")
+		//return errors.New("Transaction queue is full")
+	}
+
+	logger.Infof("Queuing Trade on queue: %v (previous queue length = %v)", txq.Name, ln)
+
+	err := txq.PublishPendingTrades(m)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// isHeld returns true if matches touching one of the addresses are held
+func (op *Operator) isHeld(addresses []string) bool {
+	for _, held := range op.held {
+		for _, a := range tradeAddresses(held) {
+			for _, b := range addresses {
+				if a == b {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+// releaseTrades is called once a queue is done with the matches (settled or not). Their
+// addresses are released from the queue once they have no other pending trades, and the held
+// matches that can now be routed are published.
+func (op *Operator) releaseTrades(m *types.Matches) {
+	op.setQueued(m, false)
+	if op.routing == "SHORTEST" {
+		return
+	}
+
+	op.pendingMutex.Lock()
+	defer op.pendingMutex.Unlock()
+
+	for _, a := range tradeAddresses(m) {
+		if op.pendingTrades[a] == 0 {
+			continue
+		}
+
+		op.pendingTrades[a]--
+		if op.pendingTrades[a] == 0 {
+			delete(op.pendingTrades, a)
+			delete(op.QueueAddressIndex, a)
+		}
+	}
+
+	op.routeHeldTrades()
+}
+
+// routeHeldTrades publishes the held matches that can be routed, in the order they were held.
+// A match stays held while one of its addresses is touched by a match held before it.
+func (op *Operator) routeHeldTrades() {
+	held := []*types.Matches{}
+	blocked := map[string]bool{}
+
+	for _, hm := range op.held {
+		addresses := tradeAddresses(hm)
+
+		wait := len(op.pendingQueues(addresses)) > 1
+		for _, a := range addresses {
+			wait = wait || blocked[a]
+		}
+
+		if wait {
+			held = append(held, hm)
+			for _, a := range addresses {
+				blocked[a] = true
+			}
+
+			continue
+		}
+
+		err := op.routePendingTrades(hm, addresses)
+		if err != nil {
+			logger.Error(err)
+			op.HandleError(hm)
+		}
+	}
+
+	op.held = held
+}
+
+// setQueued marks the trades of the matches as waiting in a transaction queue (or held), or not
+func (op *Operator) setQueued(m *types.Matches, queued bool) {
+	op.pendingMutex.Lock()
+	defer op.pendingMutex.Unlock()
+
+	for _, t := range m.Trades {
+		if queued {
+			op.queued[t.Hash] = true
+		} else {
+			delete(op.queued, t.Hash)
+		}
+	}
+}
+
+// isQueued returns true if a trade is waiting in a transaction queue (or held)
+func (op *Operator) isQueued(t *types.Trade) bool {
+	op.pendingMutex.Lock()
+	defer op.pendingMutex.Unlock()
+
+	return op.queued[t.Hash]
+}
+
+// pendingQueues returns the queues holding pending trades of the given addresses
+func (op *Operator) pendingQueues(addresses []string) []*TxQueue {
+	queues := []*TxQueue{}
+	seen := map[*TxQueue]bool{}
+
+	for _, a := range addresses {
+		txq := op.QueueAddressIndex[a]
+		if txq == nil || seen[txq] {
+			continue
+		}
+
+		seen[txq] = true
+		queues = append(queues, txq)
+	}
+
+	return queues
+}
@@ -0,0 +1,182 @@
+package operator
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/byteball/odex-backend/types"
+	sync "github.com/sasha-s/go-deadlock"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeTxQueueBroker keeps the matches published on each transaction queue in memory
+type fakeTxQueueBroker struct {
+	queues map[string][]*types.Matches
+}
+
+func (b *fakeTxQueueBroker) QueueLength(name string) int {
+	return len(b.queues[name])
+}
+
+func (b *fakeTxQueueBroker) PublishPendingTrades(name string, m *types.Matches) error {
+	b.queues[name] = append(b.queues[name], m)
+	return nil
+}
+
+// settle takes the first matches out of a queue and reports them done, as the consumer of
+// the queue does once they are executed
+func (b *fakeTxQueueBroker) settle(op *Operator, txq *TxQueue) *types.Matches {
+	name := "TX_QUEUES:" + txq.Name
+	m := b.queues[name][0]
+	b.queues[name] = b.queues[name][1:]
+
+	op.releaseTrades(m)
+	return m
+}
+
+// queued returns the matches waiting in a queue
+func (b *fakeTxQueueBroker) queued(txq *TxQueue) []*types.Matches {
+	return b.queues["TX_QUEUES:"+txq.Name]
+}
+
+func newRoutingTestOperator(routing string, n int) (*Operator, *fakeTxQueueBroker) {
+	broker := &fakeTxQueueBroker{queues: map[string][]*types.Matches{}}
+
+	op := &Operator{
+		QueueAddressIndex: map[string]*TxQueue{},
+		routing:           routing,
+		pendingTrades:     map[string]int{},
+		pendingMutex:      &sync.Mutex{},
+		queued:            map[string]bool{},
+	}
+
+	for i := 0; i < n; i++ {
+		op.TxQueues = append(op.TxQueues, &TxQueue{
+			Name:  fmt.Sprintf("oper%d", i),
+			queue: broker,
+			done:  op.releaseTrades,
+		})
+	}
+
+	return op, broker
+}
+
+var routingTestTrades = 0
+
+// newRoutingTestMatches returns matches of a taker address against maker addresses
+func newRoutingTestMatches(taker string, makers ...string) *types.Matches {
+	m := &types.Matches{TakerOrder: &types.Order{UserAddress: taker}}
+	for _, maker := range makers {
+		routingTestTrades++
+		m.MakerOrders = append(m.MakerOrders, &types.Order{UserAddress: maker})
+		m.Trades = append(m.Trades, &types.Trade{Hash: fmt.Sprintf("trade%d", routingTestTrades)})
+	}
+
+	return m
+}
+
+// queueTestTrades routes the matches as QueueTrade does, without validating them
+func queueTestTrades(t *testing.T, op *Operator, matches ...*types.Matches) {
+	for _, m := range matches {
+		op.setQueued(m, true)
+		err := op.routeTrades(m)
+		if err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestRouteTradesOfAnAddressToTheSameQueue(t *testing.T) {
+	op, broker := newRoutingTestOperator("ADDRESS", 2)
+	q0, q1 := op.TxQueues[0], op.TxQueues[1]
+
+	m1 := newRoutingTestMatches("A", "B")
+	m2 := newRoutingTestMatches("C", "D")
+	m3 := newRoutingTestMatches("E", "A")
+	queueTestTrades(t, op, m1, m2, m3)
+
+	// m2 goes to the shortest queue, m3 follows m1 although its queue is longer
+	assert.Equal(t, []*types.Matches{m1, m3}, broker.queued(q0))
+	assert.Equal(t, []*types.Matches{m2}, broker.queued(q1))
+	assert.Equal(t, q0, op.QueueAddressIndex["A"])
+	assert.Equal(t, q0, op.QueueAddressIndex["E"])
+	assert.Equal(t, 2, op.pendingTrades["A"])
+	assert.True(t, op.isQueued(m3.Trades[0]))
+
+	// the address stays on its queue until its last pending trade is done
+	broker.settle(op, q0)
+	assert.Equal(t, q0, op.QueueAddressIndex["A"])
+	assert.Equal(t, 1, op.pendingTrades["A"])
+	assert.Nil(t, op.QueueAddressIndex["B"])
+	assert.False(t, op.isQueued(m1.Trades[0]))
+
+	broker.settle(op, q0)
+	broker.settle(op, q1)
+	assert.Empty(t, op.pendingTrades)
+	assert.Empty(t, op.QueueAddressIndex)
+	assert.Empty(t, op.queued)
+}
+
+func TestRouteTradesHeldAcrossQueues(t *testing.T) {
+	op, broker := newRoutingTestOperator("ADDRESS", 3)
+	q0, q1, q2 := op.TxQueues[0], op.TxQueues[1], op.TxQueues[2]
+
+	m1 := newRoutingTestMatches("A", "B")
+	m2 := newRoutingTestMatches("C", "D")
+	queueTestTrades(t, op, m1, m2)
+	assert.Equal(t, q0, op.QueueAddressIndex["A"])
+	assert.Equal(t, q1, op.QueueAddressIndex["C"])
+
+	// A and C are pending on different queues, m3 is held and m4 touching C is held behind it
+	m3 := newRoutingTestMatches("A", "C")
+	m4 := newRoutingTestMatches("C", "F")
+	m5 := newRoutingTestMatches("G", "H")
+	queueTestTrades(t, op, m3, m4, m5)
+
+	assert.Equal(t, []*types.Matches{m3, m4}, op.held)
+	assert.Equal(t, []*types.Matches{m5}, broker.queued(q2))
+	assert.True(t, op.isQueued(m3.Trades[0]))
+
+	// the trades of C are not done, m3 and m4 stay held
+	broker.settle(op, q2)
+	assert.Equal(t, []*types.Matches{m3, m4}, op.held)
+
+	// once A is released, m3 and m4 follow the trades of C in order
+	broker.settle(op, q0)
+	assert.Empty(t, op.held)
+	assert.Empty(t, broker.queued(q0))
+	assert.Equal(t, []*types.Matches{m2, m3, m4}, broker.queued(q1))
+	assert.Equal(t, q1, op.QueueAddressIndex["A"])
+	assert.Equal(t, 3, op.pendingTrades["C"])
+
+	for len(broker.queued(q1)) > 0 {
+		broker.settle(op, q1)
+	}
+
+	assert.Empty(t, op.pendingTrades)
+	assert.Empty(t, op.QueueAddressIndex)
+	assert.Empty(t, op.queued)
+}
+
+func TestRouteTradesToTheShortestQueue(t *testing.T) {
+	op, broker := newRoutingTestOperator("SHORTEST", 2)
+	q0, q1 := op.TxQueues[0], op.TxQueues[1]
+
+	m1 := newRoutingTestMatches("A", "B")
+	m2 := newRoutingTestMatches("A", "C")
+	m3 := newRoutingTestMatches("D", "A")
+	queueTestTrades(t, op, m1, m2, m3)
+
+	// the addresses are not tracked, the trades of A are spread over the queues
+	assert.Equal(t, []*types.Matches{m1, m3}, broker.queued(q0))
+	assert.Equal(t, []*types.Matches{m2}, broker.queued(q1))
+	assert.Empty(t, op.QueueAddressIndex)
+	assert.Empty(t, op.pendingTrades)
+	assert.Empty(t, op.held)
+
+	broker.settle(op, q1)
+	broker.settle(op, q0)
+	assert.False(t, op.isQueued(m2.Trades[0]))
+	assert.False(t, op.isQueued(m1.Trades[0]))
+	assert.True(t, op.isQueued(m3.Trades[0]))
+}
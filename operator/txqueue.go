@@ -1,8 +1,9 @@
 package operator
 
 import (
-	"encoding/json"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/byteball/odex-backend/app"
 	"github.com/byteball/odex-backend/interfaces"
@@ -17,6 +18,12 @@ type TxQueue struct {
 	OrderService  interfaces.OrderService
 	ObyteProvider interfaces.ObyteProvider
 	Broker        *rabbitmq.Connection
+
+	// the broker holding the matches waiting in the queue
+	queue interfaces.TxQueueBroker
+
+	// called once the queue is done with some matches
+	done func(m *types.Matches)
 }
 
 // NewTxQueue
@@ -26,6 +33,7 @@ func NewTxQueue(
 	p interfaces.ObyteProvider,
 	o interfaces.OrderService,
 	rabbitConn *rabbitmq.Connection,
+	done func(m *types.Matches),
 ) (*TxQueue, error) {
 	txq := &TxQueue{
 		Name:          n,
@@ -33,6 +41,8 @@ func NewTxQueue(
 		OrderService:  o,
 		ObyteProvider: p,
 		Broker:        rabbitConn,
+		queue:         rabbitConn,
+		done:          done,
 	}
 
 	err := txq.PurgePendingTrades()
@@ -49,7 +59,13 @@ func NewTxQueue(
 		logger.Error(err)
 	}
 
-	err = txq.Broker.ConsumeQueuedTrades(ch, &q, txq.ExecuteTrade)
+	if app.Config.TradeBatchSize > 1 {
+		window := time.Duration(app.Config.TradeBatchWindow) * time.Millisecond
+		err = txq.Broker.ConsumeQueuedTradeBatches(ch, &q, app.Config.TradeBatchSize, window, canBatch, txq.executeQueuedTrades)
+	} else {
+		err = txq.Broker.ConsumeQueuedTrades(ch, &q, txq.executeQueuedTrade)
+	}
+
 	if err != nil {
 		logger.Error(err)
 	}
@@ -62,16 +78,19 @@ func (txq *TxQueue) GetChannel() *amqp.Channel {
 	return txq.Broker.GetChannel(name)
 }
 
-// Length
+// Length returns the number of matches waiting in the queue
 func (txq *TxQueue) Length() int {
-	name := "TX_QUEUES:" + txq.Name
-	ch := txq.Broker.GetChannel(name)
-	q, err := ch.QueueInspect(name + "@" + app.Config.Env)
-	if err != nil {
-		logger.Error(err)
+	return txq.queue.QueueLength("TX_QUEUES:" + txq.Name)
+}
+
+// executeQueuedTrade executes the matches received from the queue and reports them done,
+// whether they were settled or not
+func (txq *TxQueue) executeQueuedTrade(m *types.Matches, tag uint64) error {
+	if txq.done != nil {
+		defer txq.done(m)
 	}
 
-	return q.Messages
+	return txq.ExecuteTrade(m, tag)
 }
 
 // ExecuteTrade send a trade execution order to the AA. After sending the
@@ -80,15 +99,21 @@ func (txq *TxQueue) Length() int {
 func (txq *TxQueue) ExecuteTrade(m *types.Matches, tag uint64) error {
 	logger.Infof("Executing trades")
 
-	arrTriggerUnits, ex_err := txq.ObyteProvider.ExecuteTrade(m)
+	arrTriggerUnits, attempts, ex_err := txq.executeWithRetries(func() ([]string, error) {
+		return txq.ObyteProvider.ExecuteTrade(m)
+	})
 	if ex_err != nil {
-		//txq.HandleError(m)
 		logger.Error(ex_err)
-		//if len(arrTriggerUnits) == 0 {
+		txq.HandleDeadLetter(m, attempts, ex_err)
 		return ex_err
-		//}
 	}
 
+	return txq.handleTradesSent(m, arrTriggerUnits)
+}
+
+// handleTradesSent records the trigger units returned by the node on the trades of the matches
+// and publishes the trades that were sent
+func (txq *TxQueue) handleTradesSent(m *types.Matches, arrTriggerUnits []string) error {
 	countSuccessful := len(arrTriggerUnits)
 	if countSuccessful == 0 {
 		panic("no error but units array is empty")
@@ -143,6 +168,134 @@ func (txq *TxQueue) ExecuteTrade(m *types.Matches, tag uint64) error {
 	return nil
 }
 
+// executeWithRetries sends the trades to the node, retrying with an exponential backoff until
+// the configured number of attempts is reached. Only the errors meaning that the trades were not
+// sent are retried, the trades could otherwise be settled twice. It returns the number of attempts.
+func (txq *TxQueue) executeWithRetries(execute func() ([]string, error)) ([]string, int, error) {
+	delay := time.Duration(app.Config.TradeExecutionRetryDelay) * time.Second
+
+	for attempt := 1; ; attempt++ {
+		arrTriggerUnits, err := execute()
+		if err == nil || attempt >= app.Config.TradeExecutionAttempts {
+			return arrTriggerUnits, attempt, err
+		}
+
+		if _, ok := err.(*types.TradesNotSentError); !ok {
+			logger.Warningf("Trade execution attempt %d on queue %v failed: %v, the trades may have been sent", attempt, txq.Name, err)
+			return arrTriggerUnits, attempt, err
+		}
+
+		logger.Warningf("Trade execution attempt %d on queue %v failed: %v, retrying in %v", attempt, txq.Name, err, delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// canBatch tells whether some matches can join a batch of matches waiting to be executed
+func canBatch(batch []*types.Matches, m *types.Matches) bool {
+	for _, b := range batch {
+		if !m.CompatibleWith(b) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// executeQueuedTrades executes a batch of matches received from the queue and reports them done,
+// whether they were settled or not
+func (txq *TxQueue) executeQueuedTrades(batch []*types.Matches) error {
+	if txq.done != nil {
+		for _, m := range batch {
+			defer txq.done(m)
+		}
+	}
+
+	return txq.ExecuteTrades(batch)
+}
+
+// ExecuteTrades sends a batch of matches to the AA with a single trade execution order. The
+// trigger units returned by the node are split back to the trades of each matches, which are
+// then handled as if they had been executed on their own.
+func (txq *TxQueue) ExecuteTrades(batch []*types.Matches) error {
+	if len(batch) == 1 {
+		return txq.ExecuteTrade(batch[0], 0)
+	}
+
+	logger.Infof("Executing a batch of %d matches", len(batch))
+
+	arrTriggerUnits, attempts, ex_err := txq.executeWithRetries(func() ([]string, error) {
+		return txq.ObyteProvider.ExecuteTrades(batch)
+	})
+
+	var units [][]string
+	if ex_err == nil {
+		// the trades were possibly sent when the units don't match them, they are dead-lettered
+		// until they are checked on the node
+		units, ex_err = splitTriggerUnits(batch, arrTriggerUnits)
+	}
+
+	if ex_err != nil {
+		logger.Error(ex_err)
+		for _, m := range batch {
+			txq.HandleDeadLetter(m, attempts, ex_err)
+		}
+
+		return ex_err
+	}
+
+	var err error
+	for i, m := range batch {
+		sendErr := txq.handleTradesSent(m, units[i])
+		if sendErr != nil {
+			logger.Error(sendErr)
+			err = sendErr
+		}
+	}
+
+	return err
+}
+
+// splitTriggerUnits splits the trigger units returned for a batch of matches into the units of
+// each matches, in the order of their trades
+func splitTriggerUnits(batch []*types.Matches, arrTriggerUnits []string) ([][]string, error) {
+	countTrades := 0
+	for _, m := range batch {
+		countTrades += len(m.Trades)
+	}
+
+	if len(arrTriggerUnits) != countTrades {
+		return nil, fmt.Errorf("Expected %d trigger units for the batch, got %d", countTrades, len(arrTriggerUnits))
+	}
+
+	units := [][]string{}
+	for _, m := range batch {
+		n := len(m.Trades)
+		units = append(units, arrTriggerUnits[:n])
+		arrTriggerUnits = arrTriggerUnits[n:]
+	}
+
+	return units, nil
+}
+
+// HandleDeadLetter moves matches that could not be executed to the dead-letter queue. The
+// trades are marked as errored, which notifies the owners of the orders. The maker orders are
+// only given their amounts back by the engine once the dead letter is discarded, since the
+// trades may still be replayed.
+func (txq *TxQueue) HandleDeadLetter(m *types.Matches, attempts int, execErr error) {
+	logger.Errorf("Dead-lettering matches after %d attempts: %v", attempts, m)
+
+	err := txq.Broker.PublishDeadLetter(types.NewDeadLetter(txq.Name, m, attempts, execErr))
+	if err != nil {
+		logger.Error(err)
+	}
+
+	err = txq.Broker.PublishErrorMessage(m, execErr.Error())
+	if err != nil {
+		logger.Error(err)
+	}
+}
+
 /*func (txq *TxQueue) HandleTradeInvalid(m *types.Matches) error {
 	logger.Errorf("Trade invalid: %v", m)
 
@@ -191,16 +344,7 @@ func (txq *TxQueue) HandleTxSuccess(m *types.Matches) error {
 }*/
 
 func (txq *TxQueue) PublishPendingTrades(m *types.Matches) error {
-	name := "TX_QUEUES:" + txq.Name
-	ch := txq.Broker.GetChannel(name)
-	q := txq.Broker.GetQueue(ch, name)
-
-	b, err := json.Marshal(m)
-	if err != nil {
-		return errors.New("Failed to marshal trade object")
-	}
-
-	err = txq.Broker.Publish(ch, q, b)
+	err := txq.queue.PublishPendingTrades("TX_QUEUES:"+txq.Name, m)
 	if err != nil {
 		logger.Error(err)
 		return err
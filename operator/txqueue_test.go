@@ -0,0 +1,47 @@
+package operator
+
+import (
+	"testing"
+
+	"github.com/byteball/odex-backend/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestMatches(pairName string, takerHash string, makerHashes ...string) *types.Matches {
+	m := &types.Matches{TakerOrder: &types.Order{Hash: takerHash, PairName: pairName}}
+	for _, h := range makerHashes {
+		m.MakerOrders = append(m.MakerOrders, &types.Order{Hash: h, PairName: pairName})
+		m.Trades = append(m.Trades, &types.Trade{TakerOrderHash: takerHash, MakerOrderHash: h})
+	}
+
+	return m
+}
+
+func TestCanBatch(t *testing.T) {
+	batch := []*types.Matches{newTestMatches("BASE/QUOTE", "t1", "m1", "m2")}
+
+	assert.True(t, canBatch(batch, newTestMatches("BASE/QUOTE", "t2", "m3")))
+	assert.False(t, canBatch(batch, newTestMatches("OTHER/QUOTE", "t2", "m3")))
+	assert.False(t, canBatch(batch, newTestMatches("BASE/QUOTE", "t2", "m2")))
+	assert.False(t, canBatch(batch, newTestMatches("BASE/QUOTE", "m1", "m3")))
+}
+
+func TestSplitTriggerUnits(t *testing.T) {
+	batch := []*types.Matches{
+		newTestMatches("BASE/QUOTE", "t1", "m1", "m2"),
+		newTestMatches("BASE/QUOTE", "t2", "m3"),
+		newTestMatches("BASE/QUOTE", "t3", "m4", "m5", "m6"),
+	}
+
+	units, err := splitTriggerUnits(batch, []string{"u1", "u2", "u3", "u4", "u5", "u6"})
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"u1", "u2"}, {"u3"}, {"u4", "u5", "u6"}}, units)
+
+	units, err = splitTriggerUnits(batch, []string{"u1", "u2", "u3", "u4", "u5"})
+	assert.Error(t, err)
+	assert.Nil(t, units)
+
+	units, err = splitTriggerUnits(batch, []string{"u1", "u2", "u3", "u4", "u5", "u6", "u7"})
+	assert.Error(t, err)
+	assert.Nil(t, units)
+}
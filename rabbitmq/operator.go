@@ -2,13 +2,46 @@ package rabbitmq
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
+	"time"
 
 	"github.com/byteball/odex-backend/app"
 	"github.com/byteball/odex-backend/types"
 	"github.com/streadway/amqp"
 )
 
+// QueueLength returns the number of messages waiting in a queue
+func (c *Connection) QueueLength(name string) int {
+	ch := c.GetChannel(name)
+	q, err := ch.QueueInspect(name + "@" + app.Config.Env)
+	if err != nil {
+		logger.Error(err)
+	}
+
+	return q.Messages
+}
+
+// PublishPendingTrades publishes matches on a transaction queue
+func (c *Connection) PublishPendingTrades(name string, m *types.Matches) error {
+	ch := c.GetChannel(name)
+	q := c.GetQueue(ch, name)
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		logger.Error(err)
+		return errors.New("Failed to marshal trade object")
+	}
+
+	err = c.Publish(ch, q, b)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
 func (c *Connection) SubscribeOperator(fn func(*types.OperatorMessage) error) error {
 	ch := c.GetChannel("OPERATOR_SUB")
 	q := c.GetQueue(ch, "TX_MESSAGES")
@@ -231,6 +264,107 @@ func (c *Connection) PublishTradeSentMessage(matches *types.Matches) error {
 	return nil
 }
 
+// PublishDeadLetter moves matches that could not be executed to the dead-letter queue
+func (c *Connection) PublishDeadLetter(d *types.DeadLetter) error {
+	ch := c.GetChannel("OPERATOR_PUB")
+	q := c.GetQueue(ch, "TX_DEAD_LETTERS")
+
+	bytes, err := json.Marshal(d)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	err = c.Publish(ch, q, bytes)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	logger.Info("PUBLISHED DEAD LETTER", d.ID)
+	return nil
+}
+
+// GetDeadLetters returns the messages of the dead-letter queue without consuming them
+func (c *Connection) GetDeadLetters() ([]*types.DeadLetter, error) {
+	letters := []*types.DeadLetter{}
+	err := c.browseDeadLetters(func(d *types.DeadLetter, delivery amqp.Delivery) bool {
+		letters = append(letters, d)
+		return true
+	})
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return letters, nil
+}
+
+// TakeDeadLetter removes the message with the given id from the dead-letter queue and returns
+// it, nil if there is no such message
+func (c *Connection) TakeDeadLetter(id string) (*types.DeadLetter, error) {
+	var letter *types.DeadLetter
+	err := c.browseDeadLetters(func(d *types.DeadLetter, delivery amqp.Delivery) bool {
+		if d.ID != id {
+			return true
+		}
+
+		err := delivery.Ack(false)
+		if err != nil {
+			logger.Error(err)
+			return false
+		}
+
+		letter = d
+		return false
+	})
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return letter, nil
+}
+
+// browseDeadLetters gets the messages of the dead-letter queue one by one until fn returns false.
+// The messages are got on a channel of their own: closing it requeues the messages left
+// unacknowledged, in their original order.
+func (c *Connection) browseDeadLetters(fn func(*types.DeadLetter, amqp.Delivery) bool) error {
+	ch, err := c.Conn.Channel()
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	defer ch.Close()
+
+	q := c.GetQueue(c.GetChannel("OPERATOR_PUB"), "TX_DEAD_LETTERS")
+	for {
+		delivery, ok, err := ch.Get(q.Name, false)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		if !ok {
+			return nil
+		}
+
+		d := &types.DeadLetter{}
+		err = json.Unmarshal(delivery.Body, d)
+		if err != nil {
+			logger.Error(err)
+			continue
+		}
+
+		if !fn(d, delivery) {
+			return nil
+		}
+	}
+}
+
 func (c *Connection) ConsumeQueuedTrades(ch *amqp.Channel, q *amqp.Queue, fn func(*types.Matches, uint64) error) error {
 	go func() {
 		msgs, err := ch.Consume(
@@ -282,3 +416,150 @@ func (c *Connection) ConsumeQueuedTrades(ch *amqp.Channel, q *amqp.Queue, fn fun
 	}()
 	return nil
 }
+
+// ConsumeQueuedTradeBatches consumes the queued matches by batches of up to size matches, collected
+// by batchQueuedMatches. The messages of a batch are acknowledged together once fn returns.
+func (c *Connection) ConsumeQueuedTradeBatches(
+	ch *amqp.Channel,
+	q *amqp.Queue,
+	size int,
+	window time.Duration,
+	accept func(batch []*types.Matches, m *types.Matches) bool,
+	fn func([]*types.Matches) error,
+) error {
+	// the messages of a batch stay unacknowledged until it is executed
+	err := ch.Qos(size, 0, true)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	msgs, err := ch.Consume(
+		q.Name, // queue
+		"",     // consumer
+		false,  // auto-ack
+		false,  // exclusive
+		false,  // no-local
+		false,  // no-wait
+		nil,    // args
+	)
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	queued := make(chan *queuedMatches)
+	go func() {
+		for d := range msgs {
+			m := decodeQueuedMatches(d)
+			if m != nil {
+				queued <- &queuedMatches{delivery: d, matches: m}
+			}
+		}
+
+		close(queued)
+	}()
+
+	go batchQueuedMatches(queued, size, window, accept, func(batch []*queuedMatches) {
+		matches := []*types.Matches{}
+		for _, q := range batch {
+			matches = append(matches, q.matches)
+		}
+
+		logger.Infof("Receiving a batch of %d pending trades", len(matches))
+
+		err := fn(matches)
+		if err != nil {
+			logger.Error(err)
+		}
+
+		for _, q := range batch {
+			if err != nil {
+				q.delivery.Nack(false, false)
+			} else {
+				q.delivery.Ack(false)
+			}
+		}
+	})
+
+	return nil
+}
+
+// decodeQueuedMatches returns the matches carried by a message of a TX queue. Invalid messages
+// are rejected and nil is returned.
+// queuedMatches are matches received from a queue along with their delivery
+type queuedMatches struct {
+	delivery amqp.Delivery
+	matches  *types.Matches
+}
+
+// batchQueuedMatches groups the matches received from queued into batches of up to size matches
+// and passes each batch to fn, until queued is closed. A batch is closed when it is full, when
+// the window elapsed since its first matches were received or when accept refuses the next
+// matches, which then open the following batch.
+func batchQueuedMatches(
+	queued <-chan *queuedMatches,
+	size int,
+	window time.Duration,
+	accept func(batch []*types.Matches, m *types.Matches) bool,
+	fn func([]*queuedMatches),
+) {
+	var pending *queuedMatches
+
+	for {
+		q := pending
+		pending = nil
+
+		if q == nil {
+			var ok bool
+			q, ok = <-queued
+			if !ok {
+				return
+			}
+		}
+
+		batch := []*queuedMatches{q}
+		matches := []*types.Matches{q.matches}
+		timer := time.NewTimer(window)
+
+	collect:
+		for len(batch) < size {
+			select {
+			case q, ok := <-queued:
+				if !ok {
+					break collect
+				}
+
+				if !accept(matches, q.matches) {
+					pending = q
+					break collect
+				}
+
+				batch = append(batch, q)
+				matches = append(matches, q.matches)
+			case <-timer.C:
+				break collect
+			}
+		}
+
+		timer.Stop()
+		fn(batch)
+	}
+}
+
+func decodeQueuedMatches(d amqp.Delivery) *types.Matches {
+	m := &types.Matches{}
+	err := json.Unmarshal(d.Body, &m)
+	if err == nil {
+		err = m.Validate()
+	}
+
+	if err != nil {
+		logger.Error(err)
+		d.Nack(false, false)
+		return nil
+	}
+
+	return m
+}
@@ -0,0 +1,84 @@
+package rabbitmq
+
+import (
+	"testing"
+	"time"
+
+	"github.com/byteball/odex-backend/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func newQueuedMatches(pairName string, hash string) *queuedMatches {
+	return &queuedMatches{
+		matches: &types.Matches{TakerOrder: &types.Order{Hash: hash, PairName: pairName}},
+	}
+}
+
+func acceptAll(batch []*types.Matches, m *types.Matches) bool {
+	return true
+}
+
+// collectBatches runs batchQueuedMatches until queued is closed and returns the taker order
+// hashes of each batch
+func collectBatches(
+	queued chan *queuedMatches,
+	size int,
+	window time.Duration,
+	accept func(batch []*types.Matches, m *types.Matches) bool,
+) [][]string {
+	batches := [][]string{}
+	batchQueuedMatches(queued, size, window, accept, func(batch []*queuedMatches) {
+		hashes := []string{}
+		for _, q := range batch {
+			hashes = append(hashes, q.matches.TakerOrder.Hash)
+		}
+
+		batches = append(batches, hashes)
+	})
+
+	return batches
+}
+
+func TestBatchQueuedMatchesSize(t *testing.T) {
+	queued := make(chan *queuedMatches, 5)
+	for _, h := range []string{"1", "2", "3", "4", "5"} {
+		queued <- newQueuedMatches("BASE/QUOTE", h)
+	}
+
+	close(queued)
+
+	batches := collectBatches(queued, 2, time.Minute, acceptAll)
+	assert.Equal(t, [][]string{{"1", "2"}, {"3", "4"}, {"5"}}, batches)
+}
+
+func TestBatchQueuedMatchesWindow(t *testing.T) {
+	queued := make(chan *queuedMatches)
+	go func() {
+		queued <- newQueuedMatches("BASE/QUOTE", "1")
+		time.Sleep(100 * time.Millisecond)
+		queued <- newQueuedMatches("BASE/QUOTE", "2")
+		queued <- newQueuedMatches("BASE/QUOTE", "3")
+		close(queued)
+	}()
+
+	batches := collectBatches(queued, 5, 20*time.Millisecond, acceptAll)
+	assert.Equal(t, [][]string{{"1"}, {"2", "3"}}, batches)
+}
+
+func TestBatchQueuedMatchesAccept(t *testing.T) {
+	queued := make(chan *queuedMatches, 5)
+	queued <- newQueuedMatches("BASE/QUOTE", "1")
+	queued <- newQueuedMatches("BASE/QUOTE", "2")
+	queued <- newQueuedMatches("OTHER/QUOTE", "3")
+	queued <- newQueuedMatches("BASE/QUOTE", "4")
+	queued <- newQueuedMatches("BASE/QUOTE", "5")
+	close(queued)
+
+	samePair := func(batch []*types.Matches, m *types.Matches) bool {
+		return batch[0].TakerOrder.PairName == m.TakerOrder.PairName
+	}
+
+	// refused matches open the next batch and are not lost
+	batches := collectBatches(queued, 3, time.Minute, samePair)
+	assert.Equal(t, [][]string{{"1", "2"}, {"3"}, {"4", "5"}}, batches)
+}
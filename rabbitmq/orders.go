@@ -5,15 +5,22 @@ import (
 	"errors"
 	"log"
 
+	"github.com/globalsign/mgo/bson"
+
 	"github.com/byteball/odex-backend/types"
 )
 
+// SubscribeOrders consumes the engine messages. A message is acknowledged when it is marked
+// as done by the handler, so that the messages not yet processed by the engine are delivered
+// again after a crash. A message the handler returns an error for is rejected.
+// A message can be delivered twice (the engine crashed after processing it but before
+// acknowledging it), the engine skips the messages it has already journaled.
 func (c *Connection) SubscribeOrders(fn func(*Message) error) error {
 	ch := c.GetChannel("orderSubscribe")
 	q := c.GetQueue(ch, "order")
 
 	go func() {
-		msgs, err := c.Consume(ch, q)
+		msgs, err := c.ConsumeAfterAck(ch, q)
 		if err != nil {
 			logger.Error(err)
 		}
@@ -26,10 +33,26 @@ func (c *Connection) SubscribeOrders(fn func(*Message) error) error {
 				err := json.Unmarshal(d.Body, msg)
 				if err != nil {
 					logger.Error(err)
+					d.Nack(false, false)
 					continue
 				}
 
-				go fn(msg)
+				delivery := d
+				msg.done = func(err error) {
+					if err != nil {
+						delivery.Nack(false, false)
+						return
+					}
+
+					delivery.Ack(false)
+				}
+
+				// the messages are handled in the order they are received, the engine
+				// dispatches them to the workers of their pair
+				err = fn(msg)
+				if err != nil {
+					msg.Done(err)
+				}
 			}
 		}()
 
@@ -59,7 +82,11 @@ func (c *Connection) SubscribeTrades(fn func(*types.OperatorMessage) error) erro
 					continue
 				}
 
-				go fn(msg)
+				// the trades are handled one at a time, in the order they were matched: the
+				// queue the trades of an address are routed to depends on the queues its
+				// previous trades were routed to (see operator/routing.go). The handler only
+				// publishes the trades on a transaction queue, it doesn't wait for their settlement.
+				fn(msg)
 			}
 		}()
 
@@ -134,6 +161,50 @@ func (c *Connection) PublishCancelOrderMessage(o *types.Order) error {
 	return nil
 }
 
+// PublishAmendOrderMessage sends to the engine an order replacing the resting order with
+// the hash given by its AmendedOrderHash
+func (c *Connection) PublishAmendOrderMessage(o *types.Order) error {
+	b, err := json.Marshal(o)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	err = c.PublishOrder(&Message{
+		Type: "AMEND_ORDER",
+		Data: b,
+	})
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// PublishRestoreOrderMessage asks the engine to give back to the maker order of a trade
+// the amounts of this trade
+func (c *Connection) PublishRestoreOrderMessage(t *types.Trade) error {
+	b, err := json.Marshal(t)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	err = c.PublishOrder(&Message{
+		Type: "RESTORE_ORDER",
+		Data: b,
+	})
+
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
 /*func (c *Connection) PublishInvalidateMakerOrdersMessage(m types.Matches) error {
 	b, err := json.Marshal(m)
 	if err != nil {
@@ -174,10 +245,15 @@ func (c *Connection) PublishCancelOrderMessage(o *types.Order) error {
 	return nil
 }*/
 
+// PublishOrder sends a message to the engine, under a new message ID
 func (c *Connection) PublishOrder(order *Message) error {
 	ch := c.GetChannel("orderPublish")
 	q := c.GetQueue(ch, "order")
 
+	if order.ID == "" {
+		order.ID = bson.NewObjectId().Hex()
+	}
+
 	bytes, err := json.Marshal(order)
 	if err != nil {
 		log.Fatal("Failed to marshal order: ", err)
@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"log"
+	"sync"
 
 	"github.com/byteball/odex-backend/app"
 	"github.com/byteball/odex-backend/utils"
@@ -21,8 +22,25 @@ type Connection struct {
 	Conn *amqp.Connection
 }
 type Message struct {
+	// ID identifies a message published to the engine, a message delivered again after a
+	// crash keeps its ID
+	ID   string `json:"id"`
 	Type string `json:"type"`
 	Data []byte `json:"data"`
+
+	// done acknowledges the delivery of a consumed message
+	done func(error)
+	once sync.Once
+}
+
+// Done acknowledges a consumed message once it is handled, or rejects it if err is not nil.
+// Only the first call has an effect.
+func (m *Message) Done(err error) {
+	m.once.Do(func() {
+		if m.done != nil {
+			m.done(err)
+		}
+	})
 }
 
 func InitConnection(address string) *Connection {
@@ -1,19 +1,23 @@
 package server
 
 import (
+	"expvar"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/byteball/odex-backend/app"
 	"github.com/byteball/odex-backend/daos"
 	"github.com/byteball/odex-backend/endpoints"
 	"github.com/byteball/odex-backend/errors"
+	"github.com/byteball/odex-backend/interfaces"
 	"github.com/byteball/odex-backend/obyte"
 	"github.com/byteball/odex-backend/operator"
 	"github.com/byteball/odex-backend/rabbitmq"
 	"github.com/byteball/odex-backend/services"
+	"github.com/byteball/odex-backend/types"
 	"github.com/byteball/odex-backend/ws"
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -41,10 +45,11 @@ func Start() {
 
 	rabbitConn := rabbitmq.InitConnection(app.Config.RabbitMQURL)
 
-	provider := obyte.NewObyteProvider()
+	provider := newProvider()
 
 	router := NewRouter(provider, rabbitConn)
 	router.HandleFunc("/socket", ws.ConnectionEndpoint)
+	router.Handle("/debug/vars", expvar.Handler())
 
 	// certManager := autocert.Manager{
 	// 	Prompt:     autocert.AcceptTOS,
@@ -52,9 +57,10 @@ func Start() {
 	// 	Cache:      autocert.DirCache("/certs"),
 	// }
 
-	allowedHeaders := handlers.AllowedHeaders([]string{"Content-Type", "Accept", "Authorization", "Access-Control-Allow-Origin"})
+	allowedHeaders := handlers.AllowedHeaders([]string{"Content-Type", "Accept", "Authorization", "Access-Control-Allow-Origin", types.APIKeyHeader, types.APITimestampHeader, types.APISignatureHeader})
 	allowedOrigins := handlers.AllowedOrigins([]string{"*"})
 	allowedMethods := handlers.AllowedMethods([]string{"GET", "HEAD", "POST", "PUT", "DELETE", "OPTIONS"})
+	exposedHeaders := handlers.ExposedHeaders([]string{types.NextCursorHeader})
 
 	// start the server
 	if app.Config.EnableTLS {
@@ -62,7 +68,7 @@ func Start() {
 		err := http.ListenAndServeTLS(":443",
 			"/etc/ssl/matching-engine/server_certificate.pem",
 			"/etc/ssl/matching-engine/server_key.pem",
-			handlers.CORS(allowedHeaders, allowedOrigins, allowedMethods)(router),
+			handlers.CORS(allowedHeaders, allowedOrigins, allowedMethods, exposedHeaders)(router),
 		)
 
 		if err != nil {
@@ -84,7 +90,7 @@ func Start() {
 	} else {
 		address := fmt.Sprintf(":%v", app.Config.ServerPort)
 		log.Printf("server %v starting at %v\n", app.Version, address)
-		err := http.ListenAndServe(address, handlers.CORS(allowedHeaders, allowedOrigins, allowedMethods)(router))
+		err := http.ListenAndServe(address, handlers.CORS(allowedHeaders, allowedOrigins, allowedMethods, exposedHeaders)(router))
 		if err != nil {
 			log.Fatal("The process exited with error:", err.Error())
 		}
@@ -99,8 +105,30 @@ func handleCerts(certManager *autocert.Manager) {
 	}
 }
 
+// newProvider returns the configured settlement backend. The simulator knows the tokens of the
+// database.
+func newProvider() interfaces.ObyteProvider {
+	if app.Config.SettlementBackend != "SIMULATOR" {
+		return obyte.NewObyteProvider()
+	}
+
+	log.Println("settling the trades with the simulator")
+	simulator := obyte.NewSimulator(app.Config.SimulatorOperatorAddress)
+
+	tokens, err := daos.NewTokenDao().GetAll()
+	if err != nil {
+		panic(err)
+	}
+
+	for _, t := range tokens {
+		simulator.RegisterToken(t.Asset, t.Symbol, uint8(t.Decimals))
+	}
+
+	return simulator
+}
+
 func NewRouter(
-	provider *obyte.ObyteProvider,
+	provider interfaces.ObyteProvider,
 	rabbitConn *rabbitmq.Connection,
 ) *mux.Router {
 
@@ -112,14 +140,23 @@ func NewRouter(
 	pairDao := daos.NewPairDao()
 	tradeDao := daos.NewTradeDao()
 	accountDao := daos.NewAccountDao()
+	journalDao := daos.NewJournalDao()
+	feeScheduleDao := daos.NewFeeScheduleDao()
+	affiliateFeeDao := daos.NewAffiliateFeeDao()
+	matcherAlertDao := daos.NewMatcherAlertDao()
+	apiKeyDao := daos.NewAPIKeyDao()
 
 	// get services for injection
 	accountService := services.NewAccountService(accountDao, tokenDao)
 	ohlcvService := services.NewOHLCVService(tradeDao)
 	tokenService := services.NewTokenService(tokenDao, provider)
 	tradeService := services.NewTradeService(tradeDao)
-	validatorService := services.NewValidatorService(provider, accountDao, orderDao, pairDao)
+	feeService := services.NewFeeService(feeScheduleDao, tradeDao, provider)
+	validatorService := services.NewValidatorService(provider, accountDao, orderDao, pairDao, feeService)
 	priceService := services.NewPriceService()
+	affiliateService := services.NewAffiliateService(affiliateFeeDao)
+	watchdogService := services.NewWatchdogService(orderDao, matcherAlertDao)
+	apiKeyService := services.NewAPIKeyService(apiKeyDao, time.Duration(app.Config.APIRequestWindow)*time.Second)
 
 	infoService := services.NewInfoService(pairDao, tokenDao, tradeDao, orderDao, priceService)
 	pairService := services.NewPairService(pairDao, tokenDao, tradeDao, orderDao, provider)
@@ -128,13 +165,15 @@ func NewRouter(
 	// cronService := crons.NewCronService(ohlcvService)
 
 	// instantiate engine
-	eng := engine.NewEngine(rabbitConn, orderDao, tradeDao, pairDao, provider, orderService)
+	eng := engine.NewEngine(rabbitConn, orderDao, tradeDao, pairDao, provider, orderService, journalDao)
 
 	// deploy operator
 	op, err := operator.NewOperator(
 		tradeService,
 		orderService,
 		accountService,
+		affiliateService,
+		watchdogService,
 		provider,
 		rabbitConn,
 	)
@@ -144,7 +183,7 @@ func NewRouter(
 	}
 
 	// deploy http and ws endpoints
-	endpoints.ServeInfoResource(r, tokenService, infoService, provider)
+	endpoints.ServeInfoResource(r, tokenService, infoService, feeService, provider)
 	endpoints.ServeAccountResource(r, accountService, orderService, provider)
 	endpoints.ServeTokenResource(r, tokenService)
 	endpoints.ServePairResource(r, pairService, tokenService)
@@ -153,6 +192,10 @@ func NewRouter(
 	endpoints.ServeTradeResource(r, tradeService)
 	endpoints.ServeOrderResource(r, orderService, accountService, provider)
 	endpoints.ServeLoginResource(r)
+	endpoints.ServeAffiliateResource(r, affiliateService)
+	endpoints.ServeWatchdogResource(r, watchdogService)
+	endpoints.ServePrivateResource(r, apiKeyService, orderService, provider)
+	endpoints.ServeAdminResource(r, op, feeService)
 
 	//initialize rabbitmq subscriptions
 	rabbitConn.SubscribeOrders(eng.HandleOrders)
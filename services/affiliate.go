@@ -0,0 +1,51 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/byteball/odex-backend/interfaces"
+	"github.com/byteball/odex-backend/types"
+)
+
+// AffiliateService keeps the accounts of the fees earned by the affiliates on the committed trades
+type AffiliateService struct {
+	affiliateFeeDao interfaces.AffiliateFeeDao
+}
+
+// NewAffiliateService returns a new instance of AffiliateService
+func NewAffiliateService(affiliateFeeDao interfaces.AffiliateFeeDao) *AffiliateService {
+	return &AffiliateService{affiliateFeeDao}
+}
+
+// RecordTrade records the affiliate fees of the orders of a committed trade
+func (s *AffiliateService) RecordTrade(t *types.Trade, orders ...*types.Order) error {
+	for _, o := range orders {
+		f := types.NewAffiliateFee(o, t)
+		if f == nil {
+			continue
+		}
+
+		err := s.affiliateFeeDao.Upsert(f)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetFees returns the fees earned by an affiliate on the trades made between two times
+func (s *AffiliateService) GetFees(address string, from, to time.Time) ([]*types.AffiliateFee, error) {
+	return s.affiliateFeeDao.GetByAffiliate(address, from, to)
+}
+
+// GetRevenue returns the daily or monthly revenue of an affiliate between two times
+func (s *AffiliateService) GetRevenue(address string, unit string, from, to time.Time) ([]*types.AffiliateRevenue, error) {
+	if unit != "day" && unit != "month" {
+		return nil, errors.New("Unit should be day or month")
+	}
+
+	return s.affiliateFeeDao.GetRevenue(address, unit, from, to)
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils/testutils/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAffiliateServiceRecordTrade(t *testing.T) {
+	affiliateFeeDao := new(mocks.AffiliateFeeDao)
+	affiliateService := NewAffiliateService(affiliateFeeDao)
+
+	trade := &types.Trade{Hash: "0xtrade", PairName: "GBYTE/USDC", Amount: 100, QuoteAmount: 400}
+
+	maker := &types.Order{
+		Hash:        "0xmaker",
+		UserAddress: "MAKER",
+		Side:        "SELL",
+		OriginalOrder: map[string]interface{}{
+			"signed_message": map[string]interface{}{
+				"affiliate":           "AFFILIATE",
+				"affiliate_fee_asset": "base",
+				"affiliate_fee":       float64(10),
+				"sell_amount":         float64(100),
+			},
+		},
+	}
+
+	// the taker has no affiliate
+	taker := &types.Order{Hash: "0xtaker", UserAddress: "TAKER", Side: "BUY"}
+
+	expected := types.NewAffiliateFee(maker, trade)
+	affiliateFeeDao.On("Upsert", expected).Return(nil)
+
+	err := affiliateService.RecordTrade(trade, maker, taker)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), expected.Amount)
+
+	affiliateFeeDao.AssertNumberOfCalls(t, "Upsert", 1)
+	affiliateFeeDao.AssertExpectations(t)
+}
+
+func TestAffiliateServiceGetRevenue(t *testing.T) {
+	affiliateFeeDao := new(mocks.AffiliateFeeDao)
+	affiliateService := NewAffiliateService(affiliateFeeDao)
+
+	from, to := time.Unix(1550000000, 0), time.Unix(1560000000, 0)
+	revenue := []*types.AffiliateRevenue{&types.AffiliateRevenue{Period: "2019-05-01", Asset: "base", Amount: 10, Trades: 1}}
+	affiliateFeeDao.On("GetRevenue", "AFFILIATE", "day", from, to).Return(revenue, nil)
+
+	res, err := affiliateService.GetRevenue("AFFILIATE", "day", from, to)
+	assert.Nil(t, err)
+	assert.Equal(t, revenue, res)
+
+	_, err = affiliateService.GetRevenue("AFFILIATE", "year", from, to)
+	assert.NotNil(t, err)
+
+	affiliateFeeDao.AssertExpectations(t)
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/byteball/odex-backend/interfaces"
+	"github.com/byteball/odex-backend/types"
+
+	sync "github.com/sasha-s/go-deadlock"
+)
+
+// APIKeyService issues the API keys of the private API and authenticates its signed requests
+type APIKeyService struct {
+	apiKeyDao     interfaces.APIKeyDao
+	requestWindow time.Duration
+
+	// timestamps of the requests authenticated within the request window, a signed request
+	// can't be replayed. They are kept in memory only, the requests signed before the service
+	// started are rejected so that they can't be replayed after a restart either.
+	startedAt  int64
+	mu         sync.Mutex
+	seen       map[seenRequest]bool
+	seenPruned time.Time
+}
+
+type seenRequest struct {
+	key       string
+	timestamp int64
+}
+
+// NewAPIKeyService returns a new instance of APIKeyService. The signed requests are accepted
+// when their timestamp differs from the server time by less than the request window, is not
+// older than the start of the service and was not used by an earlier request of the key.
+func NewAPIKeyService(apiKeyDao interfaces.APIKeyDao, requestWindow time.Duration) *APIKeyService {
+	return &APIKeyService{
+		apiKeyDao:     apiKeyDao,
+		requestWindow: requestWindow,
+		startedAt:     time.Now().UnixNano() / int64(time.Millisecond),
+		seen:          map[seenRequest]bool{},
+	}
+}
+
+// CreateKey issues a new API key to an address. The returned key is the only one holding its
+// secret.
+func (s *APIKeyService) CreateKey(address string, label string) (*types.APIKey, error) {
+	k, err := types.NewAPIKey(address, label)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	err = s.apiKeyDao.Create(k)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// GetKeys returns the API keys of an address, without their secrets
+func (s *APIKeyService) GetKeys(address string) ([]*types.APIKey, error) {
+	keys, err := s.apiKeyDao.GetByAddress(address)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	for _, k := range keys {
+		k.Secret = ""
+	}
+
+	return keys, nil
+}
+
+// RevokeKey deletes an API key of an address
+func (s *APIKeyService) RevokeKey(address string, key string) error {
+	k, err := s.apiKeyDao.GetByKey(key)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if k == nil || k.Address != address {
+		return errors.New("API key not found")
+	}
+
+	err = s.apiKeyDao.Delete(address, key)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// Authenticate returns the API key that signed a request. The timestamp is the time of the
+// request in milliseconds since the epoch.
+func (s *APIKeyService) Authenticate(key, timestamp, signature, method, uri string, body []byte) (*types.APIKey, error) {
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return nil, errors.New("Invalid timestamp")
+	}
+
+	drift := time.Since(time.Unix(0, ms*int64(time.Millisecond)))
+	if drift > s.requestWindow || drift < -s.requestWindow {
+		return nil, errors.New("Request timestamp outside of the request window")
+	}
+
+	if ms < s.startedAt {
+		return nil, errors.New("Request timestamp older than the server start")
+	}
+
+	k, err := s.apiKeyDao.GetByKey(key)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if k == nil || !k.Verify(signature, timestamp, method, uri, body) {
+		return nil, errors.New("Invalid API key or signature")
+	}
+
+	if !s.markSeen(key, ms) {
+		return nil, errors.New("Request timestamp already used")
+	}
+
+	return k, nil
+}
+
+// markSeen records the timestamp of a request of a key and tells whether it was new. The
+// timestamps are forgotten once they are outside of the request window.
+func (s *APIKeyService) markSeen(key string, ms int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.seenPruned) > s.requestWindow {
+		for r := range s.seen {
+			if now.Sub(time.Unix(0, r.timestamp*int64(time.Millisecond))) > s.requestWindow {
+				delete(s.seen, r)
+			}
+		}
+
+		s.seenPruned = now
+	}
+
+	r := seenRequest{key: key, timestamp: ms}
+	if s.seen[r] {
+		return false
+	}
+
+	s.seen[r] = true
+	return true
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils/testutils/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeyServiceAuthenticate(t *testing.T) {
+	apiKeyDao := new(mocks.APIKeyDao)
+	apiKeyService := NewAPIKeyService(apiKeyDao, 30*time.Second)
+
+	k := &types.APIKey{Key: "key", Secret: "secret", Address: "ADDRESS"}
+	apiKeyDao.On("GetByKey", "key").Return(k, nil)
+	apiKeyDao.On("GetByKey", "unknown").Return(nil, nil)
+
+	timestamp := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
+	body := []byte(`{}`)
+	signature := k.Sign(timestamp, "GET", "/private/orders", body)
+
+	_, err := apiKeyService.Authenticate("key", timestamp, signature, "DELETE", "/private/orders", body)
+	assert.NotNil(t, err)
+
+	res, err := apiKeyService.Authenticate("key", timestamp, signature, "GET", "/private/orders", body)
+	assert.Nil(t, err)
+	assert.Equal(t, k, res)
+
+	// a request can't be replayed within the request window
+	_, err = apiKeyService.Authenticate("key", timestamp, signature, "GET", "/private/orders", body)
+	assert.NotNil(t, err)
+
+	next := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond)+1, 10)
+	res, err = apiKeyService.Authenticate("key", next, k.Sign(next, "GET", "/private/orders", body), "GET", "/private/orders", body)
+	assert.Nil(t, err)
+	assert.Equal(t, k, res)
+
+	_, err = apiKeyService.Authenticate("unknown", timestamp, signature, "GET", "/private/orders", body)
+	assert.NotNil(t, err)
+
+	old := strconv.FormatInt(time.Now().Add(-time.Minute).UnixNano()/int64(time.Millisecond), 10)
+	signature = k.Sign(old, "GET", "/private/orders", body)
+	_, err = apiKeyService.Authenticate("key", old, signature, "GET", "/private/orders", body)
+	assert.NotNil(t, err)
+
+	_, err = apiKeyService.Authenticate("key", "now", signature, "GET", "/private/orders", body)
+	assert.NotNil(t, err)
+
+	// the requests signed before a restart are not replayable although their timestamps were forgotten
+	time.Sleep(2 * time.Millisecond)
+	restarted := NewAPIKeyService(apiKeyDao, 30*time.Second)
+	res, err = restarted.Authenticate("key", next, k.Sign(next, "GET", "/private/orders", body), "GET", "/private/orders", body)
+	assert.Nil(t, res)
+	assert.NotNil(t, err)
+}
+
+func TestAPIKeyServiceGetAndRevokeKeys(t *testing.T) {
+	apiKeyDao := new(mocks.APIKeyDao)
+	apiKeyService := NewAPIKeyService(apiKeyDao, 30*time.Second)
+
+	k := &types.APIKey{Key: "key", Secret: "secret", Address: "ADDRESS"}
+	apiKeyDao.On("GetByAddress", "ADDRESS").Return([]*types.APIKey{k}, nil)
+	apiKeyDao.On("GetByKey", "key").Return(k, nil)
+	apiKeyDao.On("Delete", "ADDRESS", "key").Return(nil)
+
+	keys, err := apiKeyService.GetKeys("ADDRESS")
+	assert.Nil(t, err)
+	assert.Len(t, keys, 1)
+	assert.Equal(t, "", keys[0].Secret)
+
+	err = apiKeyService.RevokeKey("OTHER", "key")
+	assert.NotNil(t, err)
+	apiKeyDao.AssertNotCalled(t, "Delete", "OTHER", "key")
+
+	err = apiKeyService.RevokeKey("ADDRESS", "key")
+	assert.Nil(t, err)
+	apiKeyDao.AssertCalled(t, "Delete", "ADDRESS", "key")
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"time"
+
+	"github.com/byteball/odex-backend/ws"
+
+	sync "github.com/sasha-s/go-deadlock"
+)
+
+// cancelOnDisconnectKey identifies the address of a websocket connection that enabled the
+// cancellation of its orders on disconnection
+type cancelOnDisconnectKey struct {
+	client  *ws.Client
+	address string
+}
+
+// cancelOnDisconnectSwitch is the timer of an enabled switch, restarted by each heartbeat
+type cancelOnDisconnectSwitch struct {
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+// cancelOnDisconnect is a dead man's switch: it calls cancel with the address of a connection
+// that closed, or that didn't send a heartbeat before the end of its timeout
+type cancelOnDisconnect struct {
+	cancel func(address string)
+
+	mu       sync.Mutex
+	switches map[cancelOnDisconnectKey]*cancelOnDisconnectSwitch
+}
+
+func newCancelOnDisconnect(cancel func(address string)) *cancelOnDisconnect {
+	return &cancelOnDisconnect{
+		cancel:   cancel,
+		switches: map[cancelOnDisconnectKey]*cancelOnDisconnectSwitch{},
+	}
+}
+
+// enable starts, or restarts with a new timeout, the switch of an address of a connection
+func (d *cancelOnDisconnect) enable(c *ws.Client, address string, timeout time.Duration) {
+	key := cancelOnDisconnectKey{c, address}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	sw := d.switches[key]
+	if sw != nil {
+		sw.timer.Stop()
+	} else {
+		ws.RegisterConnectionUnsubscribeHandler(c, func(c *ws.Client) {
+			d.fire(key, nil)
+		})
+	}
+
+	sw = &cancelOnDisconnectSwitch{timeout: timeout}
+	sw.timer = time.AfterFunc(timeout, func() {
+		logger.Info("no heartbeat from", address, "before the end of its timeout")
+		d.fire(key, sw)
+	})
+
+	d.switches[key] = sw
+}
+
+// disable stops the switch of an address of a connection
+func (d *cancelOnDisconnect) disable(c *ws.Client, address string) {
+	key := cancelOnDisconnectKey{c, address}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	sw := d.switches[key]
+	if sw != nil {
+		sw.timer.Stop()
+		delete(d.switches, key)
+	}
+}
+
+// heartbeat restarts the timeout of the switch of an address of a connection. It returns false
+// when the switch is not enabled.
+func (d *cancelOnDisconnect) heartbeat(c *ws.Client, address string) bool {
+	key := cancelOnDisconnectKey{c, address}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	sw := d.switches[key]
+	if sw == nil {
+		return false
+	}
+
+	// a timer that already fired is not restarted, the orders are being cancelled
+	if !sw.timer.Stop() {
+		return false
+	}
+
+	sw.timer.Reset(sw.timeout)
+	return true
+}
+
+// fire cancels the orders of the address of an enabled switch, once. A timer only fires the
+// switch it was started for, not the switch that replaced it.
+func (d *cancelOnDisconnect) fire(key cancelOnDisconnectKey, expected *cancelOnDisconnectSwitch) {
+	d.mu.Lock()
+	sw := d.switches[key]
+	if expected != nil && sw != expected {
+		sw = nil
+	}
+
+	if sw != nil {
+		sw.timer.Stop()
+		delete(d.switches, key)
+	}
+	d.mu.Unlock()
+
+	if sw != nil {
+		d.cancel(key.address)
+	}
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/byteball/odex-backend/ws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelOnDisconnectTimeout(t *testing.T) {
+	cancelled := make(chan string, 10)
+	d := newCancelOnDisconnect(func(address string) {
+		cancelled <- address
+	})
+
+	c := ws.NewClient(nil)
+	d.enable(c, "ADDRESS", 200*time.Millisecond)
+
+	// the heartbeats postpone the cancellation
+	for i := 0; i < 4; i++ {
+		time.Sleep(50 * time.Millisecond)
+		assert.True(t, d.heartbeat(c, "ADDRESS"))
+	}
+
+	assert.Len(t, cancelled, 0)
+
+	select {
+	case address := <-cancelled:
+		assert.Equal(t, "ADDRESS", address)
+	case <-time.After(time.Second):
+		t.Fatal("orders not cancelled after the timeout")
+	}
+
+	assert.False(t, d.heartbeat(c, "ADDRESS"))
+	assert.False(t, d.heartbeat(c, "OTHER"))
+}
+
+func TestCancelOnDisconnectDisconnection(t *testing.T) {
+	cancelled := make(chan string, 10)
+	d := newCancelOnDisconnect(func(address string) {
+		cancelled <- address
+	})
+
+	c := ws.NewClient(nil)
+	d.enable(c, "ADDRESS", time.Minute)
+	d.enable(c, "DISABLED", time.Minute)
+	d.disable(c, "DISABLED")
+
+	// the disconnection of the client fires the switch once
+	key := cancelOnDisconnectKey{c, "ADDRESS"}
+	d.fire(key, nil)
+	d.fire(key, nil)
+	d.fire(cancelOnDisconnectKey{c, "DISABLED"}, nil)
+
+	assert.Len(t, cancelled, 1)
+	assert.Equal(t, "ADDRESS", <-cancelled)
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/byteball/odex-backend/interfaces"
+	"github.com/byteball/odex-backend/types"
+
+	sync "github.com/sasha-s/go-deadlock"
+)
+
+// userVolumeCacheDuration is how long the trading volume of a user on a pair is cached for
+const userVolumeCacheDuration = time.Minute
+
+// FeeService resolves the matcher fees of the users. The fee schedules are read once from the
+// database and kept in memory, the updates go through the service. Without default schedule,
+// the fees of the Obyte node apply to the pairs without schedule of their own.
+// The trading volumes of the users, which select their tier, are cached for a minute.
+type FeeService struct {
+	feeScheduleDao interfaces.FeeScheduleDao
+	tradeDao       interfaces.TradeDao
+	provider       interfaces.ObyteProvider
+
+	mu        sync.Mutex
+	schedules map[string]*types.FeeSchedule
+
+	volumesMu     sync.Mutex
+	volumes       map[userVolumeKey]*userVolume
+	volumesPruned time.Time
+}
+
+type userVolumeKey struct {
+	address  string
+	pairName string
+}
+
+type userVolume struct {
+	volume  int64
+	expires time.Time
+}
+
+// NewFeeService returns a new instance of FeeService
+func NewFeeService(
+	feeScheduleDao interfaces.FeeScheduleDao,
+	tradeDao interfaces.TradeDao,
+	provider interfaces.ObyteProvider,
+) *FeeService {
+	return &FeeService{
+		feeScheduleDao: feeScheduleDao,
+		tradeDao:       tradeDao,
+		provider:       provider,
+		volumes:        map[userVolumeKey]*userVolume{},
+	}
+}
+
+// GetSchedules returns the default fee schedule followed by the schedules of the pairs
+func (s *FeeService) GetSchedules() ([]*types.FeeSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	res := []*types.FeeSchedule{}
+	for pairName, schedule := range s.schedules {
+		if pairName != "" {
+			res = append(res, schedule)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].PairName < res[j].PairName
+	})
+
+	return append([]*types.FeeSchedule{s.defaultSchedule()}, res...), nil
+}
+
+// GetSchedule returns the fee schedule applied to a pair
+func (s *FeeService) GetSchedule(pairName string) (*types.FeeSchedule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := s.schedules[pairName]
+	if schedule == nil {
+		schedule = s.defaultSchedule()
+	}
+
+	return schedule, nil
+}
+
+// UpdateSchedule creates or replaces the fee schedule of a pair, or the default schedule when the
+// pair name is empty
+func (s *FeeService) UpdateSchedule(schedule *types.FeeSchedule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.load()
+	if err != nil {
+		return err
+	}
+
+	err = s.feeScheduleDao.Upsert(schedule)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	s.schedules[schedule.PairName] = schedule
+
+	return nil
+}
+
+// DeleteSchedule removes the fee schedule of a pair, which falls back to the default schedule
+func (s *FeeService) DeleteSchedule(pairName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.load()
+	if err != nil {
+		return err
+	}
+
+	if s.schedules[pairName] == nil {
+		return errors.New("Fee schedule not found")
+	}
+
+	err = s.feeScheduleDao.DeleteByPairName(pairName)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	delete(s.schedules, pairName)
+
+	return nil
+}
+
+// GetUserFees returns the fee rates of a user on a pair, given their trading volume on the pair
+// over the last 30 days
+func (s *FeeService) GetUserFees(address string, pairName string) (*types.UserFees, error) {
+	schedule, err := s.GetSchedule(pairName)
+	if err != nil {
+		return nil, err
+	}
+
+	volume := int64(0)
+	if len(schedule.Tiers) > 0 {
+		volume, err = s.getUserVolume(address, pairName)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+	}
+
+	makerFee, takerFee := schedule.Rates(volume)
+
+	return &types.UserFees{
+		Address:      address,
+		PairName:     pairName,
+		Volume:       volume,
+		MakerFee:     makerFee,
+		TakerFee:     takerFee,
+		AffiliateFee: schedule.AffiliateFee,
+	}, nil
+}
+
+// getUserVolume returns the trading volume of a user on a pair over the last 30 days, from the
+// cache if it was read less than a minute ago
+func (s *FeeService) getUserVolume(address string, pairName string) (int64, error) {
+	key := userVolumeKey{address: address, pairName: pairName}
+	now := time.Now()
+
+	s.volumesMu.Lock()
+	cached := s.volumes[key]
+	s.volumesMu.Unlock()
+
+	if cached != nil && now.Before(cached.expires) {
+		return cached.volume, nil
+	}
+
+	volume, err := s.tradeDao.GetUserVolume(address, pairName, now.Add(-types.FeeVolumePeriod))
+	if err != nil {
+		logger.Error(err)
+		return 0, err
+	}
+
+	s.volumesMu.Lock()
+	defer s.volumesMu.Unlock()
+
+	// drop the expired volumes of the users who stopped trading
+	if now.Sub(s.volumesPruned) > userVolumeCacheDuration {
+		for k, v := range s.volumes {
+			if !now.Before(v.expires) {
+				delete(s.volumes, k)
+			}
+		}
+
+		s.volumesPruned = now
+	}
+
+	s.volumes[key] = &userVolume{volume: volume, expires: now.Add(userVolumeCacheDuration)}
+
+	return volume, nil
+}
+
+// load reads the fee schedules from the database the first time they are needed. The mutex
+// must be held.
+func (s *FeeService) load() error {
+	if s.schedules != nil {
+		return nil
+	}
+
+	schedules, err := s.feeScheduleDao.GetAll()
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	s.schedules = map[string]*types.FeeSchedule{}
+	for _, schedule := range schedules {
+		s.schedules[schedule.PairName] = schedule
+	}
+
+	return nil
+}
+
+// defaultSchedule returns the stored default schedule, or the fees of the Obyte node. The mutex
+// must be held.
+func (s *FeeService) defaultSchedule() *types.FeeSchedule {
+	schedule := s.schedules[""]
+	if schedule != nil {
+		return schedule
+	}
+
+	matcherFee, affiliateFee := s.provider.GetFees()
+
+	return &types.FeeSchedule{
+		MakerFee:     matcherFee,
+		TakerFee:     matcherFee,
+		AffiliateFee: affiliateFee,
+		Tiers:        []types.FeeTier{},
+	}
+}
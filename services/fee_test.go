@@ -0,0 +1,125 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils/testutils/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFeeServiceGetUserFees(t *testing.T) {
+	feeScheduleDao := new(mocks.FeeScheduleDao)
+	tradeDao := new(mocks.TradeDao)
+	provider := new(mocks.ObyteProvider)
+
+	pairSchedule := &types.FeeSchedule{
+		PairName:     "GBYTE/USDC",
+		MakerFee:     0.002,
+		TakerFee:     0.003,
+		AffiliateFee: 0.0005,
+		Tiers:        []types.FeeTier{types.FeeTier{MinVolume: 1000, MakerFee: 0, TakerFee: 0.001}},
+	}
+
+	feeScheduleDao.On("GetAll").Return([]*types.FeeSchedule{pairSchedule}, nil)
+	provider.On("GetFees").Return(0.001, 0.0001)
+	tradeDao.On("GetUserVolume", "ADDRESS", "GBYTE/USDC", mock.Anything).Return(int64(5000), nil)
+
+	feeService := NewFeeService(feeScheduleDao, tradeDao, provider)
+
+	fees, err := feeService.GetUserFees("ADDRESS", "GBYTE/USDC")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5000), fees.Volume)
+	assert.Equal(t, float64(0), fees.MakerFee)
+	assert.Equal(t, 0.001, fees.TakerFee)
+	assert.Equal(t, 0.0005, fees.AffiliateFee)
+
+	// the pairs without schedule get the fees of the node
+	fees, err = feeService.GetUserFees("ADDRESS", "GBYTE/BTC")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.001, fees.MakerFee)
+	assert.Equal(t, 0.001, fees.TakerFee)
+	assert.Equal(t, 0.0001, fees.AffiliateFee)
+
+	schedules, err := feeService.GetSchedules()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(schedules))
+	assert.Equal(t, "", schedules[0].PairName)
+	assert.Equal(t, "GBYTE/USDC", schedules[1].PairName)
+
+	feeScheduleDao.AssertNumberOfCalls(t, "GetAll", 1)
+	tradeDao.AssertNumberOfCalls(t, "GetUserVolume", 1)
+}
+
+func TestFeeServiceCachesUserVolume(t *testing.T) {
+	feeScheduleDao := new(mocks.FeeScheduleDao)
+	tradeDao := new(mocks.TradeDao)
+	provider := new(mocks.ObyteProvider)
+
+	pairSchedule := &types.FeeSchedule{
+		PairName: "GBYTE/USDC",
+		MakerFee: 0.002,
+		TakerFee: 0.003,
+		Tiers:    []types.FeeTier{types.FeeTier{MinVolume: 1000, MakerFee: 0, TakerFee: 0.001}},
+	}
+
+	feeScheduleDao.On("GetAll").Return([]*types.FeeSchedule{pairSchedule}, nil)
+	tradeDao.On("GetUserVolume", "ADDRESS", "GBYTE/USDC", mock.Anything).Return(int64(5000), nil)
+	tradeDao.On("GetUserVolume", "OTHER", "GBYTE/USDC", mock.Anything).Return(int64(0), nil)
+
+	feeService := NewFeeService(feeScheduleDao, tradeDao, provider)
+
+	for i := 0; i < 3; i++ {
+		fees, err := feeService.GetUserFees("ADDRESS", "GBYTE/USDC")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(5000), fees.Volume)
+	}
+
+	tradeDao.AssertNumberOfCalls(t, "GetUserVolume", 1)
+
+	fees, err := feeService.GetUserFees("OTHER", "GBYTE/USDC")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.003, fees.TakerFee)
+	tradeDao.AssertNumberOfCalls(t, "GetUserVolume", 2)
+
+	// the expired volumes are read again
+	feeService.volumes[userVolumeKey{address: "ADDRESS", pairName: "GBYTE/USDC"}].expires = time.Now()
+
+	fees, err = feeService.GetUserFees("ADDRESS", "GBYTE/USDC")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5000), fees.Volume)
+	tradeDao.AssertNumberOfCalls(t, "GetUserVolume", 3)
+}
+
+func TestFeeServiceUpdateSchedule(t *testing.T) {
+	feeScheduleDao := new(mocks.FeeScheduleDao)
+	provider := new(mocks.ObyteProvider)
+
+	defaultSchedule := &types.FeeSchedule{MakerFee: 0.001, TakerFee: 0.002}
+
+	feeScheduleDao.On("GetAll").Return([]*types.FeeSchedule{}, nil)
+	feeScheduleDao.On("Upsert", defaultSchedule).Return(nil)
+	feeScheduleDao.On("DeleteByPairName", "").Return(nil)
+	provider.On("GetFees").Return(0.005, 0.0)
+
+	feeService := NewFeeService(feeScheduleDao, new(mocks.TradeDao), provider)
+
+	err := feeService.UpdateSchedule(defaultSchedule)
+	assert.Nil(t, err)
+
+	schedule, err := feeService.GetSchedule("GBYTE/USDC")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.002, schedule.TakerFee)
+
+	err = feeService.DeleteSchedule("")
+	assert.Nil(t, err)
+
+	schedule, err = feeService.GetSchedule("GBYTE/USDC")
+	assert.Nil(t, err)
+	assert.Equal(t, 0.005, schedule.TakerFee)
+
+	err = feeService.DeleteSchedule("GBYTE/USDC")
+	assert.NotNil(t, err)
+}
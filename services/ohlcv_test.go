@@ -94,7 +94,7 @@ func TestOHLCV(t *testing.T) {
 		Hash:           "0x4ac68946450e5a6273b92d81aa58f288d7b5515942456b89fb5c7e982efeas3f",
 		PairName:       pair.Name,
 		//Side:           "BUY",
-		Price:  9987,
+		Price:  types.NewDecimal(9987),
 		Amount: 125772,
 		Status: "SUCCESS",
 	}
@@ -109,7 +109,7 @@ func TestOHLCV(t *testing.T) {
 		}
 		sampleTrade.CreatedAt = tTime
 		sampleTrade.Amount = sampleTrade.Amount + 10
-		sampleTrade.Price = sampleTrade.Price + 5
+		sampleTrade.Price = types.NewDecimal(sampleTrade.Price.Float64() + 5)
 		sampleTrade.MakerOrderHash = sampleTrade.MakerOrderHash + "5"
 		sampleTrade.ID = bson.NewObjectId()
 		sampleTrade.Hash = sampleTrade.ComputeHash()
@@ -201,26 +201,26 @@ func tradeToTick(trade *types.Trade, tick *types.Tick, ts int64) *types.Tick {
 				BaseToken:  trade.BaseToken,
 				QuoteToken: trade.QuoteToken,
 			},
-			Open:        trade.Price,
-			High:        trade.Price,
-			Low:         trade.Price,
-			Close:       trade.Price,
+			Open:        trade.Price.Float64(),
+			High:        trade.Price.Float64(),
+			Low:         trade.Price.Float64(),
+			Close:       trade.Price.Float64(),
 			Volume:      trade.Amount,
 			QuoteVolume: trade.QuoteAmount,
 			Count:       1,
 			Timestamp:   ts * 1000,
 		}
 	} else {
-		tick.Close = trade.Price
+		tick.Close = trade.Price.Float64()
 		tick.Volume = tick.Volume + trade.Amount
 		tick.QuoteVolume = tick.QuoteVolume + trade.QuoteAmount
 
 		tick.Count++
-		if trade.Price > tick.High {
-			tick.High = trade.Price
+		if trade.Price.Float64() > tick.High {
+			tick.High = trade.Price.Float64()
 		}
-		if trade.Price < tick.Low {
-			tick.Low = trade.Price
+		if trade.Price.Float64() < tick.Low {
+			tick.Low = trade.Price.Float64()
 		}
 	}
 	return tick
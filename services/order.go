@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"log"
-	"math"
 	"strings"
 	"time"
 
@@ -33,6 +32,7 @@ type OrderService struct {
 	orderChannels       map[string]chan *types.WebsocketEvent
 	ordersInThePipeline map[string]*types.Order
 	mu                  sync.Mutex
+	cancelOnDisconnect  *cancelOnDisconnect
 }
 
 // NewOrderService returns a new instance of orderservice
@@ -58,7 +58,14 @@ func NewOrderService(
 		orderChannels,
 		ordersInThePipeline,
 		sync.Mutex{},
+		nil,
 	}
+	s.cancelOnDisconnect = newCancelOnDisconnect(func(address string) {
+		err := s.AutoCancelOrders(address)
+		if err != nil {
+			logger.Error(err)
+		}
+	})
 	ticker := time.NewTicker(1 * time.Minute)
 	go func() {
 		for range ticker.C {
@@ -100,11 +107,74 @@ func (s *OrderService) GetHistoryByUserAddress(addr string, limit ...int) ([]*ty
 	return s.orderDao.GetHistoryByUserAddress(addr, limit...)
 }
 
+// GetPageByUserAddress returns a page of the orders of a user, and the cursor of the next page
+// (empty after the last page)
+func (s *OrderService) GetPageByUserAddress(q *types.HistoryQuery) ([]*types.Order, string, error) {
+	orders, err := s.orderDao.GetPageByUserAddress(q)
+	if err != nil {
+		logger.Error(err)
+		return nil, "", err
+	}
+
+	return orders, nextOrderCursor(q, orders), nil
+}
+
+// GetHistoryPageByUserAddress returns a page of the orders of a user that are no longer in the
+// orderbook, and the cursor of the next page (empty after the last page)
+func (s *OrderService) GetHistoryPageByUserAddress(q *types.HistoryQuery) ([]*types.Order, string, error) {
+	orders, err := s.orderDao.GetHistoryPageByUserAddress(q)
+	if err != nil {
+		logger.Error(err)
+		return nil, "", err
+	}
+
+	return orders, nextOrderCursor(q, orders), nil
+}
+
+// nextOrderCursor returns the cursor following a full page of orders
+func nextOrderCursor(q *types.HistoryQuery, orders []*types.Order) string {
+	if q.Limit == 0 || len(orders) < q.Limit {
+		return ""
+	}
+
+	last := orders[len(orders)-1]
+	return types.NewCursor(last.CreatedAt, last.ID).Encode()
+}
+
 // NewOrder validates if the passed order is valid or not based on user's available
 // funds and order data.
 // If valid: Order is inserted in DB with order status as new and order is publiched
 // on rabbitmq queue for matching engine to process the order
-func (s *OrderService) NewOrder(o *types.Order) (e error) {
+func (s *OrderService) NewOrder(o *types.Order) error {
+	return s.newOrder(o, nil)
+}
+
+// AmendOrder handles an order replacing an open order of the same address. The new order is
+// validated like a new order, with the balance locked by the amended order available to it, and
+// is sent to the engine which replaces the amended order atomically.
+func (s *OrderService) AmendOrder(o *types.Order) error {
+	amended, err := s.orderDao.GetByHash(o.AmendedOrderHash)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if amended == nil {
+		return errors.New("No order with corresponding hash: " + o.AmendedOrderHash)
+	}
+
+	err = o.ValidateAmendment(amended)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	return s.newOrder(o, amended)
+}
+
+// newOrder validates an order and publishes it to the engine, as a new order or as the
+// amendment of the given order
+func (s *OrderService) newOrder(o *types.Order, amended *types.Order) (e error) {
 	s.mu.Lock()
 	existingOrder := s.ordersInThePipeline[o.Hash]
 	if existingOrder == nil {
@@ -175,6 +245,20 @@ func (s *OrderService) NewOrder(o *types.Order) (e error) {
 	}
 	//logger.Info("filled pair", o.Pair)
 
+	err = s.validator.ValidateMatcherFee(o)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if o.IsMarketOrder() {
+		err = s.priceMarketOrder(o, p)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+	}
+
 	balanceLockedInMemoryOrders := int64(0)
 	s.mu.Lock()
 	for _, po := range s.ordersInThePipeline {
@@ -184,6 +268,11 @@ func (s *OrderService) NewOrder(o *types.Order) (e error) {
 	}
 	s.mu.Unlock()
 
+	// the balance locked by the amended order is released when the engine replaces it
+	if amended != nil {
+		balanceLockedInMemoryOrders -= amended.RemainingSellAmount
+	}
+
 	deltas := s.AdjustBalancesForUncommittedTrades(o.UserAddress, map[string]int64{})
 	err = s.validator.ValidateAvailableBalance(o, deltas, balanceLockedInMemoryOrders)
 	if err != nil {
@@ -191,7 +280,12 @@ func (s *OrderService) NewOrder(o *types.Order) (e error) {
 		return err
 	}
 
-	err = s.broker.PublishNewOrderMessage(o)
+	if amended != nil {
+		err = s.broker.PublishAmendOrderMessage(o)
+	} else {
+		err = s.broker.PublishNewOrderMessage(o)
+	}
+
 	if err != nil {
 		logger.Error(err)
 		return err
@@ -200,6 +294,33 @@ func (s *OrderService) NewOrder(o *types.Order) (e error) {
 	return nil
 }
 
+// priceMarketOrder sets the worst price of a market order from the best price of the opposite
+// side of the orderbook and its maximum slippage. The order locks the amount it could sell at
+// this price, the engine only tightens the price against the orderbook it matches.
+func (s *OrderService) priceMarketOrder(o *types.Order, p *types.Pair) error {
+	if o.MaxSlippage > 0 {
+		side := "SELL"
+		if o.Side == "SELL" {
+			side = "BUY"
+		}
+
+		bestPrice, err := s.orderDao.GetBestPrice(p, side, o.MatcherAddress)
+		if err != nil {
+			logger.Error(err)
+			return err
+		}
+
+		if bestPrice.IsZero() && o.Price.IsZero() {
+			return errors.New("No liquidity for market order")
+		}
+
+		o.Price = o.WorstPrice(bestPrice)
+	}
+
+	o.RemainingSellAmount = o.WorstCaseSellAmount(p)
+	return nil
+}
+
 // CancelOrder handles the cancellation order requests.
 // Only Orders which are OPEN or NEW i.e. Not yet filled/partially filled
 // can be cancelled
@@ -234,7 +355,7 @@ func (s *OrderService) CancelOrder(oc *types.OrderCancel) error {
 		}
 	}
 
-	if o.Status == "FILLED" || o.Status == "ERROR" || foundInDb && o.Status == "CANCELLED" {
+	if o.Status == "FILLED" || o.Status == "ERROR" || o.Status == "AMENDED" || foundInDb && o.Status == "CANCELLED" {
 		return fmt.Errorf("Cannot cancel order %v. Status is %v", o.Hash, o.Status)
 	}
 
@@ -257,6 +378,43 @@ func (s *OrderService) CancelOrder(oc *types.OrderCancel) error {
 	return nil
 }
 
+// CancelAllOrders cancels the open orders of an address on a pair and side, or on all of them,
+// one by one like CancelOrder, and notifies the address with a summary of the cancelled orders
+func (s *OrderService) CancelAllOrders(oca *types.OrderCancelAll) (*types.OrderCancelAllSummary, error) {
+	orders, err := s.orderDao.GetCurrentByUserAddress(oca.Address)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	summary := &types.OrderCancelAllSummary{
+		Address:   oca.Address,
+		PairName:  oca.PairName,
+		Side:      oca.Side,
+		Cancelled: []string{},
+		Failed:    map[string]string{},
+	}
+
+	for _, o := range orders {
+		if !oca.Matches(o) {
+			continue
+		}
+
+		err := s.CancelOrder(&types.OrderCancel{OrderHash: o.Hash, UserAddress: oca.UserAddress})
+		if err != nil {
+			logger.Error(err)
+			summary.Failed[o.Hash] = err.Error()
+			continue
+		}
+
+		summary.Cancelled = append(summary.Cancelled, o.Hash)
+	}
+
+	go ws.SendOrderMessage("ORDERS_CANCELLED", oca.Address, summary)
+
+	return summary, nil
+}
+
 func (s *OrderService) GetSenderAddresses(oc *types.OrderCancel) (string, string, error) {
 	/*addr, err := s.validator.VerifyCancelSignature(oc)
 	if err != nil {
@@ -363,6 +521,77 @@ func (s *OrderService) CancelExpiredOrders() {
 	}
 }
 
+// AutoCancelOrders auto-cancels the open orders of an address, including the orders still on
+// their way to the engine, which the engine adds to the orderbook as cancelled. It keeps on
+// cancelling when an order can't be cancelled and returns the errors of all the failed orders.
+func (s *OrderService) AutoCancelOrders(address string) error {
+	// the orders in the pipeline are marked first, the orders the engine adds afterwards are
+	// cancelled and the orders it already added are found in the database
+	pipelineOrders := []*types.Order{}
+	s.mu.Lock()
+	for _, po := range s.ordersInThePipeline {
+		if po.UserAddress == address && po.Status != "CANCELLED" {
+			po.Status = "CANCELLED"
+			o := *po
+			pipelineOrders = append(pipelineOrders, &o)
+		}
+	}
+	s.mu.Unlock()
+
+	orders, err := s.orderDao.GetCurrentByUserAddress(address)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	hashes := map[string]bool{}
+	for _, o := range orders {
+		hashes[o.Hash] = true
+	}
+
+	for _, o := range pipelineOrders {
+		if !hashes[o.Hash] {
+			orders = append(orders, o)
+		}
+	}
+
+	logger.Info("will auto-cancel", len(orders), "orders of", address)
+	failed := []string{}
+	for _, order := range orders {
+		order.Status = "AUTO_CANCELLED"
+		err = s.broker.PublishCancelOrderMessage(order)
+		if err != nil {
+			logger.Error(err)
+			failed = append(failed, order.Hash+": "+err.Error())
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("Failed to auto-cancel %d of the %d orders of %v: %v", len(failed), len(orders), address, strings.Join(failed, ", "))
+	}
+
+	return nil
+}
+
+// EnableCancelOnDisconnect auto-cancels the open orders of an address when a websocket connection
+// closes, or when it doesn't send a heartbeat within the timeout. Enabling it again restarts the
+// timer with the new timeout.
+func (s *OrderService) EnableCancelOnDisconnect(c *ws.Client, address string, timeout time.Duration) {
+	s.cancelOnDisconnect.enable(c, address, timeout)
+}
+
+// DisableCancelOnDisconnect stops the auto-cancellation of the orders of an address on the
+// disconnection of a websocket connection
+func (s *OrderService) DisableCancelOnDisconnect(c *ws.Client, address string) {
+	s.cancelOnDisconnect.disable(c, address)
+}
+
+// Heartbeat restarts the timeout of the cancel-on-disconnect of an address of a connection. It
+// returns false when cancel-on-disconnect is not enabled.
+func (s *OrderService) Heartbeat(c *ws.Client, address string) bool {
+	return s.cancelOnDisconnect.heartbeat(c, address)
+}
+
 func (s *OrderService) handleOrderCancelled(res *types.EngineResponse) {
 	go ws.SendOrderMessage("ORDER_CANCELLED", res.Order.UserAddress, res.Order)
 	s.broadcastOrderBookUpdate([]*types.Order{res.Order})
@@ -395,6 +624,20 @@ func (s *OrderService) HandleEngineResponse(res *types.EngineResponse) error {
 		s.handleEngineOrderMatched(res)
 	case "ORDER_CANCELLED":
 		s.handleOrderCancelled(res)
+	case "ORDER_AMENDED":
+		s.handleEngineOrderAmended(res)
+	case "ORDER_AMEND_REJECTED":
+		s.handleEngineOrderAmendRejected(res)
+	case "ORDER_REMAINDER_CANCELLED":
+		s.handleEngineOrderRemainderCancelled(res)
+	case "ORDER_KILLED":
+		s.handleEngineOrderKilled(res)
+	case "ORDER_POST_ONLY_REJECTED":
+		s.handleEngineOrderPostOnlyRejected(res)
+	case "TRIGGER_ORDER_ADDED":
+		s.handleEngineTriggerOrderAdded(res)
+	case "ORDER_TRIGGERED":
+		s.handleEngineOrderTriggered(res)
 	case "TRADES_CANCELLED":
 		s.handleOrdersInvalidated(res)
 	default:
@@ -524,6 +767,62 @@ func (s *OrderService) handleEngineOrderMatched(res *types.EngineResponse) {
 	}
 }
 
+// handleEngineOrderRemainderCancelled handles immediate-or-cancel orders: the matched part (if any)
+// is processed as a regular match and the client is informed that the rest of the order was cancelled
+func (s *OrderService) handleEngineOrderRemainderCancelled(res *types.EngineResponse) {
+	if res.Matches != nil && res.Matches.Length() > 0 {
+		s.handleEngineOrderMatched(res)
+	}
+
+	go ws.SendOrderMessage("ORDER_REMAINDER_CANCELLED", res.Order.UserAddress, res.Order)
+}
+
+// handleEngineOrderKilled informs the client that his fill-or-kill order could not be filled
+// completely and was rejected
+func (s *OrderService) handleEngineOrderKilled(res *types.EngineResponse) {
+	go ws.SendOrderMessage("ORDER_KILLED", res.Order.UserAddress, res.Order)
+}
+
+// handleEngineOrderPostOnlyRejected informs the client that his post-only order was rejected
+// because it would have been matched against resting orders
+func (s *OrderService) handleEngineOrderPostOnlyRejected(res *types.EngineResponse) {
+	go ws.SendOrderMessage("ORDER_POST_ONLY_REJECTED", res.Order.UserAddress, res.Order)
+}
+
+// handleEngineOrderAmended informs the client that his order was replaced by the amending order
+// and updates the orderbook. An amending order that lost the priority of the amended order is
+// matched by the engine and followed by the response of its matching.
+func (s *OrderService) handleEngineOrderAmended(res *types.EngineResponse) {
+	payload := types.OrderAmendedPayload{Order: res.Order, AmendedOrder: res.AmendedOrder}
+	go ws.SendOrderMessage("ORDER_AMENDED", res.Order.UserAddress, payload)
+
+	orders := []*types.Order{res.AmendedOrder}
+	if res.Order.KeepsPriorityOf(res.AmendedOrder) {
+		orders = append(orders, res.Order)
+	}
+
+	s.broadcastOrderBookUpdate(orders)
+	s.broadcastRawOrderBookUpdate(orders)
+}
+
+// handleEngineOrderAmendRejected informs the client that the order he amended was filled or
+// cancelled before the amendment reached the orderbook
+func (s *OrderService) handleEngineOrderAmendRejected(res *types.EngineResponse) {
+	go ws.SendOrderMessage("ORDER_AMEND_REJECTED", res.Order.UserAddress, res.Order)
+}
+
+// handleEngineTriggerOrderAdded informs the client that his stop-loss or take-profit order is
+// waiting for the last trade price to cross its stop price. The order is not in the orderbook yet
+func (s *OrderService) handleEngineTriggerOrderAdded(res *types.EngineResponse) {
+	go ws.SendOrderMessage("TRIGGER_ORDER_ADDED", res.Order.UserAddress, res.Order)
+}
+
+// handleEngineOrderTriggered informs the client that the stop price of his order was crossed
+// and that the order was sent to the orderbook
+func (s *OrderService) handleEngineOrderTriggered(res *types.EngineResponse) {
+	go ws.SendOrderMessage("ORDER_TRIGGERED", res.Order.UserAddress, res.Order)
+}
+
 func (s *OrderService) handleEngineUnknownMessage(res *types.EngineResponse) {
 	log.Print("Receiving unknown engine message")
 	utils.PrintJSON(res)
@@ -746,7 +1045,7 @@ func (s *OrderService) broadcastRawOrderBookUpdate(orders []*types.Order) {
 	}
 
 	id := utils.GetOrderBookChannelID(p.BaseAsset, p.QuoteAsset)
-	go ws.GetRawOrderBookSocket().BroadcastMessage(id, orders)
+	go ws.GetRawOrderBookSocket().BroadcastMessage(id, types.PublicOrders(orders))
 }
 
 func (s *OrderService) broadcastTradeUpdate(trades []*types.Trade) {
@@ -782,7 +1081,7 @@ func (s *OrderService) AdjustBalancesForUncommittedTrades(address string, balanc
 			continue
 		}
 		baseTokenAmount := t.Amount
-		quoteTokenAmount := int64(math.Round(float64(baseTokenAmount) * t.Price))
+		quoteTokenAmount := types.MulPrice(baseTokenAmount, t.Price)
 		var sellAmount, buyAmount int64
 		var sellSymbol, buySymbol string
 		if o.Side == "SELL" {
@@ -9,6 +9,8 @@ import (
 	"github.com/byteball/odex-backend/types"
 	"github.com/byteball/odex-backend/utils/testutils"
 	"github.com/byteball/odex-backend/utils/testutils/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestCancelOrder(t *testing.T) {
@@ -50,3 +52,133 @@ func TestCancelOrder(t *testing.T) {
 	//engine.AssertNumberOfCalls(t, "CancelOrder", 1)
 	//engine.AssertCalled(t, "handleCancelOrder")
 }
+
+func TestCancelAllOrders(t *testing.T) {
+	orderDao := new(mocks.OrderDao)
+	pairDao := new(mocks.PairDao)
+	accountDao := new(mocks.AccountDao)
+	tradeDao := new(mocks.TradeDao)
+	validator := new(mocks.ValidatorService)
+
+	amqp := rabbitmq.InitConnection(app.Config.RabbitMQURL)
+	orderService := NewOrderService(
+		orderDao,
+		pairDao,
+		accountDao,
+		tradeDao,
+		validator,
+		amqp,
+	)
+
+	buy := testutils.GetTestOrder1()
+	sell := testutils.GetTestOrder2()
+
+	orderDao.On("GetCurrentByUserAddress", buy.UserAddress).Return([]*types.Order{&buy, &sell}, nil)
+	orderDao.On("GetByHash", sell.Hash).Return(&sell, nil)
+	orderDao.On("UpdateOrderStatus", sell.Hash, "CANCELLED").Return(nil)
+
+	oca := &types.OrderCancelAll{UserAddress: buy.UserAddress, Address: buy.UserAddress, PairName: "ZRX/WETH", Side: "SELL"}
+	summary, err := orderService.CancelAllOrders(oca)
+	if err != nil {
+		t.Error("Could not cancel orders", err)
+	}
+
+	assert.Equal(t, []string{sell.Hash}, summary.Cancelled)
+	assert.Empty(t, summary.Failed)
+	orderDao.AssertNotCalled(t, "GetByHash", buy.Hash)
+}
+
+func TestAutoCancelOrders(t *testing.T) {
+	orderDao := new(mocks.OrderDao)
+	pairDao := new(mocks.PairDao)
+	accountDao := new(mocks.AccountDao)
+	tradeDao := new(mocks.TradeDao)
+	validator := new(mocks.ValidatorService)
+
+	amqp := rabbitmq.InitConnection(app.Config.RabbitMQURL)
+	orderService := NewOrderService(
+		orderDao,
+		pairDao,
+		accountDao,
+		tradeDao,
+		validator,
+		amqp,
+	)
+
+	buy := testutils.GetTestOrder1()
+	sell := testutils.GetTestOrder2()
+
+	// an order of the address and an order of another address on their way to the engine
+	pipeline := &types.Order{Hash: "pipeline", UserAddress: buy.UserAddress, Status: "OPEN"}
+	other := &types.Order{Hash: "other", UserAddress: "OTHER", Status: "OPEN"}
+	orderService.ordersInThePipeline[pipeline.Hash] = pipeline
+	orderService.ordersInThePipeline[other.Hash] = other
+
+	orderDao.On("GetCurrentByUserAddress", buy.UserAddress).Return([]*types.Order{&buy, &sell}, nil)
+
+	err := orderService.AutoCancelOrders(buy.UserAddress)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "AUTO_CANCELLED", buy.Status)
+	assert.Equal(t, "AUTO_CANCELLED", sell.Status)
+	assert.Equal(t, "CANCELLED", pipeline.Status)
+	assert.Equal(t, "OPEN", other.Status)
+}
+
+func TestAmendOrderRejected(t *testing.T) {
+	orderDao := new(mocks.OrderDao)
+	pairDao := new(mocks.PairDao)
+	accountDao := new(mocks.AccountDao)
+	tradeDao := new(mocks.TradeDao)
+	validator := new(mocks.ValidatorService)
+
+	amqp := rabbitmq.InitConnection(app.Config.RabbitMQURL)
+	orderService := NewOrderService(
+		orderDao,
+		pairDao,
+		accountDao,
+		tradeDao,
+		validator,
+		amqp,
+	)
+
+	o := testutils.GetTestOrder1()
+	orderDao.On("GetByHash", o.Hash).Return(&o, nil)
+	orderDao.On("GetByHash", "unknown").Return(nil, nil)
+
+	amending := o
+	amending.Hash = "amending"
+	amending.AmendedOrderHash = "unknown"
+	assert.NotNil(t, orderService.AmendOrder(&amending))
+
+	amending.AmendedOrderHash = o.Hash
+	amending.UserAddress = testutils.GetTestAddress3()
+	assert.NotNil(t, orderService.AmendOrder(&amending))
+
+	validator.AssertNotCalled(t, "ValidateAvailableBalance", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPriceMarketOrder(t *testing.T) {
+	orderDao := new(mocks.OrderDao)
+	orderService := NewOrderService(orderDao, nil, nil, nil, nil, nil)
+
+	p := testutils.GetZRXWETHTestPair()
+	o := &types.Order{
+		Type:           "MARKET",
+		Side:           "SELL",
+		Amount:         1000,
+		MaxSlippage:    0.1,
+		MatcherAddress: "matcher",
+	}
+
+	orderDao.On("GetBestPrice", p, "BUY", "matcher").Return(types.NewDecimal(2), nil).Once()
+	assert.Nil(t, orderService.priceMarketOrder(o, p))
+	assert.Equal(t, types.NewDecimal(1.8), o.Price)
+	assert.Equal(t, int64(1000), o.RemainingSellAmount)
+
+	o.Price = types.Decimal{}
+	orderDao.On("GetBestPrice", p, "BUY", "matcher").Return(types.Decimal{}, nil).Once()
+	assert.EqualError(t, orderService.priceMarketOrder(o, p), "No liquidity for market order")
+
+	orderDao.AssertExpectations(t)
+}
@@ -90,7 +90,8 @@ func (s *OrderBookService) UnsubscribeOrderBookChannel(c *ws.Client, bt, qt stri
 	socket.UnsubscribeChannel(id, c)
 }
 
-// GetRawOrderBook fetches complete orderbook from engine
+// GetRawOrderBook fetches complete orderbook from engine. Iceberg orders only show their
+// visible amount.
 func (s *OrderBookService) GetRawOrderBook(bt, qt string) (*types.RawOrderBook, error) {
 	pair, err := s.pairDao.GetByAsset(bt, qt)
 	if err != nil {
@@ -110,7 +111,7 @@ func (s *OrderBookService) GetRawOrderBook(bt, qt string) (*types.RawOrderBook,
 
 	return &types.RawOrderBook{
 		PairName: pair.Name(),
-		Orders:   orders,
+		Orders:   types.PublicOrders(orders),
 	}, nil
 }
 
@@ -1,6 +1,8 @@
 package services
 
 import (
+	"time"
+
 	"github.com/byteball/odex-backend/interfaces"
 	"github.com/byteball/odex-backend/types"
 	"github.com/byteball/odex-backend/utils"
@@ -70,6 +72,23 @@ func (s *TradeService) GetSortedTradesByUserAddress(a string, limit ...int) ([]*
 	return s.tradeDao.GetSortedTradesByUserAddress(a, limit...)
 }
 
+// GetPageByUserAddress returns a page of the trades of a user, and the cursor of the next page
+// (empty after the last page)
+func (s *TradeService) GetPageByUserAddress(q *types.HistoryQuery) ([]*types.Trade, string, error) {
+	trades, err := s.tradeDao.GetPageByUserAddress(q)
+	if err != nil {
+		logger.Error(err)
+		return nil, "", err
+	}
+
+	if q.Limit == 0 || len(trades) < q.Limit {
+		return trades, "", nil
+	}
+
+	last := trades[len(trades)-1]
+	return trades, types.NewCursor(last.CreatedAt, last.ID).Encode(), nil
+}
+
 func (s *TradeService) GetSortedTrades(bt, qt string, n int) ([]*types.Trade, error) {
 	return s.tradeDao.GetSortedTrades(bt, qt, n)
 }
@@ -104,6 +123,12 @@ func (s *TradeService) GetByOrderHashes(hashes []string) ([]*types.Trade, error)
 	return s.tradeDao.GetByOrderHashes(hashes)
 }
 
+// GetUnsettledTrades returns the trades created before the given time that are still waiting
+// for the response of the exchange AA
+func (s *TradeService) GetUnsettledTrades(before time.Time) ([]*types.Trade, error) {
+	return s.tradeDao.GetUnsettledTrades(before)
+}
+
 func (s *TradeService) UpdatePendingTrade(t *types.Trade, txh string) (*types.Trade, error) {
 	t.Status = "PENDING"
 	t.TxHash = txh
@@ -14,6 +14,7 @@ type ValidatorService struct {
 	accountDao    interfaces.AccountDao
 	orderDao      interfaces.OrderDao
 	pairDao       interfaces.PairDao
+	feeService    interfaces.FeeService
 }
 
 func NewValidatorService(
@@ -21,6 +22,7 @@ func NewValidatorService(
 	accountDao interfaces.AccountDao,
 	orderDao interfaces.OrderDao,
 	pairDao interfaces.PairDao,
+	feeService interfaces.FeeService,
 ) *ValidatorService {
 
 	return &ValidatorService{
@@ -28,6 +30,7 @@ func NewValidatorService(
 		accountDao,
 		orderDao,
 		pairDao,
+		feeService,
 	}
 }
 
@@ -127,6 +130,33 @@ func (s *ValidatorService) ValidateBalance(o *types.Order) error {
 	return nil
 }
 
+// ValidateMatcherFee checks that the signed matcher fee of an order covers the fee rate of its user
+// on the pair. The post-only orders only pay the maker rate, the other orders might be takers.
+func (s *ValidatorService) ValidateMatcherFee(o *types.Order) error {
+	fees, err := s.feeService.GetUserFees(o.UserAddress, o.PairName)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	rate := fees.TakerFee
+	if o.PostOnly {
+		rate = fees.MakerFee
+	}
+
+	covered, err := o.CoversMatcherFeeRate(rate)
+	if err != nil {
+		logger.Error(err)
+		return err
+	}
+
+	if !covered {
+		return fmt.Errorf("Matcher fee too low: the fee rate on %v is %v", o.PairName, rate)
+	}
+
+	return nil
+}
+
 /*func (s *ValidatorService) VerifySignature(o *types.Order) (string, error) {
 	id, err := s.obyteProvider.VerifySignature(o)
 	if err != nil {
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/byteball/odex-backend/interfaces"
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/ws"
+)
+
+// WatchdogService checks the trades settled by the other matchers against the orders they share
+// with us, and raises alerts about the trades that wronged a user
+type WatchdogService struct {
+	orderDao        interfaces.OrderDao
+	matcherAlertDao interfaces.MatcherAlertDao
+}
+
+// NewWatchdogService returns a new instance of WatchdogService
+func NewWatchdogService(
+	orderDao interfaces.OrderDao,
+	matcherAlertDao interfaces.MatcherAlertDao,
+) *WatchdogService {
+	return &WatchdogService{orderDao, matcherAlertDao}
+}
+
+// CheckTrade checks that a trade of a matcher respected the limit prices, the signed matcher fees
+// and the price-time priority of the orders, records the alerts about the violations and publishes
+// them on the matcher alert channel. The trades of unknown orders can't be checked.
+func (s *WatchdogService) CheckTrade(t *types.MatcherTrade) ([]*types.MatcherAlert, error) {
+	maker, err := s.orderDao.GetByHash(t.MakerOrderHash)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	taker, err := s.orderDao.GetByHash(t.TakerOrderHash)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if maker == nil || taker == nil {
+		logger.Info("unknown orders in the trade of trigger unit", t.TriggerUnit)
+		return nil, nil
+	}
+
+	alerts := t.Check(maker, taker)
+
+	alert, err := s.checkPriority(t, maker, taker)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	if alert != nil {
+		alerts = append(alerts, alert)
+	}
+
+	for _, a := range alerts {
+		logger.Warning("matcher alert", a.Type, a.MatcherAddress, a.TriggerUnit, a.Message)
+
+		err := s.matcherAlertDao.Create(a)
+		if err != nil {
+			logger.Error(err)
+			return nil, err
+		}
+
+		ws.GetMatcherAlertSocket().BroadcastMessage(a)
+	}
+
+	return alerts, nil
+}
+
+// checkPriority looks for an order of the matcher that was resting in the orderbook when the
+// taker order arrived and should have been matched before the maker order, at a better price or
+// at the same price but placed earlier. Only the orders still open are known to have been open
+// at the time of the trade, but not whether their owners had the balance to fill them, so the
+// alerts are heuristic. The prices are compared as the exchange AA reads them.
+func (s *WatchdogService) checkPriority(t *types.MatcherTrade, maker, taker *types.Order) (*types.MatcherAlert, error) {
+	var orders []*types.Order
+	var err error
+
+	if taker.Side == "BUY" {
+		orders, err = s.orderDao.GetMatchingSellOrders(taker)
+	} else {
+		orders, err = s.orderDao.GetMatchingBuyOrders(taker)
+	}
+
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	for _, o := range orders {
+		if o.Hash == maker.Hash || o.UserAddress == taker.UserAddress || !o.CreatedAt.Before(taker.CreatedAt) {
+			continue
+		}
+
+		if o.Status != "OPEN" && o.Status != "PARTIAL_FILLED" {
+			continue
+		}
+
+		cmp := o.Price.Cmp(maker.Price)
+		betterPrice := cmp < 0
+		if maker.Side == "BUY" {
+			betterPrice = cmp > 0
+		}
+
+		if betterPrice || (cmp == 0 && o.CreatedAt.Before(maker.CreatedAt)) {
+			message := fmt.Sprintf("Skipped in favor of the order %v at the price %v", maker.Hash, maker.Price)
+			alert := types.NewMatcherAlert(types.PriorityViolationAlert, t, o, message)
+			alert.Heuristic = true
+			return alert, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// GetAlerts returns the latest alerts about a matcher, or about all the matchers when the address
+// is empty
+func (s *WatchdogService) GetAlerts(matcherAddress string, limit int) ([]*types.MatcherAlert, error) {
+	return s.matcherAlertDao.GetLatest(matcherAddress, limit)
+}
+
+// Subscribe sends the latest alerts to a websocket connection and registers it to the new ones
+func (s *WatchdogService) Subscribe(c *ws.Client) {
+	socket := ws.GetMatcherAlertSocket()
+
+	alerts, err := s.GetAlerts("", 20)
+	if err != nil {
+		logger.Error(err)
+		socket.SendErrorMessage(c, err.Error())
+		return
+	}
+
+	err = socket.Subscribe(c)
+	if err != nil {
+		logger.Error(err)
+		socket.SendErrorMessage(c, err.Error())
+		return
+	}
+
+	ws.RegisterConnectionUnsubscribeHandler(c, socket.UnsubscribeHandler())
+	socket.SendInitMessage(c, alerts)
+}
+
+// Unsubscribe removes a websocket connection from the matcher alerts
+func (s *WatchdogService) Unsubscribe(c *ws.Client) {
+	ws.GetMatcherAlertSocket().Unsubscribe(c)
+}
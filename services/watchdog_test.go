@@ -0,0 +1,66 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/byteball/odex-backend/types"
+	"github.com/byteball/odex-backend/utils/testutils/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWatchdogServiceCheckTrade(t *testing.T) {
+	orderDao := new(mocks.OrderDao)
+	matcherAlertDao := new(mocks.MatcherAlertDao)
+	watchdogService := NewWatchdogService(orderDao, matcherAlertDao)
+
+	now := time.Now()
+	signedMessage := map[string]interface{}{"sell_amount": float64(1000), "matcher_fee": float64(0)}
+
+	maker := &types.Order{Hash: "maker", UserAddress: "SELLER", Side: "SELL", Price: types.NewDecimal(2.5), CreatedAt: now.Add(-2 * time.Minute),
+		OriginalOrder: map[string]interface{}{"signed_message": signedMessage}}
+	taker := &types.Order{Hash: "taker", UserAddress: "BUYER", Side: "BUY", Price: types.NewDecimal(2.6), CreatedAt: now,
+		OriginalOrder: map[string]interface{}{"signed_message": signedMessage}}
+
+	// a cheaper sell order was resting before the taker arrived
+	cheaper := &types.Order{Hash: "cheaper", UserAddress: "OTHER", Side: "SELL", Price: types.NewDecimal(2.4), Status: "OPEN", CreatedAt: now.Add(-time.Minute)}
+	// a later order doesn't have priority
+	later := &types.Order{Hash: "later", UserAddress: "OTHER", Side: "SELL", Price: types.NewDecimal(2.3), Status: "OPEN", CreatedAt: now.Add(time.Second)}
+	// the same price as the maker order for the exchange AA, placed after it
+	samePrice := &types.Order{Hash: "same", UserAddress: "OTHER", Side: "SELL", Price: types.NewDecimal(2.4999999999999996), Status: "OPEN", CreatedAt: now.Add(-time.Minute)}
+	// an order no longer open may have been filled or cancelled before the trade
+	closed := &types.Order{Hash: "closed", UserAddress: "OTHER", Side: "SELL", Price: types.NewDecimal(2.2), Status: "FILLED", CreatedAt: now.Add(-time.Minute)}
+
+	orderDao.On("GetByHash", "maker").Return(maker, nil)
+	orderDao.On("GetByHash", "taker").Return(taker, nil)
+	orderDao.On("GetMatchingSellOrders", taker).Return([]*types.Order{later, samePrice, closed, cheaper, maker}, nil)
+	matcherAlertDao.On("Create", mock.Anything).Return(nil)
+
+	trade := &types.MatcherTrade{
+		TriggerUnit:    "unit",
+		MatcherAddress: "MATCHER",
+		MakerOrderHash: "maker",
+		TakerOrderHash: "taker",
+		Amount:         100,
+		QuoteAmount:    250,
+	}
+
+	alerts, err := watchdogService.CheckTrade(trade)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(alerts))
+	assert.Equal(t, types.PriorityViolationAlert, alerts[0].Type)
+	assert.Equal(t, "cheaper", alerts[0].OrderHash)
+	assert.Equal(t, "OTHER", alerts[0].UserAddress)
+	assert.True(t, alerts[0].Heuristic)
+
+	matcherAlertDao.AssertNumberOfCalls(t, "Create", 1)
+
+	// the trades of unknown orders are not checked
+	orderDao.On("GetByHash", "unknown").Return(nil, nil)
+	alerts, err = watchdogService.CheckTrade(&types.MatcherTrade{MakerOrderHash: "unknown", TakerOrderHash: "taker"})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(alerts))
+
+	matcherAlertDao.AssertNumberOfCalls(t, "Create", 1)
+}
@@ -0,0 +1,71 @@
+package types
+
+import (
+	"math"
+	"time"
+)
+
+// AffiliateFee is the fee earned by an affiliate on a committed trade of an order they referred.
+// The affiliate fee signed with the order is shared between its trades, in proportion of the
+// amount sold by the order in each trade.
+type AffiliateFee struct {
+	AffiliateAddress string    `json:"affiliateAddress" bson:"affiliateAddress"`
+	UserAddress      string    `json:"userAddress" bson:"userAddress"`
+	OrderHash        string    `json:"orderHash" bson:"orderHash"`
+	TradeHash        string    `json:"tradeHash" bson:"tradeHash"`
+	PairName         string    `json:"pairName" bson:"pairName"`
+	Asset            string    `json:"asset" bson:"asset"`
+	Amount           int64     `json:"amount" bson:"amount"`
+	TradedAt         time.Time `json:"tradedAt" bson:"tradedAt"`
+}
+
+// NewAffiliateFee returns the affiliate fee of an order on one of its trades, nil if the order
+// has no affiliate fee
+func NewAffiliateFee(o *Order, t *Trade) *AffiliateFee {
+	signedMessage, ok := o.OriginalOrder["signed_message"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	affiliate, _ := signedMessage["affiliate"].(string)
+	if affiliate == "" {
+		affiliate = o.AffiliateAddress
+	}
+
+	asset, _ := signedMessage["affiliate_fee_asset"].(string)
+	fee, _ := signedMessage["affiliate_fee"].(float64)
+	sellAmount, _ := signedMessage["sell_amount"].(float64)
+	if affiliate == "" || asset == "" || fee <= 0 || sellAmount <= 0 {
+		return nil
+	}
+
+	sold := t.Amount
+	if o.Side == "BUY" {
+		sold = t.QuoteAmount
+	}
+
+	amount := int64(math.Round(fee * float64(sold) / sellAmount))
+	if amount == 0 {
+		return nil
+	}
+
+	return &AffiliateFee{
+		AffiliateAddress: affiliate,
+		UserAddress:      o.UserAddress,
+		OrderHash:        o.Hash,
+		TradeHash:        t.Hash,
+		PairName:         t.PairName,
+		Asset:            asset,
+		Amount:           amount,
+		TradedAt:         t.CreatedAt,
+	}
+}
+
+// AffiliateRevenue is the amount of an asset earned by an affiliate over a day ("2006-01-02") or
+// a month ("2006-01")
+type AffiliateRevenue struct {
+	Period string `json:"period" bson:"period"`
+	Asset  string `json:"asset" bson:"asset"`
+	Amount int64  `json:"amount" bson:"amount"`
+	Trades int    `json:"trades" bson:"trades"`
+}
@@ -0,0 +1,69 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAffiliateFee(t *testing.T) {
+	tradedAt := time.Unix(1500000000, 0)
+	trade := &Trade{
+		Hash:        "0xtrade",
+		PairName:    "GBYTE/USDC",
+		Amount:      250,
+		QuoteAmount: 1000,
+		CreatedAt:   tradedAt,
+	}
+
+	sell := &Order{
+		Hash:        "0xsell",
+		UserAddress: "SELLER",
+		Side:        "SELL",
+		OriginalOrder: map[string]interface{}{
+			"signed_message": map[string]interface{}{
+				"affiliate":           "AFFILIATE",
+				"affiliate_fee_asset": "base",
+				"affiliate_fee":       float64(100),
+				"sell_amount":         float64(1000),
+			},
+		},
+	}
+
+	f := NewAffiliateFee(sell, trade)
+	assert.NotNil(t, f)
+	assert.Equal(t, "AFFILIATE", f.AffiliateAddress)
+	assert.Equal(t, "SELLER", f.UserAddress)
+	assert.Equal(t, "0xsell", f.OrderHash)
+	assert.Equal(t, "0xtrade", f.TradeHash)
+	assert.Equal(t, "GBYTE/USDC", f.PairName)
+	assert.Equal(t, "base", f.Asset)
+	assert.Equal(t, int64(25), f.Amount)
+	assert.Equal(t, tradedAt, f.TradedAt)
+
+	buy := &Order{
+		Hash:             "0xbuy",
+		UserAddress:      "BUYER",
+		AffiliateAddress: "REFERRER",
+		Side:             "BUY",
+		OriginalOrder: map[string]interface{}{
+			"signed_message": map[string]interface{}{
+				"affiliate_fee_asset": "base",
+				"affiliate_fee":       float64(40),
+				"sell_amount":         float64(2000),
+			},
+		},
+	}
+
+	f = NewAffiliateFee(buy, trade)
+	assert.NotNil(t, f)
+	assert.Equal(t, "REFERRER", f.AffiliateAddress)
+	assert.Equal(t, int64(20), f.Amount)
+
+	noFee := &Order{Side: "SELL", OriginalOrder: map[string]interface{}{
+		"signed_message": map[string]interface{}{"sell_amount": float64(1000)},
+	}}
+	assert.Nil(t, NewAffiliateFee(noFee, trade))
+	assert.Nil(t, NewAffiliateFee(&Order{Side: "SELL"}, trade))
+}
@@ -0,0 +1,102 @@
+package types
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Headers of the requests to the private API
+const (
+	APIKeyHeader       = "X-API-KEY"
+	APITimestampHeader = "X-API-TIMESTAMP"
+	APISignatureHeader = "X-API-SIGNATURE"
+)
+
+// APIKey grants access to the private API on behalf of an Obyte address. The requests are
+// signed with the secret, which is only returned when the key is created.
+type APIKey struct {
+	ID        bson.ObjectId `json:"-" bson:"_id"`
+	Key       string        `json:"key" bson:"key"`
+	Secret    string        `json:"secret,omitempty" bson:"secret"`
+	Address   string        `json:"address" bson:"address"`
+	Label     string        `json:"label" bson:"label"`
+	CreatedAt time.Time     `json:"createdAt" bson:"createdAt"`
+}
+
+// NewAPIKey returns a new API key with a random key and secret
+func NewAPIKey(address string, label string) (*APIKey, error) {
+	key, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	return &APIKey{
+		Key:       key,
+		Secret:    secret,
+		Address:   address,
+		Label:     label,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// Sign returns the signature of a request: the hex encoded HMAC-SHA256, keyed by the secret,
+// of the timestamp, the method, the request URI (path and query) and the body concatenated
+func (k *APIKey) Sign(timestamp string, method string, uri string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(k.Secret))
+	mac.Write([]byte(timestamp + method + uri))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify tells whether the signature of a request was made with the secret of the key
+func (k *APIKey) Verify(signature string, timestamp string, method string, uri string, body []byte) bool {
+	expected := k.Sign(timestamp, method, uri, body)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// SignedOrderAddress returns the address that signed an order, as sent by the wallets to the
+// node: the address of the signed message, or else the first author of the signature. The
+// unsigned fields of the order are not trusted.
+func SignedOrderAddress(signedOrder interface{}) (string, error) {
+	m, ok := signedOrder.(map[string]interface{})
+	if !ok {
+		return "", errors.New("Invalid signed order")
+	}
+
+	if signedMessage, ok := m["signed_message"].(map[string]interface{}); ok {
+		if address, ok := signedMessage["address"].(string); ok && address != "" {
+			return address, nil
+		}
+	}
+
+	if authors, ok := m["authors"].([]interface{}); ok && len(authors) > 0 {
+		if author, ok := authors[0].(map[string]interface{}); ok {
+			if address, ok := author["address"].(string); ok && address != "" {
+				return address, nil
+			}
+		}
+	}
+
+	return "", errors.New("Signed order has no address")
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,56 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIKeySignature(t *testing.T) {
+	k, err := NewAPIKey("ADDRESS", "bot")
+	assert.Nil(t, err)
+	assert.Len(t, k.Key, 32)
+	assert.Len(t, k.Secret, 64)
+
+	other, _ := NewAPIKey("ADDRESS", "bot")
+	assert.NotEqual(t, k.Key, other.Key)
+	assert.NotEqual(t, k.Secret, other.Secret)
+
+	body := []byte(`{"signed_message":{}}`)
+	signature := k.Sign("1500000000000", "POST", "/private/orders", body)
+
+	assert.True(t, k.Verify(signature, "1500000000000", "POST", "/private/orders", body))
+	assert.False(t, k.Verify(signature, "1500000000001", "POST", "/private/orders", body))
+	assert.False(t, k.Verify(signature, "1500000000000", "DELETE", "/private/orders", body))
+	assert.False(t, k.Verify(signature, "1500000000000", "POST", "/private/orders", []byte(`{}`)))
+	assert.False(t, other.Verify(signature, "1500000000000", "POST", "/private/orders", body))
+}
+
+func TestSignedOrderAddress(t *testing.T) {
+	address, err := SignedOrderAddress(map[string]interface{}{
+		"signed_message": map[string]interface{}{"address": "SIGNER"},
+		"authors":        []interface{}{map[string]interface{}{"address": "AUTHOR"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "SIGNER", address)
+
+	address, err = SignedOrderAddress(map[string]interface{}{
+		"signed_message": map[string]interface{}{},
+		"authors":        []interface{}{map[string]interface{}{"address": "AUTHOR"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "AUTHOR", address)
+
+	_, err = SignedOrderAddress(map[string]interface{}{"signed_message": map[string]interface{}{}})
+	assert.NotNil(t, err)
+
+	// the address of an unsigned field is not trusted
+	_, err = SignedOrderAddress(map[string]interface{}{
+		"signed_message": map[string]interface{}{},
+		"userAddress":    "USER",
+	})
+	assert.NotNil(t, err)
+
+	_, err = SignedOrderAddress("order")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,30 @@
+package types
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// DeadLetter holds matches whose trades could not be sent to the node after all the
+// execution attempts of their transaction queue, or whose execution failed in a way that
+// doesn't tell whether they were sent
+type DeadLetter struct {
+	ID       string    `json:"id"`
+	Queue    string    `json:"queue"`
+	Matches  *Matches  `json:"matches"`
+	Error    string    `json:"error"`
+	Attempts int       `json:"attempts"`
+	FailedAt time.Time `json:"failedAt"`
+}
+
+func NewDeadLetter(queue string, m *Matches, attempts int, err error) *DeadLetter {
+	return &DeadLetter{
+		ID:       bson.NewObjectId().Hex(),
+		Queue:    queue,
+		Matches:  m,
+		Error:    err.Error(),
+		Attempts: attempts,
+		FailedAt: time.Now(),
+	}
+}
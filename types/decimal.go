@@ -0,0 +1,274 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// OscriptPrecision is the number of significant digits oscript, the language of the
+// exchange AA, computes with
+const OscriptPrecision = 15
+
+// Decimal is an exact decimal number following the arithmetic of oscript:
+// - a float read from a JSON message is converted to its 15 significant digits representation
+// - the result of every multiplication and division is rounded to 15 significant digits,
+// ties to even
+// - Round rounds to the nearest integer, ties away from zero like math.Round
+//
+// The prices of the orders and trades are decimals of at most 15 significant digits, the
+// numbers the exchange AA reads. The engine sorts and compares them exactly and computes the
+// traded amounts with them, so that the amounts are the ones the exchange AA computes when the
+// trades are settled.
+// In JSON and in the database a price is the float64 nearest to it. A float64 holds 15
+// significant digits without loss and the conversion keeps their order, so the database sorts
+// and compares the prices like the decimals and reading them back gives the same decimals.
+// The zero value is 0.
+type Decimal struct {
+	// decimals are compared with Cmp, == does not compile
+	_ [0]func()
+	r *big.Rat
+}
+
+// NewDecimal returns the decimal oscript reads from a float
+func NewDecimal(f float64) Decimal {
+	r, ok := new(big.Rat).SetString(strconv.FormatFloat(f, 'g', OscriptPrecision, 64))
+	if !ok {
+		panic(fmt.Sprintf("invalid decimal: %v", f))
+	}
+
+	return newDecimal(r)
+}
+
+// NewDecimalFromInt returns the decimal holding the given integer
+func NewDecimalFromInt(i int64) Decimal {
+	return newDecimal(new(big.Rat).SetInt64(i))
+}
+
+// ParseDecimal returns the decimal oscript reads from a number literal
+func ParseDecimal(s string) (Decimal, error) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return Decimal{}, errors.New("invalid decimal: " + s)
+	}
+
+	return NewDecimal(f), nil
+}
+
+// newDecimal wraps r, 0 is always the zero value so that equal decimals are deeply equal
+func newDecimal(r *big.Rat) Decimal {
+	if r.Sign() == 0 {
+		return Decimal{}
+	}
+
+	return Decimal{r: r}
+}
+
+func (d Decimal) rat() *big.Rat {
+	if d.r == nil {
+		return new(big.Rat)
+	}
+
+	return d.r
+}
+
+// Mul returns d * e rounded to 15 significant digits
+func (d Decimal) Mul(e Decimal) Decimal {
+	r := new(big.Rat).Mul(d.rat(), e.rat())
+	return newDecimal(roundSignificant(r, OscriptPrecision))
+}
+
+// Quo returns d / e rounded to 15 significant digits. It panics if e is zero.
+func (d Decimal) Quo(e Decimal) Decimal {
+	r := new(big.Rat).Quo(d.rat(), e.rat())
+	return newDecimal(roundSignificant(r, OscriptPrecision))
+}
+
+// Round returns the nearest integer, ties away from zero
+func (d Decimal) Round() int64 {
+	return roundHalfAway(d.rat().Num(), d.rat().Denom()).Int64()
+}
+
+// Cmp compares d and e and returns -1, 0 or +1
+func (d Decimal) Cmp(e Decimal) int {
+	return d.rat().Cmp(e.rat())
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of d
+func (d Decimal) Sign() int {
+	return d.rat().Sign()
+}
+
+// IsZero tells whether d is 0
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Float64 returns the float64 nearest to d
+func (d Decimal) Float64() float64 {
+	f, _ := d.rat().Float64()
+	return f
+}
+
+func (d Decimal) String() string {
+	return strconv.FormatFloat(d.Float64(), 'g', OscriptPrecision, 64)
+}
+
+// MarshalJSON writes the decimal as a JSON number
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON reads the decimal from a JSON number, null is 0
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		*d = Decimal{}
+		return nil
+	}
+
+	dec, err := ParseDecimal(string(b))
+	if err != nil {
+		return err
+	}
+
+	*d = dec
+	return nil
+}
+
+// GetBSON stores the decimal as the float64 nearest to it
+func (d Decimal) GetBSON() (interface{}, error) {
+	return d.Float64(), nil
+}
+
+// SetBSON reads the decimal from a number of the database
+func (d *Decimal) SetBSON(raw bson.Raw) error {
+	var v interface{}
+	err := raw.Unmarshal(&v)
+	if err != nil {
+		return err
+	}
+
+	switch n := v.(type) {
+	case nil:
+		*d = Decimal{}
+	case float64:
+		*d = NewDecimal(n)
+	case int:
+		*d = NewDecimalFromInt(int64(n))
+	case int64:
+		*d = NewDecimalFromInt(n)
+	default:
+		return fmt.Errorf("invalid decimal: %v", v)
+	}
+
+	return nil
+}
+
+// MulPrice returns round(amount * price) computed with the oscript arithmetic
+func MulPrice(amount int64, price Decimal) int64 {
+	return NewDecimalFromInt(amount).Mul(price).Round()
+}
+
+// DivPrice returns round(amount / price) computed with the oscript arithmetic
+func DivPrice(amount int64, price Decimal) int64 {
+	return NewDecimalFromInt(amount).Quo(price).Round()
+}
+
+// roundHalfAway returns num / den rounded to the nearest integer, ties away from zero.
+// den must be positive.
+func roundHalfAway(num *big.Int, den *big.Int) *big.Int {
+	q, m := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	// the remainder has the sign of num, the rounding goes away from zero
+	twice := new(big.Int).Lsh(new(big.Int).Abs(m), 1)
+	if twice.Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(int64(num.Sign())))
+	}
+
+	return q
+}
+
+// roundHalfEven returns num / den rounded to the nearest integer, ties to even.
+// den must be positive.
+func roundHalfEven(num *big.Int, den *big.Int) *big.Int {
+	q, m := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	// the remainder has the sign of num, the rounding goes away from zero
+	step := big.NewInt(int64(num.Sign()))
+	twice := new(big.Int).Lsh(new(big.Int).Abs(m), 1)
+
+	switch twice.Cmp(den) {
+	case 1:
+		q.Add(q, step)
+	case 0:
+		if q.Bit(0) == 1 {
+			q.Add(q, step)
+		}
+	}
+
+	return q
+}
+
+// roundSignificant rounds r to the given number of significant digits, ties to even
+func roundSignificant(r *big.Rat, digits int) *big.Rat {
+	if r.Sign() == 0 {
+		return new(big.Rat)
+	}
+
+	num := new(big.Int).Abs(r.Num())
+	den := r.Denom()
+
+	// |r| is within a factor 10 of 10^e, scale it to get an integer part of the given
+	// number of digits
+	e := len(num.String()) - len(den.String())
+	lower := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits-1)), nil)
+	upper := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)
+
+	var scaled *big.Rat
+	for {
+		scaled = new(big.Rat).SetFrac(num, den)
+		shift := digits - 1 - e
+		pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil)
+		if shift >= 0 {
+			scaled.Mul(scaled, new(big.Rat).SetInt(pow))
+		} else {
+			scaled.Quo(scaled, new(big.Rat).SetInt(pow))
+		}
+
+		intPart := new(big.Int).Quo(scaled.Num(), scaled.Denom())
+		if intPart.Cmp(upper) >= 0 {
+			e++
+		} else if intPart.Cmp(lower) < 0 {
+			e--
+		} else {
+			break
+		}
+	}
+
+	res := new(big.Rat).SetInt(roundHalfEven(scaled.Num(), scaled.Denom()))
+	shift := digits - 1 - e
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(abs(shift))), nil)
+	if shift >= 0 {
+		res.Quo(res, new(big.Rat).SetInt(pow))
+	} else {
+		res.Mul(res, new(big.Rat).SetInt(pow))
+	}
+
+	if r.Sign() < 0 {
+		res.Neg(res)
+	}
+
+	return res
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+
+	return i
+}
@@ -0,0 +1,120 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecimalFromFloat(t *testing.T) {
+	// 0.1 + 0.2 is 0.30000000000000004 as a float, oscript reads it as 0.3
+	assert.Equal(t, 0, NewDecimal(0.1+0.2).Cmp(NewDecimal(0.3)))
+	assert.Equal(t, "0.3", NewDecimal(0.1+0.2).String())
+	assert.Equal(t, int64(3), NewDecimal(0.3).Mul(NewDecimalFromInt(10)).Round())
+}
+
+func TestDecimalSignificantDigits(t *testing.T) {
+	// the product is rounded to 15 significant digits before being rounded to an integer
+	price := NewDecimal(1.00000000000001)
+	assert.Equal(t, "5.00000000000005", NewDecimalFromInt(5).Mul(price).String())
+	assert.Equal(t, int64(5000000000000050), MulPrice(5e14*10, price))
+
+	// ties are rounded to even
+	assert.Equal(t, "1.50000000000002", NewDecimal(1.00000000000001).Mul(NewDecimal(1.5)).String())
+	assert.Equal(t, "1.50000000000004", NewDecimal(1.00000000000003).Mul(NewDecimal(1.5)).String())
+	assert.Equal(t, "-1.50000000000004", NewDecimal(-1.00000000000003).Mul(NewDecimal(1.5)).String())
+	assert.Equal(t, 0, NewDecimal(3.00000000000001).Quo(NewDecimalFromInt(2)).Cmp(NewDecimal(1.5)))
+	assert.Equal(t, "1.50000000000002", NewDecimal(3.00000000000003).Quo(NewDecimalFromInt(2)).String())
+	assert.Equal(t, int64(3), NewDecimalFromInt(5).Quo(NewDecimalFromInt(2)).Round())
+	assert.Equal(t, int64(4), NewDecimalFromInt(7).Quo(NewDecimalFromInt(2)).Round())
+	assert.Equal(t, int64(-3), NewDecimalFromInt(-5).Quo(NewDecimalFromInt(2)).Round())
+
+	// 1/3 has 15 significant digits
+	assert.Equal(t, "0.333333333333333", NewDecimalFromInt(1).Quo(NewDecimalFromInt(3)).String())
+}
+
+func TestDecimalRound(t *testing.T) {
+	// the amounts are rounded like math.Round, ties away from zero
+	assert.Equal(t, int64(3), NewDecimal(2.5).Round())
+	assert.Equal(t, int64(4), NewDecimal(3.5).Round())
+	assert.Equal(t, int64(3), NewDecimal(2.51).Round())
+	assert.Equal(t, int64(-3), NewDecimal(-2.5).Round())
+	assert.Equal(t, int64(-3), NewDecimal(-2.51).Round())
+	assert.Equal(t, int64(0), NewDecimal(0.4).Round())
+
+	assert.Equal(t, int64(7), MulPrice(2, NewDecimal(3.5)))
+	assert.Equal(t, int64(4), DivPrice(7, NewDecimal(2)))
+	assert.Equal(t, int64(3), DivPrice(5, NewDecimal(2)))
+	assert.Equal(t, int64(3), MulPrice(5, NewDecimal(0.5)))
+	assert.Equal(t, int64(-3), MulPrice(-5, NewDecimal(0.5)))
+}
+
+func TestDecimalZero(t *testing.T) {
+	assert.Equal(t, Decimal{}, NewDecimal(0))
+	assert.Equal(t, Decimal{}, NewDecimalFromInt(0))
+	assert.Equal(t, Decimal{}, NewDecimal(1.5).Mul(Decimal{}))
+	assert.True(t, Decimal{}.IsZero())
+	assert.Equal(t, "0", Decimal{}.String())
+}
+
+func TestDecimalJSON(t *testing.T) {
+	for _, price := range []float64{0, 1.5, 0.1 + 0.2, 1.23456789012345e-9, 98765432109876.5, -2.5} {
+		d := NewDecimal(price)
+
+		encoded, err := json.Marshal(d)
+		assert.Nil(t, err)
+
+		decoded := Decimal{}
+		assert.Nil(t, json.Unmarshal(encoded, &decoded))
+		assert.Equal(t, 0, d.Cmp(decoded), string(encoded))
+		assert.Equal(t, d, decoded)
+	}
+
+	d := NewDecimal(1)
+	assert.Nil(t, json.Unmarshal([]byte("null"), &d))
+	assert.True(t, d.IsZero())
+	assert.NotNil(t, json.Unmarshal([]byte(`"1.5"`), &d))
+}
+
+func TestDecimalBSON(t *testing.T) {
+	type record struct {
+		Price Decimal `bson:"price"`
+	}
+
+	for _, price := range []float64{0, 1.5, 0.1 + 0.2, 1.23456789012345e-9, 98765432109876.5} {
+		encoded, err := bson.Marshal(record{NewDecimal(price)})
+		assert.Nil(t, err)
+
+		// the database holds a plain number, sorted and compared by value
+		raw := bson.M{}
+		assert.Nil(t, bson.Unmarshal(encoded, &raw))
+		assert.Equal(t, NewDecimal(price).Float64(), raw["price"])
+
+		decoded := record{}
+		assert.Nil(t, bson.Unmarshal(encoded, &decoded))
+		assert.Equal(t, NewDecimal(price), decoded.Price)
+	}
+
+	decoded := record{}
+	encoded, _ := bson.Marshal(bson.M{"price": 12})
+	assert.Nil(t, bson.Unmarshal(encoded, &decoded))
+	assert.Equal(t, NewDecimalFromInt(12), decoded.Price)
+}
+
+func TestDecimalFloat64KeepsOrder(t *testing.T) {
+	// neighbouring decimals of 15 significant digits stay ordered and distinct as floats
+	prices := []Decimal{
+		NewDecimal(0.999999999999999),
+		NewDecimal(1),
+		NewDecimal(1.00000000000001),
+		NewDecimal(1.00000000000002),
+		NewDecimal(999999999999999),
+	}
+
+	for i := 1; i < len(prices); i++ {
+		assert.True(t, prices[i-1].Float64() < prices[i].Float64())
+		assert.Equal(t, prices[i], NewDecimal(prices[i].Float64()))
+	}
+}
@@ -69,6 +69,35 @@ func (m *Matches) AppendMatch(mo *Order, t *Trade) {
 	m.Trades = append(m.Trades, t)
 }
 
+// OrderHashes returns the hashes of the taker order and of the maker orders of the matches
+func (m *Matches) OrderHashes() []string {
+	hashes := []string{m.TakerOrder.Hash}
+	for _, mo := range m.MakerOrders {
+		hashes = append(hashes, mo.Hash)
+	}
+
+	return hashes
+}
+
+// CompatibleWith tells whether the matches can be settled by the same trigger of the exchange AA
+// as the other matches: both must be on the same pair and have no order in common, so that
+// the trades of an order are never settled twice within a trigger
+func (m *Matches) CompatibleWith(other *Matches) bool {
+	if m.TakerOrder.PairName != other.TakerOrder.PairName {
+		return false
+	}
+
+	for _, h := range m.OrderHashes() {
+		for _, oh := range other.OrderHashes() {
+			if h == oh {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
 func (m *Matches) Validate() error {
 	if len(m.Trades) == 0 {
 		return errors.New("Matches should contain at least one trade")
@@ -114,6 +143,9 @@ type EngineResponse struct {
 	RecoveredOrders   *[]*Order `json:"recoveredOrders,omitempty"`
 	InvalidatedOrders *[]*Order `json:"invalidatedOrders,omitempty"`
 	CancelledTrades   *[]*Trade `json:"cancelledTrades,omitempty"`
+
+	// the order replaced by the order of an ORDER_AMENDED response
+	AmendedOrder *Order `json:"amendedOrder,omitempty"`
 }
 
 func (r *EngineResponse) AppendMatch(mo *Order, t *Trade) {
@@ -0,0 +1,26 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesCompatibleWith(t *testing.T) {
+	newMatches := func(pair, taker string, makers ...string) *Matches {
+		m := &Matches{TakerOrder: &Order{Hash: taker, PairName: pair}}
+		for _, h := range makers {
+			m.AppendMatch(&Order{Hash: h, PairName: pair}, &Trade{})
+		}
+
+		return m
+	}
+
+	m := newMatches("GBYTE/USDC", "taker1", "maker1", "maker2")
+
+	assert.True(t, m.CompatibleWith(newMatches("GBYTE/USDC", "taker2", "maker3")))
+	assert.False(t, m.CompatibleWith(newMatches("GBYTE/BTC", "taker2", "maker3")))
+	assert.False(t, m.CompatibleWith(newMatches("GBYTE/USDC", "taker2", "maker2")))
+	assert.False(t, m.CompatibleWith(newMatches("GBYTE/USDC", "maker1", "maker3")))
+	assert.Equal(t, []string{"taker1", "maker1", "maker2"}, m.OrderHashes())
+}
@@ -0,0 +1,79 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+// FeeVolumePeriod is the period over which the trading volume of a user is summed to find their
+// fee tier
+const FeeVolumePeriod = 30 * 24 * time.Hour
+
+// FeeTier lowers the fees of the users whose trading volume on the pair over the last 30 days
+// reached MinVolume, expressed in the quote token
+type FeeTier struct {
+	MinVolume int64   `json:"minVolume" bson:"minVolume"`
+	MakerFee  float64 `json:"makerFee" bson:"makerFee"`
+	TakerFee  float64 `json:"takerFee" bson:"takerFee"`
+}
+
+// FeeSchedule holds the matcher fee rates of a pair, or the default rates of the pairs without
+// schedule of their own when PairName is empty. The rates are fractions of the traded amounts.
+type FeeSchedule struct {
+	PairName     string    `json:"pairName" bson:"pairName"`
+	MakerFee     float64   `json:"makerFee" bson:"makerFee"`
+	TakerFee     float64   `json:"takerFee" bson:"takerFee"`
+	AffiliateFee float64   `json:"affiliateFee" bson:"affiliateFee"`
+	Tiers        []FeeTier `json:"tiers" bson:"tiers"`
+	UpdatedAt    time.Time `json:"updatedAt" bson:"updatedAt"`
+}
+
+func (s *FeeSchedule) Validate() error {
+	if !validFeeRate(s.MakerFee) || !validFeeRate(s.TakerFee) || !validFeeRate(s.AffiliateFee) {
+		return errors.New("Fee rates should be between 0 and 1")
+	}
+
+	for i, t := range s.Tiers {
+		if !validFeeRate(t.MakerFee) || !validFeeRate(t.TakerFee) {
+			return errors.New("Fee rates should be between 0 and 1")
+		}
+
+		if t.MinVolume <= 0 {
+			return errors.New("Fee tier volumes should be positive")
+		}
+
+		if i > 0 && t.MinVolume <= s.Tiers[i-1].MinVolume {
+			return errors.New("Fee tiers should be sorted by increasing volume")
+		}
+	}
+
+	return nil
+}
+
+// Rates returns the maker and taker fee rates of a user with the given trading volume
+func (s *FeeSchedule) Rates(volume int64) (float64, float64) {
+	makerFee, takerFee := s.MakerFee, s.TakerFee
+	for _, t := range s.Tiers {
+		if volume < t.MinVolume {
+			break
+		}
+
+		makerFee, takerFee = t.MakerFee, t.TakerFee
+	}
+
+	return makerFee, takerFee
+}
+
+func validFeeRate(rate float64) bool {
+	return rate >= 0 && rate < 1
+}
+
+// UserFees are the fee rates applied to a user on a pair, given their trading volume
+type UserFees struct {
+	Address      string  `json:"address"`
+	PairName     string  `json:"pairName"`
+	Volume       int64   `json:"volume"`
+	MakerFee     float64 `json:"makerFee"`
+	TakerFee     float64 `json:"takerFee"`
+	AffiliateFee float64 `json:"affiliateFee"`
+}
@@ -0,0 +1,116 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeeScheduleRates(t *testing.T) {
+	s := &FeeSchedule{
+		MakerFee: 0.002,
+		TakerFee: 0.003,
+		Tiers: []FeeTier{
+			FeeTier{MinVolume: 1000, MakerFee: 0.001, TakerFee: 0.002},
+			FeeTier{MinVolume: 10000, MakerFee: 0, TakerFee: 0.001},
+		},
+	}
+
+	assert.Nil(t, s.Validate())
+
+	cases := []struct {
+		volume   int64
+		makerFee float64
+		takerFee float64
+	}{
+		{0, 0.002, 0.003},
+		{999, 0.002, 0.003},
+		{1000, 0.001, 0.002},
+		{50000, 0, 0.001},
+	}
+
+	for _, c := range cases {
+		makerFee, takerFee := s.Rates(c.volume)
+		assert.Equal(t, c.makerFee, makerFee, c.volume)
+		assert.Equal(t, c.takerFee, takerFee, c.volume)
+	}
+}
+
+func TestFeeScheduleValidate(t *testing.T) {
+	assert.NotNil(t, (&FeeSchedule{TakerFee: 1}).Validate())
+	assert.NotNil(t, (&FeeSchedule{MakerFee: -0.1}).Validate())
+	assert.NotNil(t, (&FeeSchedule{Tiers: []FeeTier{FeeTier{MinVolume: 0}}}).Validate())
+
+	unsorted := &FeeSchedule{Tiers: []FeeTier{FeeTier{MinVolume: 1000}, FeeTier{MinVolume: 100}}}
+	assert.NotNil(t, unsorted.Validate())
+}
+
+func TestOrderCoversMatcherFeeRate(t *testing.T) {
+	o := &Order{
+		OriginalOrder: map[string]interface{}{
+			"signed_message": map[string]interface{}{
+				"sell_asset":        "base",
+				"buy_asset":         "usd",
+				"sell_amount":       float64(100000),
+				"price":             0.5,
+				"matcher_fee_asset": "base",
+				"matcher_fee":       float64(100),
+			},
+		},
+	}
+
+	covers := func(rate float64) bool {
+		covered, err := o.CoversMatcherFeeRate(rate)
+		assert.Nil(t, err)
+		return covered
+	}
+
+	assert.True(t, covers(0.001))
+	assert.True(t, covers(0.00100999))
+	assert.False(t, covers(0.002))
+
+	// fee paid in the bought asset, on 50000 usd
+	o.OriginalOrder["signed_message"].(map[string]interface{})["matcher_fee_asset"] = "usd"
+	assert.True(t, covers(0.002))
+	assert.False(t, covers(0.003))
+
+	o.OriginalOrder["signed_message"].(map[string]interface{})["matcher_fee_asset"] = "other"
+	assert.False(t, covers(0))
+}
+
+func TestOrderCoversMatcherFeeRateInvalid(t *testing.T) {
+	invalid := []map[string]interface{}{
+		{},
+		{"signed_message": "message"},
+		{"signed_message": map[string]interface{}{"sell_asset": "base"}},
+		{"signed_message": map[string]interface{}{
+			"sell_asset":        "base",
+			"buy_asset":         "usd",
+			"sell_amount":       "100000",
+			"price":             0.5,
+			"matcher_fee_asset": "base",
+			"matcher_fee":       float64(100),
+		}},
+		{"signed_message": map[string]interface{}{
+			"sell_asset":        "base",
+			"buy_asset":         "usd",
+			"sell_amount":       float64(100000),
+			"price":             0.5,
+			"matcher_fee_asset": 1,
+			"matcher_fee":       float64(100),
+		}},
+	}
+
+	for _, originalOrder := range invalid {
+		o := &Order{OriginalOrder: originalOrder}
+		assert.NotPanics(t, func() {
+			covered, err := o.CoversMatcherFeeRate(0)
+			assert.Error(t, err, "%v", originalOrder)
+			assert.False(t, covered)
+		})
+	}
+
+	covered, err := (&Order{}).CoversMatcherFeeRate(0)
+	assert.Error(t, err)
+	assert.False(t, covered)
+}
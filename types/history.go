@@ -0,0 +1,82 @@
+package types
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// NextCursorHeader is the header of the responses of the histories holding the cursor of the next
+// page, absent after the last page
+const NextCursorHeader = "X-NEXT-CURSOR"
+
+// HistoryQuery selects a page of the orders or trades of a user, newest first. The zero values
+// of the filters select everything, a zero limit selects all the following orders or trades.
+type HistoryQuery struct {
+	Address  string
+	PairName string
+	Side     string
+	Status   string
+	From     time.Time
+	To       time.Time
+	Cursor   *Cursor
+	Limit    int
+}
+
+func (q *HistoryQuery) Validate() error {
+	if q.Side != "" && q.Side != "BUY" && q.Side != "SELL" {
+		return errors.New("Side should be BUY or SELL")
+	}
+
+	if q.Limit < 0 {
+		return errors.New("Limit should be positive")
+	}
+
+	if !q.From.IsZero() && !q.To.IsZero() && q.To.Before(q.From) {
+		return errors.New("The end of the time range should follow its beginning")
+	}
+
+	return nil
+}
+
+// Cursor points at the last order or trade of a page of history. The next page starts with the
+// orders or trades created before it, the ties being broken by their id.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        bson.ObjectId
+}
+
+// NewCursor returns the cursor of the page following the given order or trade
+func NewCursor(createdAt time.Time, id bson.ObjectId) *Cursor {
+	return &Cursor{CreatedAt: createdAt, ID: id}
+}
+
+// Encode returns the opaque string passed by the clients to get the next page
+func (c *Cursor) Encode() string {
+	s := strconv.FormatInt(c.CreatedAt.UnixNano(), 10) + ":" + c.ID.Hex()
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// DecodeCursor parses a cursor encoded by Encode
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.New("Invalid cursor")
+	}
+
+	parts := strings.Split(string(b), ":")
+	if len(parts) != 2 || !bson.IsObjectIdHex(parts[1]) {
+		return nil, errors.New("Invalid cursor")
+	}
+
+	ns, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, errors.New("Invalid cursor")
+	}
+
+	return NewCursor(time.Unix(0, ns), bson.ObjectIdHex(parts[1])), nil
+}
@@ -0,0 +1,30 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorEncoding(t *testing.T) {
+	c := NewCursor(time.Unix(1500000000, 123000000), bson.ObjectIdHex("537f700b537461b70c5f0004"))
+
+	decoded, err := DecodeCursor(c.Encode())
+	assert.Nil(t, err)
+	assert.True(t, c.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, c.ID, decoded.ID)
+
+	for _, s := range []string{"", "not a cursor", c.Encode()[1:]} {
+		_, err := DecodeCursor(s)
+		assert.NotNil(t, err, s)
+	}
+}
+
+func TestHistoryQueryValidate(t *testing.T) {
+	assert.Nil(t, (&HistoryQuery{Address: "ADDRESS"}).Validate())
+	assert.NotNil(t, (&HistoryQuery{Side: "LONG"}).Validate())
+	assert.NotNil(t, (&HistoryQuery{Limit: -1}).Validate())
+	assert.NotNil(t, (&HistoryQuery{From: time.Unix(2, 0), To: time.Unix(1, 0)}).Validate())
+}
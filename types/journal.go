@@ -0,0 +1,50 @@
+package types
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// JournalEntry is an input of the matching engine (NEW_ORDER, ADD_ORDER, CANCEL_ORDER,
+// AMEND_ORDER or RESTORE_ORDER) recorded in the engine journal before being processed. The
+// entry is completed with the engine responses once the input is processed.
+type JournalEntry struct {
+	ID       bson.ObjectId `json:"id" bson:"_id"`
+	PairCode string        `json:"pairCode" bson:"pairCode"`
+	Sequence int64         `json:"sequence" bson:"sequence"`
+	Type     string        `json:"type" bson:"type"`
+	Order    *Order        `json:"order" bson:"order"`
+
+	// the ID of the engine message of the input, if any
+	MessageID string `json:"messageId" bson:"messageId"`
+
+	// the orders found cancelled by the order service while the input was processed
+	CancelledOrders []string          `json:"cancelledOrders" bson:"cancelledOrders"`
+	Responses       []*EngineResponse `json:"responses" bson:"responses"`
+	Processed       bool              `json:"processed" bson:"processed"`
+
+	// the engine clock when the input was received, used again when the input is replayed
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
+
+// EngineSnapshot is the state of the orderbook of a pair after the journal entry with
+// the given sequence was processed
+type EngineSnapshot struct {
+	ID       bson.ObjectId `json:"id" bson:"_id"`
+	PairCode string        `json:"pairCode" bson:"pairCode"`
+	Sequence int64         `json:"sequence" bson:"sequence"`
+
+	// resting orders by price-time priority
+	Orders []*Order `json:"orders" bson:"orders"`
+
+	TriggerOrders   []*Order `json:"triggerOrders" bson:"triggerOrders"`
+	LastPrice       Decimal  `json:"lastPrice" bson:"lastPrice"`
+	ReleasedOrders  []string `json:"releasedOrders" bson:"releasedOrders"`
+	CancelledOrders []string `json:"cancelledOrders" bson:"cancelledOrders"`
+
+	// the IDs of the engine messages of the journal entries the snapshot replaces
+	Messages []string `json:"messages" bson:"messages"`
+
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+}
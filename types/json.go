@@ -0,0 +1,110 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+// jsonFields reads the fields of a decoded JSON object into typed values. A field of the wrong
+// type is not read and the first such error is kept in err. Missing and null fields are skipped.
+type jsonFields struct {
+	fields map[string]interface{}
+	err    error
+}
+
+func (f *jsonFields) invalid(key string) {
+	if f.err == nil {
+		f.err = errors.New("invalid type of " + key)
+	}
+}
+
+func (f *jsonFields) string(key string, dst *string) {
+	if f.fields[key] == nil {
+		return
+	}
+
+	v, ok := f.fields[key].(string)
+	if !ok {
+		f.invalid(key)
+		return
+	}
+
+	*dst = v
+}
+
+func (f *jsonFields) float(key string, dst *float64) {
+	if f.fields[key] == nil {
+		return
+	}
+
+	v, ok := f.fields[key].(float64)
+	if !ok {
+		f.invalid(key)
+		return
+	}
+
+	*dst = v
+}
+
+// decimal reads a number as oscript reads it (see Decimal)
+func (f *jsonFields) decimal(key string, dst *Decimal) {
+	var v float64
+	f.float(key, &v)
+	if f.fields[key] != nil && f.err == nil {
+		*dst = NewDecimal(v)
+	}
+}
+
+func (f *jsonFields) int(key string, dst *int64) {
+	if f.fields[key] == nil {
+		return
+	}
+
+	v, ok := f.fields[key].(float64)
+	if !ok {
+		f.invalid(key)
+		return
+	}
+
+	*dst = int64(v)
+}
+
+func (f *jsonFields) bool(key string, dst *bool) {
+	if f.fields[key] == nil {
+		return
+	}
+
+	v, ok := f.fields[key].(bool)
+	if !ok {
+		f.invalid(key)
+		return
+	}
+
+	*dst = v
+}
+
+func (f *jsonFields) object(key string, dst *map[string]interface{}) {
+	if f.fields[key] == nil {
+		return
+	}
+
+	v, ok := f.fields[key].(map[string]interface{})
+	if !ok {
+		f.invalid(key)
+		return
+	}
+
+	*dst = v
+}
+
+// time reads an RFC 3339 date, an unparsable date is read as the zero time
+func (f *jsonFields) time(key string, dst *time.Time) {
+	var v string
+	f.string(key, &v)
+	if v == "" {
+		return
+	}
+
+	t, _ := time.Parse(time.RFC3339Nano, v)
+	*dst = t
+}
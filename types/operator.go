@@ -1,6 +1,9 @@
 package types
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type OperatorMessage struct {
 	MessageType string
@@ -16,6 +19,17 @@ func (m *OperatorMessage) String() string {
 	return fmt.Sprintf("%v: %v", m.MessageType, m.Matches.String())
 }
 
+// TradesNotSentError is returned by the providers when the trades of a trade execution order
+// were certainly not sent: the node could not be reached or it rejected the order. Only these
+// errors are safe to retry.
+type TradesNotSentError struct {
+	Err error
+}
+
+func (e *TradesNotSentError) Error() string {
+	return e.Err.Error()
+}
+
 type PendingTradeBatch struct {
 	Matches *Matches
 }
@@ -25,3 +39,24 @@ type ExchangeLogError struct {
 	MakerOrderHash string
 	TakerOrderHash string
 }
+
+// TriggerUnitStatus is the status of a trade trigger unit as reported by the Obyte node.
+// Status is UNKNOWN when the node does not know the unit, PENDING while the exchange AA
+// has not responded yet and DONE once it responded, Bounced and Error telling whether the
+// trade was rejected by the AA and why.
+type TriggerUnitStatus struct {
+	Unit    string `json:"unit"`
+	Status  string `json:"status"`
+	Bounced bool   `json:"bounced"`
+	Error   string `json:"error"`
+}
+
+// NodeConnectionState is the state of the websocket receiving the events of the Obyte node.
+// Since is the time of the last connection or disconnection and LastEventId the sequence
+// number of the last event received, from which the events are resumed after a reconnection.
+type NodeConnectionState struct {
+	Connected   bool      `json:"connected"`
+	Since       time.Time `json:"since"`
+	Reconnects  int       `json:"reconnects"`
+	LastEventId int64     `json:"lastEventId"`
+}
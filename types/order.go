@@ -23,12 +23,21 @@ type Order struct {
 	Status              string                 `json:"status" bson:"status"`
 	Side                string                 `json:"side" bson:"side"`
 	Hash                string                 `json:"hash" bson:"hash"`
-	Price               float64                `json:"price" bson:"price"`
+	Price               Decimal                `json:"price" bson:"price"`
 	Amount              int64                  `json:"amount" bson:"amount"`
 	FilledAmount        int64                  `json:"filledAmount" bson:"filledAmount"`
 	RemainingSellAmount int64                  `json:"remainingSellAmount" bson:"remainingSellAmount"`
+	TimeInForce         string                 `json:"timeInForce" bson:"timeInForce"`
+	PostOnly            bool                   `json:"postOnly" bson:"postOnly"`
+	Type                string                 `json:"type" bson:"type"`
+	StopPrice           Decimal                `json:"stopPrice" bson:"stopPrice"`
+	Triggered           bool                   `json:"triggered" bson:"triggered"`
+	MaxSlippage         float64                `json:"maxSlippage" bson:"maxSlippage"`
+	DisplayAmount       int64                  `json:"displayAmount" bson:"displayAmount"`
+	VisibleAmount       int64                  `json:"visibleAmount" bson:"visibleAmount"`
 	PairName            string                 `json:"pairName" bson:"pairName"`
 	OriginalOrder       map[string]interface{} `json:"originalOrder" bson:"originalOrder"`
+	AmendedOrderHash    string                 `json:"amendedOrderHash" bson:"amendedOrderHash"`
 	CreatedAt           time.Time              `json:"createdAt" bson:"createdAt"`
 	UpdatedAt           time.Time              `json:"updatedAt" bson:"updatedAt"`
 }
@@ -55,7 +64,8 @@ func (o *Order) Validate() error {
 		return errors.New("Order 'amount' parameter is required")
 	}
 
-	if o.Price == 0 {
+	// market orders can be bounded by their maximum slippage only
+	if o.Price.IsZero() && !(o.IsMarketOrder() && o.MaxSlippage > 0) {
 		return errors.New("Order 'price' parameter is required")
 	}
 
@@ -67,10 +77,57 @@ func (o *Order) Validate() error {
 		return errors.New("Order 'amount' parameter should be strictly positive")
 	}
 
-	if o.Price <= 0 {
+	if o.Price.Sign() < 0 {
 		return errors.New("Order 'price' parameter should be strictly positive")
 	}
 
+	if o.TimeInForce != "" && o.TimeInForce != "GTC" && o.TimeInForce != "IOC" && o.TimeInForce != "FOK" {
+		return errors.New("Order 'timeInForce' should be 'GTC', 'IOC' or 'FOK'")
+	}
+
+	if o.PostOnly && (o.TimeInForce == "IOC" || o.TimeInForce == "FOK") {
+		return errors.New("Post-only orders cannot be 'IOC' or 'FOK'")
+	}
+
+	switch o.Type {
+	case "", "LIMIT":
+	case "STOP_LOSS", "TAKE_PROFIT":
+		if o.PostOnly {
+			return errors.New("Post-only orders should be 'STOP_LOSS_LIMIT' or 'TAKE_PROFIT_LIMIT' orders")
+		}
+	case "STOP_LOSS_LIMIT", "TAKE_PROFIT_LIMIT":
+	case "MARKET":
+		if o.PostOnly {
+			return errors.New("Market orders cannot be post-only")
+		}
+
+		if o.TimeInForce == "GTC" {
+			return errors.New("Market orders should be 'IOC' or 'FOK'")
+		}
+	default:
+		return errors.New("Order 'type' should be 'LIMIT', 'MARKET', 'STOP_LOSS', 'STOP_LOSS_LIMIT', 'TAKE_PROFIT' or 'TAKE_PROFIT_LIMIT'")
+	}
+
+	if o.MaxSlippage != 0 && !o.IsMarketOrder() {
+		return errors.New("Order 'maxSlippage' parameter is only allowed for market orders")
+	}
+
+	if o.MaxSlippage < 0 || o.MaxSlippage >= 1 {
+		return errors.New("Order 'maxSlippage' parameter should be between 0 and 1")
+	}
+
+	if o.DisplayAmount < 0 {
+		return errors.New("Order 'displayAmount' parameter should be positive")
+	}
+
+	if o.IsIceberg() && (o.IsMarketOrder() || o.TimeInForce == "IOC" || o.TimeInForce == "FOK") {
+		return errors.New("Orders with a 'displayAmount' should be able to rest in the orderbook")
+	}
+
+	if o.IsTriggerOrder() && o.StopPrice.Sign() <= 0 {
+		return errors.New("Order 'stopPrice' parameter should be strictly positive")
+	}
+
 	return nil
 }
 
@@ -98,12 +155,153 @@ func (o *Order) Process(p *Pair) error {
 	if o.RemainingSellAmount == 0 {
 		o.RemainingSellAmount = o.SellAmount(p)
 	}
+	if o.TimeInForce == "" && o.IsMarketOrder() {
+		o.TimeInForce = "IOC"
+	}
+	if o.TimeInForce == "" {
+		o.TimeInForce = "GTC"
+	}
+	if o.Type == "" {
+		o.Type = "LIMIT"
+	}
 	o.PairName = p.Name()
 	o.CreatedAt = time.Now()
 	o.UpdatedAt = time.Now()
 	return nil
 }
 
+// IsMarketOrder returns true for market orders, which sweep the opposite side of the orderbook
+// up to their worst price and never rest in the orderbook
+func (o *Order) IsMarketOrder() bool {
+	return o.Type == "MARKET"
+}
+
+// WorstPrice returns the worst price a market order accepts given the best price of the opposite
+// side of the orderbook: the best price moved by the maximum slippage, bounded by the price of
+// the order if any. Limit orders are matched up to their price.
+func (o *Order) WorstPrice(bestPrice Decimal) Decimal {
+	if !o.IsMarketOrder() || o.MaxSlippage == 0 || bestPrice.IsZero() {
+		return o.Price
+	}
+
+	if o.Side == "BUY" {
+		price := bestPrice.Mul(NewDecimal(1 + o.MaxSlippage))
+		if o.Price.Sign() > 0 && o.Price.Cmp(price) < 0 {
+			return o.Price
+		}
+
+		return price
+	}
+
+	price := bestPrice.Mul(NewDecimal(1 - o.MaxSlippage))
+	if o.Price.Cmp(price) > 0 {
+		return o.Price
+	}
+
+	return price
+}
+
+// WorstCaseSellAmount returns the largest amount an order can sell: the amount of base asset
+// of sell orders and the quote amount at the worst price of buy orders, bounded by the signed
+// sell amount
+func (o *Order) WorstCaseSellAmount(p *Pair) int64 {
+	if o.Side == "SELL" {
+		return o.Amount
+	}
+
+	sellAmount := o.SellAmount(p)
+	worstCase := MulPrice(o.Amount, o.Price)
+	if worstCase < sellAmount {
+		return worstCase
+	}
+
+	return sellAmount
+}
+
+// IsIceberg returns true for orders showing only a slice of their amount in the orderbook
+func (o *Order) IsIceberg() bool {
+	return o.DisplayAmount > 0
+}
+
+// DisplayedAmount returns the remaining amount shown in the orderbook, which is the visible
+// slice of iceberg orders
+func (o *Order) DisplayedAmount() int64 {
+	if o.IsIceberg() && o.VisibleAmount < o.RemainingAmount() {
+		return o.VisibleAmount
+	}
+
+	return o.RemainingAmount()
+}
+
+// PublicOrder returns the order as shown in the public orderbook. An iceberg order is shown as
+// a regular order of its visible amount: its hidden reserve and its signed message, which holds
+// its full amount, are left out.
+func (o *Order) PublicOrder() *Order {
+	if !o.IsIceberg() {
+		return o
+	}
+
+	p := *o
+	visible := o.DisplayedAmount()
+	remaining := o.RemainingAmount()
+	if visible < remaining {
+		p.RemainingSellAmount = NewDecimalFromInt(o.RemainingSellAmount).Mul(NewDecimalFromInt(visible)).Quo(NewDecimalFromInt(remaining)).Round()
+	}
+
+	p.Amount = o.FilledAmount + visible
+	p.DisplayAmount = 0
+	p.VisibleAmount = 0
+	p.OriginalOrder = nil
+	return &p
+}
+
+// PublicOrders returns the orders as shown in the public orderbook (see PublicOrder)
+func PublicOrders(orders []*Order) []*Order {
+	public := make([]*Order, len(orders))
+	for i, o := range orders {
+		public[i] = o.PublicOrder()
+	}
+
+	return public
+}
+
+// RefillVisibleAmount shows the next slice of an iceberg order once its visible part is
+// exhausted. It returns true if a new slice was shown.
+func (o *Order) RefillVisibleAmount() bool {
+	if !o.IsIceberg() || o.VisibleAmount > 0 {
+		return false
+	}
+
+	o.VisibleAmount = o.DisplayAmount
+	if o.RemainingAmount() < o.VisibleAmount {
+		o.VisibleAmount = o.RemainingAmount()
+	}
+
+	return o.VisibleAmount > 0
+}
+
+// IsTriggerOrder returns true for stop-loss and take-profit orders, which are only
+// released to the orderbook once the last trade price crosses their stop price
+func (o *Order) IsTriggerOrder() bool {
+	return o.Type == "STOP_LOSS" || o.Type == "STOP_LOSS_LIMIT" || o.Type == "TAKE_PROFIT" || o.Type == "TAKE_PROFIT_LIMIT"
+}
+
+// IsTriggeredBy returns true if a trade at the given price releases the trigger order.
+// Stop-loss orders are triggered when the price moves against the position (down for
+// sell orders, up for buy orders) and take-profit orders when it moves in its favor.
+func (o *Order) IsTriggeredBy(price Decimal) bool {
+	if !o.IsTriggerOrder() || price.IsZero() {
+		return false
+	}
+
+	stopLoss := o.Type == "STOP_LOSS" || o.Type == "STOP_LOSS_LIMIT"
+	if (o.Side == "SELL") == stopLoss {
+		return price.Cmp(o.StopPrice) <= 0
+	}
+
+	return price.Cmp(o.StopPrice) >= 0
+}
+
 func (o *Order) Pair() (*Pair, error) {
 	if o.BaseToken == "" {
 		return nil, errors.New("Base token is not set")
@@ -123,6 +321,38 @@ func (o *Order) RemainingAmount() int64 {
 	return o.Amount - o.FilledAmount
 }
 
+// ValidateAmendment checks that an order can replace an open order: both orders are limit
+// orders of the same address, pair, side and matcher
+func (o *Order) ValidateAmendment(amended *Order) error {
+	if amended.Status != "OPEN" && amended.Status != "PARTIAL_FILLED" {
+		return fmt.Errorf("Cannot amend order %v. Status is %v", amended.Hash, amended.Status)
+	}
+
+	if o.UserAddress != amended.UserAddress {
+		return errors.New("Not your order")
+	}
+
+	if o.BaseToken != amended.BaseToken || o.QuoteToken != amended.QuoteToken || o.Side != amended.Side {
+		return errors.New("Amended order should be on the same pair and side")
+	}
+
+	if o.MatcherAddress != amended.MatcherAddress {
+		return errors.New("Amended order should have the same matcher")
+	}
+
+	if o.IsMarketOrder() || o.IsTriggerOrder() || o.TimeInForce == "IOC" || o.TimeInForce == "FOK" {
+		return errors.New("Amended order should be a limit order resting in the orderbook")
+	}
+
+	return nil
+}
+
+// KeepsPriorityOf returns true if an order amending another one takes its place in the queue of
+// its price level: the price is unchanged and the remaining amount is not increased
+func (o *Order) KeepsPriorityOf(amended *Order) bool {
+	return o.Price.Cmp(amended.Price) == 0 && o.RemainingAmount() <= amended.RemainingAmount()
+}
+
 func (o *Order) SellTokenSymbol() string {
 	if o.Side == "BUY" {
 		return o.QuoteTokenSymbol()
@@ -153,14 +383,14 @@ func (o *Order) BuyToken() string {
 }
 
 func (o *Order) QuoteAmount(p *Pair) int64 {
-	return int64(math.Round(float64(o.Amount) * o.Price))
+	return MulPrice(o.Amount, o.Price)
 }
 
 func (o *Order) RemainingQuoteAmount() int64 {
 	if o.Side == "BUY" {
 		return o.RemainingSellAmount
 	} else {
-		return int64(math.Round(float64(o.RemainingSellAmount) * o.Price))
+		return MulPrice(o.RemainingSellAmount, o.Price)
 	}
 }
 
@@ -175,7 +405,7 @@ func (o *Order) SellAmount(p *Pair) int64 {
 	}
 	return sellAmount
 	/*if o.Side == "BUY" {
-		return int64(math.Round(float64(o.Amount) * o.Price))
+		return MulPrice(o.Amount, o.Price)
 	} else {
 		return o.Amount
 	}*/
@@ -197,6 +427,44 @@ func (o *Order) MatcherFeeRate() float64 {
 	}
 }
 
+// CoversMatcherFeeRate tells whether the signed matcher fee of the order is at least the given
+// rate of the amount it applies to, allowing for the rounding of the fee to an integer amount.
+// It returns an error if the signed message of the order lacks the fields of the fee.
+func (o *Order) CoversMatcherFeeRate(rate float64) (bool, error) {
+	signedMessage, ok := o.OriginalOrder["signed_message"].(map[string]interface{})
+	if !ok {
+		return false, errors.New("Order has no signed message")
+	}
+
+	for _, key := range []string{"matcher_fee_asset", "matcher_fee", "sell_asset", "buy_asset", "sell_amount", "price"} {
+		if signedMessage[key] == nil {
+			return false, errors.New("Signed message of the order has no " + key)
+		}
+	}
+
+	var feeAsset, sellAsset, buyAsset string
+	var fee, amount, price float64
+
+	f := &jsonFields{fields: signedMessage}
+	f.string("matcher_fee_asset", &feeAsset)
+	f.float("matcher_fee", &fee)
+	f.string("sell_asset", &sellAsset)
+	f.string("buy_asset", &buyAsset)
+	f.float("sell_amount", &amount)
+	f.float("price", &price)
+	if f.err != nil {
+		return false, f.err
+	}
+
+	if feeAsset == buyAsset {
+		amount *= price
+	} else if feeAsset != sellAsset {
+		return false, nil
+	}
+
+	return fee >= math.Floor(rate*amount), nil
+}
+
 /*func (o *Order) RemainingSellAmount() int64 {
 	//pairMultiplier := p.PairMultiplier()
 
@@ -214,7 +482,7 @@ func (o *Order) RequiredSellAmount(p *Pair) int64 {
 	//pairMultiplier := p.PairMultiplier()
 
 	if o.Side == "BUY" {
-		requiredSellTokenAmount = int64(math.Round(float64(o.Amount) * o.Price))
+		requiredSellTokenAmount = MulPrice(o.Amount, o.Price)
 	} else {
 		requiredSellTokenAmount = o.Amount
 	}
@@ -231,6 +499,10 @@ func (o *Order) TotalRequiredSellAmount(p *Pair) int64 {
 		// selling the quote token, the fee is also paid in quote token
 		OriginalOrderData := o.OriginalOrder["signed_message"].(map[string]interface{})
 		requiredSellTokenAmount = int64(OriginalOrderData["sell_amount"].(float64)) + int64(OriginalOrderData["matcher_fee"].(float64))
+		// market orders lock the quote amount they could spend at their worst price
+		if o.IsMarketOrder() {
+			requiredSellTokenAmount = o.WorstCaseSellAmount(p) + int64(OriginalOrderData["matcher_fee"].(float64))
+		}
 	} else {
 		requiredSellTokenAmount = o.Amount
 	}
@@ -246,7 +518,7 @@ func (o *Order) BuyAmount(pairMultiplier int64) int64 {
 	if o.Side == "SELL" {
 		return o.Amount
 	} else {
-		return int64(math.Round(float64(o.Amount) * o.Price))
+		return MulPrice(o.Amount, o.Price)
 	}
 }
 
@@ -311,6 +583,9 @@ func (o *Order) MarshalJSON() ([]byte, error) {
 		"amount":              o.Amount,
 		"remainingSellAmount": o.RemainingSellAmount,
 		"price":               o.Price,
+		"timeInForce":         o.TimeInForce,
+		"postOnly":            o.PostOnly,
+		"type":                o.Type,
 		// NOTE: Currently removing this to simplify public API, might reinclude
 		// later. An alternative would be to create additional simplified type
 		"createdAt": o.CreatedAt.Format(time.RFC3339Nano),
@@ -326,55 +601,57 @@ func (o *Order) MarshalJSON() ([]byte, error) {
 		order["hash"] = o.Hash
 	}
 
-	return json.Marshal(order)
-}
-
-func (o *Order) UnmarshalJSON(b []byte) error {
-	order := map[string]interface{}{}
-
-	err := json.Unmarshal(b, &order)
-	if err != nil {
-		return err
+	if o.IsTriggerOrder() {
+		order["stopPrice"] = o.StopPrice
+		order["triggered"] = o.Triggered
 	}
 
-	if order["id"] != nil && bson.IsObjectIdHex(order["id"].(string)) {
-		o.ID = bson.ObjectIdHex(order["id"].(string))
+	if o.IsMarketOrder() {
+		order["maxSlippage"] = o.MaxSlippage
 	}
 
-	if order["pairName"] != nil {
-		o.PairName = order["pairName"].(string)
+	if o.IsIceberg() {
+		order["displayAmount"] = o.DisplayAmount
+		order["visibleAmount"] = o.VisibleAmount
 	}
 
-	if order["matcherAddress"] != nil {
-		o.MatcherAddress = order["matcherAddress"].(string)
+	if o.AmendedOrderHash != "" {
+		order["amendedOrderHash"] = o.AmendedOrderHash
 	}
 
-	if order["affiliateAddress"] != nil {
-		o.AffiliateAddress = order["affiliateAddress"].(string)
-	}
+	return json.Marshal(order)
+}
 
-	if order["userAddress"] != nil {
-		o.UserAddress = order["userAddress"].(string)
-	}
+func (o *Order) UnmarshalJSON(b []byte) error {
+	order := map[string]interface{}{}
 
-	if order["baseToken"] != nil {
-		o.BaseToken = order["baseToken"].(string)
+	err := json.Unmarshal(b, &order)
+	if err != nil {
+		return err
 	}
 
-	if order["quoteToken"] != nil {
-		o.QuoteToken = order["quoteToken"].(string)
-	}
+	f := &jsonFields{fields: order}
 
-	if order["price"] != 0 {
-		o.Price = order["price"].(float64) // FIX!!!
+	id := ""
+	f.string("id", &id)
+	if bson.IsObjectIdHex(id) {
+		o.ID = bson.ObjectIdHex(id)
 	}
 
+	f.string("pairName", &o.PairName)
+	f.string("matcherAddress", &o.MatcherAddress)
+	f.string("affiliateAddress", &o.AffiliateAddress)
+	f.string("userAddress", &o.UserAddress)
+	f.string("baseToken", &o.BaseToken)
+	f.string("quoteToken", &o.QuoteToken)
+	f.decimal("price", &o.Price)
+
 	if order["amount"] != nil {
-		switch order["amount"].(type) {
+		switch amount := order["amount"].(type) {
 		case float64:
-			o.Amount = int64(order["amount"].(float64))
+			o.Amount = int64(amount)
 		case string:
-			o.Amount, err = strconv.ParseInt(order["amount"].(string), 10, 64)
+			o.Amount, err = strconv.ParseInt(amount, 10, 64)
 			if err != nil {
 				return errors.New("failed to parse amount")
 			}
@@ -383,41 +660,25 @@ func (o *Order) UnmarshalJSON(b []byte) error {
 		}
 	}
 
-	if order["filledAmount"] != nil {
-		o.FilledAmount = int64(order["filledAmount"].(float64))
-	}
-
-	if order["remainingSellAmount"] != nil {
-		o.RemainingSellAmount = int64(order["remainingSellAmount"].(float64))
-	}
-
-	if order["hash"] != nil {
-		o.Hash = order["hash"].(string)
-	}
-
-	if order["side"] != nil {
-		o.Side = order["side"].(string)
-	}
-
-	if order["status"] != nil {
-		o.Status = order["status"].(string)
-	}
-
-	if order["originalOrder"] != nil {
-		o.OriginalOrder = order["originalOrder"].(map[string]interface{})
-	}
-
-	if order["createdAt"] != nil {
-		t, _ := time.Parse(time.RFC3339Nano, order["createdAt"].(string))
-		o.CreatedAt = t
-	}
-
-	if order["updatedAt"] != nil {
-		t, _ := time.Parse(time.RFC3339Nano, order["updatedAt"].(string))
-		o.UpdatedAt = t
-	}
-
-	return nil
+	f.int("filledAmount", &o.FilledAmount)
+	f.int("remainingSellAmount", &o.RemainingSellAmount)
+	f.string("hash", &o.Hash)
+	f.string("side", &o.Side)
+	f.string("status", &o.Status)
+	f.string("timeInForce", &o.TimeInForce)
+	f.bool("postOnly", &o.PostOnly)
+	f.string("type", &o.Type)
+	f.decimal("stopPrice", &o.StopPrice)
+	f.bool("triggered", &o.Triggered)
+	f.float("maxSlippage", &o.MaxSlippage)
+	f.int("displayAmount", &o.DisplayAmount)
+	f.int("visibleAmount", &o.VisibleAmount)
+	f.object("originalOrder", &o.OriginalOrder)
+	f.string("amendedOrderHash", &o.AmendedOrderHash)
+	f.time("createdAt", &o.CreatedAt)
+	f.time("updatedAt", &o.UpdatedAt)
+
+	return f.err
 }
 
 // OrderRecord is the object that will be saved in the database
@@ -431,12 +692,21 @@ type OrderRecord struct {
 	Status              string        `json:"status" bson:"status"`
 	Side                string        `json:"side" bson:"side"`
 	Hash                string        `json:"hash" bson:"hash"`
-	Price               float64       `json:"price" bson:"price"`
+	Price               Decimal       `json:"price" bson:"price"`
 	Amount              int64         `json:"amount" bson:"amount"`
 	FilledAmount        int64         `json:"filledAmount" bson:"filledAmount"`
 	RemainingSellAmount int64         `json:"remainingSellAmount" bson:"remainingSellAmount"`
-
-	OriginalOrder map[string]interface{} `json:"originalOrder" bson:"originalOrder"`
+	TimeInForce         string        `json:"timeInForce" bson:"timeInForce"`
+	PostOnly            bool          `json:"postOnly" bson:"postOnly"`
+	Type                string        `json:"type" bson:"type"`
+	StopPrice           Decimal       `json:"stopPrice" bson:"stopPrice"`
+	Triggered           bool          `json:"triggered" bson:"triggered"`
+	MaxSlippage         float64       `json:"maxSlippage" bson:"maxSlippage"`
+	DisplayAmount       int64         `json:"displayAmount" bson:"displayAmount"`
+	VisibleAmount       int64         `json:"visibleAmount" bson:"visibleAmount"`
+
+	OriginalOrder    map[string]interface{} `json:"originalOrder" bson:"originalOrder"`
+	AmendedOrderHash string                 `json:"amendedOrderHash,omitempty" bson:"amendedOrderHash,omitempty"`
 
 	PairName  string    `json:"pairName" bson:"pairName"`
 	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
@@ -457,7 +727,16 @@ func (o *Order) GetBSON() (interface{}, error) {
 		Amount:              o.Amount,
 		RemainingSellAmount: o.RemainingSellAmount,
 		Price:               o.Price,
+		TimeInForce:         o.TimeInForce,
+		PostOnly:            o.PostOnly,
+		Type:                o.Type,
+		StopPrice:           o.StopPrice,
+		Triggered:           o.Triggered,
+		MaxSlippage:         o.MaxSlippage,
+		DisplayAmount:       o.DisplayAmount,
+		VisibleAmount:       o.VisibleAmount,
 		OriginalOrder:       o.OriginalOrder,
+		AmendedOrderHash:    o.AmendedOrderHash,
 		CreatedAt:           o.CreatedAt,
 		UpdatedAt:           o.UpdatedAt,
 	}
@@ -487,11 +766,20 @@ func (o *Order) SetBSON(raw bson.Raw) error {
 		Status              string                 `json:"status" bson:"status"`
 		Side                string                 `json:"side" bson:"side"`
 		Hash                string                 `json:"hash" bson:"hash"`
-		Price               float64                `json:"price" bson:"price"`
+		Price               Decimal                `json:"price" bson:"price"`
 		Amount              int64                  `json:"amount" bson:"amount"`
 		FilledAmount        int64                  `json:"filledAmount" bson:"filledAmount"`
 		RemainingSellAmount int64                  `json:"remainingSellAmount" bson:"remainingSellAmount"`
+		TimeInForce         string                 `json:"timeInForce" bson:"timeInForce"`
+		PostOnly            bool                   `json:"postOnly" bson:"postOnly"`
+		Type                string                 `json:"type" bson:"type"`
+		StopPrice           Decimal                `json:"stopPrice" bson:"stopPrice"`
+		Triggered           bool                   `json:"triggered" bson:"triggered"`
+		MaxSlippage         float64                `json:"maxSlippage" bson:"maxSlippage"`
+		DisplayAmount       int64                  `json:"displayAmount" bson:"displayAmount"`
+		VisibleAmount       int64                  `json:"visibleAmount" bson:"visibleAmount"`
 		OriginalOrder       map[string]interface{} `json:"originalOrder" bson:"originalOrder"`
+		AmendedOrderHash    string                 `json:"amendedOrderHash" bson:"amendedOrderHash"`
 		CreatedAt           time.Time              `json:"createdAt" bson:"createdAt"`
 		UpdatedAt           time.Time              `json:"updatedAt" bson:"updatedAt"`
 	})
@@ -514,7 +802,16 @@ func (o *Order) SetBSON(raw bson.Raw) error {
 	o.Status = decoded.Status
 	o.Side = decoded.Side
 	o.Hash = decoded.Hash
+	o.TimeInForce = decoded.TimeInForce
+	o.PostOnly = decoded.PostOnly
+	o.Type = decoded.Type
+	o.StopPrice = decoded.StopPrice
+	o.Triggered = decoded.Triggered
+	o.MaxSlippage = decoded.MaxSlippage
+	o.DisplayAmount = decoded.DisplayAmount
+	o.VisibleAmount = decoded.VisibleAmount
 	o.OriginalOrder = decoded.OriginalOrder
+	o.AmendedOrderHash = decoded.AmendedOrderHash
 
 	if decoded.Amount != 0 {
 		o.Amount = decoded.Amount
@@ -524,7 +821,7 @@ func (o *Order) SetBSON(raw bson.Raw) error {
 		o.FilledAmount = decoded.FilledAmount
 	}
 
-	if decoded.Price != 0 {
+	if !decoded.Price.IsZero() {
 		o.Price = decoded.Price
 	}
 
@@ -553,6 +850,14 @@ func (o OrderBSONUpdate) GetBSON() (interface{}, error) {
 		"price":               o.Price,
 		"amount":              o.Amount,
 		"remainingSellAmount": o.RemainingSellAmount,
+		"timeInForce":         o.TimeInForce,
+		"postOnly":            o.PostOnly,
+		"type":                o.Type,
+		"stopPrice":           o.StopPrice,
+		"triggered":           o.Triggered,
+		"maxSlippage":         o.MaxSlippage,
+		"displayAmount":       o.DisplayAmount,
+		"visibleAmount":       o.VisibleAmount,
 		"originalOrder":       o.OriginalOrder,
 		"updatedAt":           now,
 	}
@@ -561,6 +866,10 @@ func (o OrderBSONUpdate) GetBSON() (interface{}, error) {
 		set["filledAmount"] = o.FilledAmount
 	}
 
+	if o.AmendedOrderHash != "" {
+		set["amendedOrderHash"] = o.AmendedOrderHash
+	}
+
 	setOnInsert := bson.M{
 		"_id":       bson.NewObjectId(),
 		"hash":      o.Hash,
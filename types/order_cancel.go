@@ -51,16 +51,66 @@ func (oc *OrderCancel) UnmarshalJSON(b []byte) error {
 	if parsed["orderHash"] == nil {
 		return errors.New("Order Hash is missing")
 	}
-	oc.OrderHash = parsed["orderHash"].(string)
 
 	if parsed["userAddress"] == nil {
 		return errors.New("userAddress is missing")
 	}
-	oc.UserAddress = parsed["userAddress"].(string)
+
+	f := &jsonFields{fields: parsed}
+	f.string("orderHash", &oc.OrderHash)
+	f.string("userAddress", &oc.UserAddress)
+	return f.err
+}
+
+// OrderCancelAll cancels all the open orders of an address, optionally restricted to a pair and
+// a side. UserAddress is the address that signed the message: the owner of the orders or an
+// address they authorized. Address defaults to UserAddress.
+type OrderCancelAll struct {
+	UserAddress string `json:"userAddress"`
+	Address     string `json:"address"`
+	PairName    string `json:"pairName"`
+	Side        string `json:"side"`
+}
+
+func (oca *OrderCancelAll) Validate() error {
+	if oca.UserAddress == "" {
+		return errors.New("userAddress is missing")
+	}
+
+	if oca.Side != "" && oca.Side != "BUY" && oca.Side != "SELL" {
+		return errors.New("Side should be BUY or SELL")
+	}
+
+	if oca.Address == "" {
+		oca.Address = oca.UserAddress
+	}
 
 	return nil
 }
 
+// Matches tells whether an order is selected by the pair and side of the cancel
+func (oca *OrderCancelAll) Matches(o *Order) bool {
+	if oca.PairName != "" && o.PairName != oca.PairName {
+		return false
+	}
+
+	if oca.Side != "" && o.Side != oca.Side {
+		return false
+	}
+
+	return true
+}
+
+// OrderCancelAllSummary lists the orders cancelled by an OrderCancelAll, and the errors of the
+// orders that could not be cancelled by their hash
+type OrderCancelAllSummary struct {
+	Address   string            `json:"address"`
+	PairName  string            `json:"pairName,omitempty"`
+	Side      string            `json:"side,omitempty"`
+	Cancelled []string          `json:"cancelled"`
+	Failed    map[string]string `json:"failed,omitempty"`
+}
+
 /*
 // ComputeHash computes the hash of an order cancel message
 func (oc *OrderCancel) ComputeHash() string {
@@ -0,0 +1,41 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderCancelAll(t *testing.T) {
+	oca := &OrderCancelAll{UserAddress: "ADDRESS"}
+	assert.Nil(t, oca.Validate())
+	assert.Equal(t, "ADDRESS", oca.Address)
+
+	assert.NotNil(t, (&OrderCancelAll{}).Validate())
+	assert.NotNil(t, (&OrderCancelAll{UserAddress: "ADDRESS", Side: "LONG"}).Validate())
+
+	buy := &Order{PairName: "GBYTE/USDC", Side: "BUY"}
+	sell := &Order{PairName: "GBYTE/USDC", Side: "SELL"}
+	other := &Order{PairName: "GBYTE/BTC", Side: "BUY"}
+
+	assert.True(t, oca.Matches(buy))
+	assert.True(t, oca.Matches(other))
+
+	oca = &OrderCancelAll{UserAddress: "ADDRESS", PairName: "GBYTE/USDC", Side: "BUY"}
+	assert.True(t, oca.Matches(buy))
+	assert.False(t, oca.Matches(sell))
+	assert.False(t, oca.Matches(other))
+}
+
+func TestOrderCancelUnmarshal(t *testing.T) {
+	oc := &OrderCancel{}
+	err := json.Unmarshal([]byte(`{"orderHash":"hash","userAddress":"ADDRESS"}`), oc)
+	assert.Nil(t, err)
+	assert.Equal(t, "hash", oc.OrderHash)
+	assert.Equal(t, "ADDRESS", oc.UserAddress)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"orderHash":1,"userAddress":"ADDRESS"}`), &OrderCancel{}))
+	assert.Error(t, json.Unmarshal([]byte(`{"orderHash":"hash","userAddress":true}`), &OrderCancel{}))
+	assert.Error(t, json.Unmarshal([]byte(`{"userAddress":"ADDRESS"}`), &OrderCancel{}))
+}
@@ -18,7 +18,7 @@ func TestBaseTokenSymbol(t *testing.T) {
 		MatcherAddress: "0xae55690d4b079460e6ac28aaa58c9ec7b73a7485",
 		BaseToken:      "0xe41d2489571d322189246dafa5ebde1f4699f498",
 		QuoteToken:     "0x12459c951127e0c374ff9105dda097662a027093",
-		Price:          1000,
+		Price:          NewDecimal(1000),
 		Amount:         1000,
 		FilledAmount:   100,
 		Status:         "OPEN",
@@ -39,7 +39,7 @@ func TestQuoteTokenSymbol(t *testing.T) {
 		MatcherAddress: "0xae55690d4b079460e6ac28aaa58c9ec7b73a7485",
 		BaseToken:      "0xe41d2489571d322189246dafa5ebde1f4699f498",
 		QuoteToken:     "0x12459c951127e0c374ff9105dda097662a027093",
-		Price:          1000,
+		Price:          NewDecimal(1000),
 		Amount:         1000,
 		FilledAmount:   100,
 		Status:         "OPEN",
@@ -61,7 +61,7 @@ func TestOrderMarshal(t *testing.T) {
 		MatcherAddress: "0xae55690d4b079460e6ac28aaa58c9ec7b73a7485",
 		BaseToken:      "0xe41d2489571d322189246dafa5ebde1f4699f498",
 		QuoteToken:     "0x12459c951127e0c374ff9105dda097662a027093",
-		Price:          1000,
+		Price:          NewDecimal(1000),
 		Amount:         1000,
 		FilledAmount:   100,
 		Status:         "OPEN",
@@ -113,7 +113,7 @@ func TestOrderUnmarshal(t *testing.T) {
 		QuoteToken:     "0xe41d2489571d322189246dafa5ebde1f4699f498",
 		BaseToken:      "0x12459c951127e0c374ff9105dda097662a027093",
 		Amount:         100,
-		Price:          100,
+		Price:          NewDecimal(100),
 		FilledAmount:   1000,
 		Status:         "OPEN",
 		Side:           "BUY",
@@ -148,6 +148,28 @@ func TestOrderUnmarshal(t *testing.T) {
 	}
 }
 
+func TestOrderUnmarshalInvalidTypes(t *testing.T) {
+	for _, data := range []string{
+		`{"price":"1"}`,
+		`{"userAddress":1}`,
+		`{"filledAmount":"1"}`,
+		`{"postOnly":"true"}`,
+		`{"originalOrder":[]}`,
+		`{"id":1}`,
+	} {
+		o := &Order{}
+		assert.Error(t, json.Unmarshal([]byte(data), o), data)
+	}
+
+	o := &Order{}
+	err := json.Unmarshal([]byte(`{"price":1.5,"userAddress":"ADDRESS","postOnly":true,"amount":"10"}`), o)
+	assert.Nil(t, err)
+	assert.Equal(t, NewDecimal(1.5), o.Price)
+	assert.Equal(t, "ADDRESS", o.UserAddress)
+	assert.Equal(t, true, o.PostOnly)
+	assert.Equal(t, int64(10), o.Amount)
+}
+
 func TestOrderBSON(t *testing.T) {
 	order := &Order{
 		ID:             bson.ObjectIdHex("537f700b537461b70c5f0000"),
@@ -155,7 +177,7 @@ func TestOrderBSON(t *testing.T) {
 		MatcherAddress: "0xae55690d4b079460e6ac28aaa58c9ec7b73a7485",
 		BaseToken:      "0xe41d2489571d322189246dafa5ebde1f4699f498",
 		QuoteToken:     "0x12459c951127e0c374ff9105dda097662a027093",
-		Price:          1000,
+		Price:          NewDecimal(1000),
 		Amount:         1000,
 		FilledAmount:   100,
 		Status:         "OPEN",
@@ -180,6 +202,213 @@ func TestOrderBSON(t *testing.T) {
 	assert.Equal(t, decoded, order)
 }
 
+func TestOrderIsTriggeredBy(t *testing.T) {
+	o := &Order{Side: "SELL", Type: "STOP_LOSS", StopPrice: NewDecimal(100)}
+	assert.True(t, o.IsTriggeredBy(NewDecimal(99)))
+	assert.True(t, o.IsTriggeredBy(NewDecimal(100)))
+	assert.False(t, o.IsTriggeredBy(NewDecimal(101)))
+
+	o = &Order{Side: "BUY", Type: "STOP_LOSS_LIMIT", StopPrice: NewDecimal(100)}
+	assert.False(t, o.IsTriggeredBy(NewDecimal(99)))
+	assert.True(t, o.IsTriggeredBy(NewDecimal(101)))
+
+	o = &Order{Side: "SELL", Type: "TAKE_PROFIT_LIMIT", StopPrice: NewDecimal(100)}
+	assert.False(t, o.IsTriggeredBy(NewDecimal(99)))
+	assert.True(t, o.IsTriggeredBy(NewDecimal(101)))
+
+	o = &Order{Side: "BUY", Type: "TAKE_PROFIT", StopPrice: NewDecimal(100)}
+	assert.True(t, o.IsTriggeredBy(NewDecimal(99)))
+	assert.False(t, o.IsTriggeredBy(NewDecimal(101)))
+
+	o = &Order{Side: "BUY", Type: "LIMIT", StopPrice: NewDecimal(100)}
+	assert.False(t, o.IsTriggeredBy(NewDecimal(99)))
+}
+
+func TestOrderWorstPrice(t *testing.T) {
+	o := &Order{Side: "BUY", Type: "MARKET", MaxSlippage: 0.1}
+	assert.Equal(t, NewDecimal(110), o.WorstPrice(NewDecimal(100)))
+
+	o.Price = NewDecimal(105)
+	assert.Equal(t, NewDecimal(105), o.WorstPrice(NewDecimal(100)))
+
+	o.Price = NewDecimal(120)
+	assert.Equal(t, NewDecimal(110), o.WorstPrice(NewDecimal(100)))
+
+	o = &Order{Side: "SELL", Type: "MARKET", MaxSlippage: 0.1}
+	assert.Equal(t, NewDecimal(90), o.WorstPrice(NewDecimal(100)))
+
+	o.Price = NewDecimal(95)
+	assert.Equal(t, NewDecimal(95), o.WorstPrice(NewDecimal(100)))
+
+	o = &Order{Side: "BUY", Type: "MARKET", Price: NewDecimal(105)}
+	assert.Equal(t, NewDecimal(105), o.WorstPrice(NewDecimal(100)))
+
+	o = &Order{Side: "BUY", Type: "LIMIT", Price: NewDecimal(105)}
+	assert.Equal(t, NewDecimal(105), o.WorstPrice(NewDecimal(100)))
+}
+
+func TestValidateMarketOrder(t *testing.T) {
+	o := &Order{
+		UserAddress: "USER",
+		BaseToken:   "base",
+		QuoteToken:  "quote",
+		Side:        "BUY",
+		Amount:      1e6,
+		Type:        "MARKET",
+		MaxSlippage: 0.05,
+	}
+	assert.Nil(t, o.Validate())
+
+	o.MaxSlippage = 0
+	assert.NotNil(t, o.Validate())
+
+	o.Price = NewDecimal(100)
+	assert.Nil(t, o.Validate())
+
+	o.TimeInForce = "GTC"
+	assert.NotNil(t, o.Validate())
+
+	o.TimeInForce = "FOK"
+	o.MaxSlippage = 1
+	assert.NotNil(t, o.Validate())
+
+	o.MaxSlippage = 0.05
+	o.Type = "LIMIT"
+	assert.NotNil(t, o.Validate())
+}
+
+func TestOrderDisplayedAmount(t *testing.T) {
+	o := &Order{Amount: 1000, FilledAmount: 100}
+	assert.Equal(t, int64(900), o.DisplayedAmount())
+	assert.False(t, o.RefillVisibleAmount())
+
+	o.DisplayAmount = 300
+	assert.True(t, o.RefillVisibleAmount())
+	assert.Equal(t, int64(300), o.DisplayedAmount())
+
+	// the visible slice is only refilled once exhausted
+	o.VisibleAmount = 50
+	assert.False(t, o.RefillVisibleAmount())
+	assert.Equal(t, int64(50), o.DisplayedAmount())
+
+	o.FilledAmount = 900
+	o.VisibleAmount = 0
+	assert.True(t, o.RefillVisibleAmount())
+	assert.Equal(t, int64(100), o.VisibleAmount)
+}
+
+func TestOrderAmendment(t *testing.T) {
+	amended := &Order{
+		Hash:           "amended",
+		UserAddress:    "user",
+		MatcherAddress: "matcher",
+		BaseToken:      "base",
+		QuoteToken:     "quote",
+		Side:           "SELL",
+		Status:         "PARTIAL_FILLED",
+		Price:          NewDecimal(1.5),
+		Amount:         1000,
+		FilledAmount:   400,
+	}
+
+	o := &Order{
+		Hash:             "amending",
+		UserAddress:      "user",
+		MatcherAddress:   "matcher",
+		BaseToken:        "base",
+		QuoteToken:       "quote",
+		Side:             "SELL",
+		Price:            NewDecimal(1.5),
+		Amount:           600,
+		AmendedOrderHash: "amended",
+	}
+
+	assert.Nil(t, o.ValidateAmendment(amended))
+	assert.True(t, o.KeepsPriorityOf(amended))
+
+	// increasing the remaining amount or changing the price loses the priority
+	o.Amount = 601
+	assert.False(t, o.KeepsPriorityOf(amended))
+
+	o.Amount = 500
+	o.Price = NewDecimal(1.4)
+	assert.False(t, o.KeepsPriorityOf(amended))
+
+	o.Side = "BUY"
+	assert.NotNil(t, o.ValidateAmendment(amended))
+
+	o.Side = "SELL"
+	o.TimeInForce = "IOC"
+	assert.NotNil(t, o.ValidateAmendment(amended))
+
+	o.TimeInForce = ""
+	o.UserAddress = "other"
+	assert.NotNil(t, o.ValidateAmendment(amended))
+
+	o.UserAddress = "user"
+	amended.Status = "FILLED"
+	assert.NotNil(t, o.ValidateAmendment(amended))
+}
+
+func TestOrderAmendedOrderHashJSON(t *testing.T) {
+	o := &Order{Hash: "amending", AmendedOrderHash: "amended", CreatedAt: time.Now()}
+
+	b, err := json.Marshal(o)
+	assert.Nil(t, err)
+
+	decoded := &Order{}
+	err = json.Unmarshal(b, decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, "amended", decoded.AmendedOrderHash)
+}
+
+func TestPublicOrder(t *testing.T) {
+	sell := &Order{
+		Side:                "SELL",
+		Amount:              1000,
+		FilledAmount:        100,
+		RemainingSellAmount: 900,
+		DisplayAmount:       100,
+		VisibleAmount:       50,
+		OriginalOrder: map[string]interface{}{
+			"signed_message": map[string]interface{}{"sell_amount": float64(1000)},
+		},
+		CreatedAt: time.Now(),
+	}
+
+	public := sell.PublicOrder()
+	assert.Equal(t, int64(150), public.Amount)
+	assert.Equal(t, int64(50), public.RemainingSellAmount)
+	assert.Equal(t, int64(1000), sell.Amount)
+
+	b, err := json.Marshal(public)
+	assert.Nil(t, err)
+
+	decoded := map[string]interface{}{}
+	err = json.Unmarshal(b, &decoded)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(150), decoded["amount"])
+	assert.Equal(t, float64(50), decoded["remainingSellAmount"])
+	assert.Nil(t, decoded["displayAmount"])
+	assert.Nil(t, decoded["visibleAmount"])
+	assert.Nil(t, decoded["originalOrder"])
+
+	buy := &Order{
+		Side:                "BUY",
+		Amount:              1000,
+		RemainingSellAmount: 2000,
+		DisplayAmount:       100,
+		VisibleAmount:       100,
+	}
+
+	public = buy.PublicOrder()
+	assert.Equal(t, int64(100), public.Amount)
+	assert.Equal(t, int64(200), public.RemainingSellAmount)
+
+	regular := &Order{Side: "SELL", Amount: 1000, RemainingSellAmount: 1000}
+	assert.Equal(t, regular, regular.PublicOrder())
+}
+
 // func TestAccountBSON(t *testing.T) {
 // 	assert := assert.New(t)
 
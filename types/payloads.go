@@ -103,7 +103,7 @@ func (p *NewOrderPayload) ToOrder() (o *Order, err error) {
 		QuoteToken:  p.QuoteToken,
 		Amount:      p.Amount,
 		Side:        p.Side,
-		Price:       p.Price,
+		Price:       NewDecimal(p.Price),
 		//	Hash:        p.ComputeHash(),
 	}
 
@@ -134,3 +134,35 @@ func (p *NewOrderPayload) ComputeHash() string {
 	return base64.StdEncoding.EncodeToString(sha.Sum(nil))
 }
 */
+
+// CancelOnDisconnectPayload enables the auto-cancellation of the open orders of an address when
+// the websocket connection closes, or when it doesn't send a heartbeat within the timeout in
+// seconds. A zero timeout selects the configured timeout.
+type CancelOnDisconnectPayload struct {
+	Address string `json:"address"`
+	Timeout int    `json:"timeout"`
+}
+
+// Validate validates the CancelOnDisconnectPayload fields.
+func (p CancelOnDisconnectPayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.Address, validation.Required),
+		validation.Field(&p.Timeout, validation.Min(0)),
+	)
+}
+
+// AmendOrderPayload replaces the open order with the given hash with a new order signed by the
+// same address. The new order keeps the priority of the amended order when it only reduces its
+// remaining amount.
+type AmendOrderPayload struct {
+	OrderHash string      `json:"orderHash"`
+	Order     interface{} `json:"order"`
+}
+
+// Validate validates the AmendOrderPayload fields.
+func (p AmendOrderPayload) Validate() error {
+	return validation.ValidateStruct(&p,
+		validation.Field(&p.OrderHash, validation.Required),
+		validation.Field(&p.Order, validation.Required),
+	)
+}
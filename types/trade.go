@@ -5,7 +5,6 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"errors"
-	"math"
 	"time"
 
 	"github.com/globalsign/mgo/bson"
@@ -25,7 +24,7 @@ type Trade struct {
 	PairName                 string        `json:"pairName" bson:"pairName"`
 	CreatedAt                time.Time     `json:"createdAt" bson:"createdAt"`
 	UpdatedAt                time.Time     `json:"updatedAt" bson:"updatedAt"`
-	Price                    float64       `json:"price" bson:"price"`
+	Price                    Decimal       `json:"price" bson:"price"`
 	Status                   string        `json:"status" bson:"status"`
 	Amount                   int64         `json:"amount" bson:"amount"`
 	QuoteAmount              int64         `json:"quoteAmount" bson:"quoteAmount"`
@@ -47,7 +46,7 @@ type TradeRecord struct {
 	PairName                 string        `json:"pairName" bson:"pairName"`
 	CreatedAt                time.Time     `json:"createdAt" bson:"createdAt"`
 	UpdatedAt                time.Time     `json:"updatedAt" bson:"updatedAt"`
-	Price                    float64       `json:"price" bson:"price"`
+	Price                    Decimal       `json:"price" bson:"price"`
 	Amount                   int64         `json:"amount" bson:"amount"`
 	QuoteAmount              int64         `json:"quoteAmount" bson:"quoteAmount"`
 	RemainingTakerSellAmount int64         `json:"remainingTakerSellAmount" bson:"remainingTakerSellAmount"`
@@ -57,7 +56,7 @@ type TradeRecord struct {
 }
 
 // NewTrade returns a new unsigned trade corresponding to an Order, amount and taker address
-func NewTrade(mo *Order, to *Order, amount int64, price float64) *Trade {
+func NewTrade(mo *Order, to *Order, amount int64, price Decimal) *Trade {
 	t := &Trade{
 		Maker:          mo.UserAddress,
 		Taker:          to.UserAddress,
@@ -110,11 +109,11 @@ func (t *Trade) Validate() error {
 		return errors.New("Trade 'quoteAmount' parameter is required")
 	}
 
-	if t.Price == 0 {
+	if t.Price.IsZero() {
 		return errors.New("Trade 'price' paramter is required")
 	}
 
-	if t.Price <= 0 {
+	if t.Price.Sign() <= 0 {
 		return errors.New("Trade 'price' parameter should be positive")
 	}
 
@@ -236,7 +235,7 @@ func (t *Trade) UnmarshalJSON(b []byte) error {
 	}
 
 	if trade["price"] != nil {
-		t.Price = trade["price"].(float64) // FIX
+		t.Price = NewDecimal(trade["price"].(float64)) // FIX
 	}
 
 	if trade["amount"] != nil {
@@ -269,7 +268,7 @@ func (t *Trade) UnmarshalJSON(b []byte) error {
 
 func (t *Trade) CalcQuoteAmount() int64 {
 	// pairMultiplier := p.PairMultiplier()
-	return int64(math.Round(float64(t.Amount) * t.Price))
+	return MulPrice(t.Amount, t.Price)
 }
 
 func (t *Trade) GetBSON() (interface{}, error) {
@@ -312,7 +311,7 @@ func (t *Trade) SetBSON(raw bson.Raw) error {
 		TxHash                   string        `json:"txHash" bson:"txHash"`
 		CreatedAt                time.Time     `json:"createdAt" bson:"createdAt"`
 		UpdatedAt                time.Time     `json:"updatedAt" bson:"updatedAt"`
-		Price                    float64       `json:"price" bson:"price"`
+		Price                    Decimal       `json:"price" bson:"price"`
 		Status                   string        `json:"status" bson:"status"`
 		Amount                   int64         `json:"amount" bson:"amount"`
 		QuoteAmount              int64         `json:"quoteAmount" bson:"quoteAmount"`
@@ -359,6 +358,15 @@ func (t *Trade) ComputeHash() string {
 	return base64.StdEncoding.EncodeToString(sha.Sum(nil))
 }
 
+// PairCode returns the code of the pair of the trade, as computed for its orders
+func (t *Trade) PairCode() (string, error) {
+	if t.PairName == "" {
+		return "", errors.New("Pair name is required")
+	}
+
+	return t.PairName + "::" + t.BaseToken + "::" + t.QuoteToken, nil
+}
+
 func (t *Trade) Pair() (*Pair, error) {
 	if t.BaseToken == "" {
 		return nil, errors.New("Base token is not set")
@@ -396,7 +404,7 @@ func (t TradeBSONUpdate) GetBSON() (interface{}, error) {
 		"makerSide":                t.MakerSide,
 	}
 
-	if t.Price != 0 {
+	if !t.Price.IsZero() {
 		set["price"] = t.Price
 	}
 
@@ -21,7 +21,7 @@ func TestTradeJSON(t *testing.T) {
 		MakerOrderHash: "0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff",
 		TakerOrderHash: "0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff",
 		PairName:       "ZRX/WETH",
-		Price:          10000,
+		Price:          NewDecimal(10000),
 		Amount:         100,
 	}
 
@@ -52,7 +52,7 @@ func TestTradeBSON(t *testing.T) {
 		MakerOrderHash: "0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff",
 		TakerOrderHash: "0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff",
 		PairName:       "ZRX/WETH",
-		Price:          10000,
+		Price:          NewDecimal(10000),
 		Amount:         100,
 		CreatedAt:      time.Unix(1405544146, 0).UTC(),
 		UpdatedAt:      time.Unix(1405544146, 0).UTC(),
@@ -0,0 +1,181 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// WalletEvent is an event received from the wallet of the node. Data holds the JSON
+// payload of the event, decoded by the handler of its type into one of the event
+// structs below (or into an Order for new_order and amend_order, an OrderCancel for
+// cancel_order and an OrderCancelAll for cancel_all_orders).
+type WalletEvent struct {
+	Event string
+	Data  []byte
+}
+
+// NewWalletEvent extracts the type and the payload of a raw wallet event
+func NewWalletEvent(raw map[string]interface{}) (*WalletEvent, error) {
+	event, ok := raw["event"].(string)
+	if !ok || event == "" {
+		return nil, errors.New("Wallet event has no type")
+	}
+
+	data, ok := raw["data"].([]interface{})
+	if !ok || len(data) == 0 {
+		return nil, errors.New("Wallet event " + event + " has no data")
+	}
+
+	bytes, err := json.Marshal(data[0])
+	if err != nil {
+		return nil, err
+	}
+
+	return &WalletEvent{Event: event, Data: bytes}, nil
+}
+
+// LoggedInEvent is sent when a user logged in with their wallet
+type LoggedInEvent struct {
+	SessionId string `json:"sessionId"`
+	Address   string `json:"address"`
+}
+
+func (ev *LoggedInEvent) Validate() error {
+	if ev.SessionId == "" {
+		return errors.New("Session id is required")
+	}
+
+	if ev.Address == "" {
+		return errors.New("Address is required")
+	}
+
+	return nil
+}
+
+// RevokeEvent is sent when a user revoked the authorization of a signer
+type RevokeEvent struct {
+	UserAddress   string `json:"userAddress"`
+	SignerAddress string `json:"signerAddress"`
+}
+
+func (ev *RevokeEvent) Validate() error {
+	if ev.UserAddress == "" {
+		return errors.New("User address is required")
+	}
+
+	if ev.SignerAddress == "" {
+		return errors.New("Signer address is required")
+	}
+
+	return nil
+}
+
+// BalancesUpdateEvent is sent when the balances of a user on the exchange AA changed
+type BalancesUpdateEvent struct {
+	Address          string           `json:"address"`
+	Event            string           `json:"event"`
+	BalancesBySymbol map[string]int64 `json:"balances_by_symbol"`
+	BalancesByAsset  map[string]int64 `json:"balances_by_asset"`
+}
+
+func (ev *BalancesUpdateEvent) Validate() error {
+	if ev.Address == "" {
+		return errors.New("Address is required")
+	}
+
+	if ev.BalancesBySymbol == nil {
+		ev.BalancesBySymbol = map[string]int64{}
+	}
+
+	if ev.BalancesByAsset == nil {
+		ev.BalancesByAsset = map[string]int64{}
+	}
+
+	return nil
+}
+
+// ExchangeResponseEvent is sent when the exchange AA responded to a trigger unit. When the
+// trigger settled a trade, of this matcher or another one, the node describes it in Trade.
+type ExchangeResponseEvent struct {
+	TriggerUnit    string
+	TriggerAddress string
+	Bounced        bool
+	Error          string
+	Trade          *MatcherTrade
+}
+
+func (ev *ExchangeResponseEvent) UnmarshalJSON(b []byte) error {
+	parsed := struct {
+		TriggerUnit    string          `json:"trigger_unit"`
+		TriggerAddress string          `json:"trigger_address"`
+		Bounced        json.RawMessage `json:"bounced"`
+		Response       struct {
+			Error string `json:"error"`
+		} `json:"response"`
+		Trade *struct {
+			MakerOrderHash string `json:"maker_order_hash"`
+			TakerOrderHash string `json:"taker_order_hash"`
+			Amount         int64  `json:"amount"`
+			QuoteAmount    int64  `json:"quote_amount"`
+			MakerFee       int64  `json:"maker_fee"`
+			TakerFee       int64  `json:"taker_fee"`
+		} `json:"trade"`
+	}{}
+
+	err := json.Unmarshal(b, &parsed)
+	if err != nil {
+		return err
+	}
+
+	ev.TriggerUnit = parsed.TriggerUnit
+	ev.TriggerAddress = parsed.TriggerAddress
+	ev.Error = parsed.Response.Error
+
+	if parsed.Trade != nil {
+		ev.Trade = &MatcherTrade{
+			TriggerUnit:    parsed.TriggerUnit,
+			MatcherAddress: parsed.TriggerAddress,
+			MakerOrderHash: parsed.Trade.MakerOrderHash,
+			TakerOrderHash: parsed.Trade.TakerOrderHash,
+			Amount:         parsed.Trade.Amount,
+			QuoteAmount:    parsed.Trade.QuoteAmount,
+			MakerFee:       parsed.Trade.MakerFee,
+			TakerFee:       parsed.Trade.TakerFee,
+		}
+	}
+
+	// the node sends bounced as a boolean or as 0/1
+	switch string(parsed.Bounced) {
+	case "false", "0":
+		ev.Bounced = false
+	case "true", "1":
+		ev.Bounced = true
+	case "", "null":
+		return errors.New("Bounced flag is required")
+	default:
+		return errors.New("Invalid bounced flag: " + string(parsed.Bounced))
+	}
+
+	return nil
+}
+
+func (ev *ExchangeResponseEvent) Validate() error {
+	if ev.TriggerUnit == "" {
+		return errors.New("Trigger unit is required")
+	}
+
+	return nil
+}
+
+// SubmittedTradesEvent is sent when trades were submitted to the exchange AA
+type SubmittedTradesEvent struct {
+	TradeHashes []string `json:"trade_hashes"`
+}
+
+func (ev *SubmittedTradesEvent) Validate() error {
+	if len(ev.TradeHashes) == 0 {
+		return errors.New("Trade hashes are required")
+	}
+
+	return nil
+}
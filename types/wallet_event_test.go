@@ -0,0 +1,104 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewWalletEvent(t *testing.T) {
+	raw := map[string]interface{}{
+		"event": "loggedin",
+		"data": []interface{}{
+			map[string]interface{}{"sessionId": "abc", "address": "ADDRESS"},
+		},
+	}
+
+	ev, err := NewWalletEvent(raw)
+	assert.Nil(t, err)
+	assert.Equal(t, "loggedin", ev.Event)
+
+	loggedIn := &LoggedInEvent{}
+	err = json.Unmarshal(ev.Data, loggedIn)
+	assert.Nil(t, err)
+	assert.Nil(t, loggedIn.Validate())
+	assert.Equal(t, "abc", loggedIn.SessionId)
+	assert.Equal(t, "ADDRESS", loggedIn.Address)
+
+	_, err = NewWalletEvent(map[string]interface{}{"event": "loggedin"})
+	assert.NotNil(t, err)
+
+	_, err = NewWalletEvent(map[string]interface{}{"event": 1, "data": []interface{}{}})
+	assert.NotNil(t, err)
+
+	assert.NotNil(t, (&LoggedInEvent{SessionId: "abc"}).Validate())
+}
+
+func TestExchangeResponseEventJSON(t *testing.T) {
+	cases := []struct {
+		json    string
+		bounced bool
+		valid   bool
+	}{
+		{`{"trigger_unit": "unit", "bounced": true, "response": {"error": "not enough balance"}}`, true, true},
+		{`{"trigger_unit": "unit", "bounced": 1, "response": {"error": "not enough balance"}}`, true, true},
+		{`{"trigger_unit": "unit", "bounced": false, "response": {}}`, false, true},
+		{`{"trigger_unit": "unit", "bounced": 0, "response": {}}`, false, true},
+		{`{"trigger_unit": "unit", "response": {}}`, false, false},
+		{`{"trigger_unit": "unit", "bounced": "yes", "response": {}}`, false, false},
+	}
+
+	for _, c := range cases {
+		ev := &ExchangeResponseEvent{}
+		err := json.Unmarshal([]byte(c.json), ev)
+		if !c.valid {
+			assert.NotNil(t, err, c.json)
+			continue
+		}
+
+		assert.Nil(t, err, c.json)
+		assert.Nil(t, ev.Validate())
+		assert.Equal(t, "unit", ev.TriggerUnit)
+		assert.Equal(t, c.bounced, ev.Bounced)
+		if c.bounced {
+			assert.Equal(t, "not enough balance", ev.Error)
+		}
+	}
+
+	ev := &ExchangeResponseEvent{}
+	err := json.Unmarshal([]byte(`{"bounced": 0}`), ev)
+	assert.Nil(t, err)
+	assert.NotNil(t, ev.Validate())
+	assert.Nil(t, ev.Trade)
+
+	ev = &ExchangeResponseEvent{}
+	err = json.Unmarshal([]byte(`{"trigger_unit": "unit", "trigger_address": "MATCHER", "bounced": false, "response": {},
+		"trade": {"maker_order_hash": "maker", "taker_order_hash": "taker", "amount": 100, "quote_amount": 250, "maker_fee": 1, "taker_fee": 2}}`), ev)
+	assert.Nil(t, err)
+	assert.Equal(t, "MATCHER", ev.TriggerAddress)
+	assert.Equal(t, &MatcherTrade{
+		TriggerUnit:    "unit",
+		MatcherAddress: "MATCHER",
+		MakerOrderHash: "maker",
+		TakerOrderHash: "taker",
+		Amount:         100,
+		QuoteAmount:    250,
+		MakerFee:       1,
+		TakerFee:       2,
+	}, ev.Trade)
+}
+
+func TestBalancesUpdateEventJSON(t *testing.T) {
+	ev := &BalancesUpdateEvent{}
+	err := json.Unmarshal([]byte(`{"address": "ADDRESS", "event": "deposit", "balances_by_symbol": {"GBYTE": 1000000}}`), ev)
+	assert.Nil(t, err)
+	assert.Nil(t, ev.Validate())
+	assert.Equal(t, int64(1000000), ev.BalancesBySymbol["GBYTE"])
+	assert.NotNil(t, ev.BalancesByAsset)
+
+	ev = &BalancesUpdateEvent{}
+	err = json.Unmarshal([]byte(`{"event": "deposit"}`), ev)
+	assert.Nil(t, err)
+	assert.NotNil(t, ev.Validate())
+}
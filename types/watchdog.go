@@ -0,0 +1,131 @@
+package types
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// MatcherTrade is a trade settled by a matcher on the exchange AA, as described by the node in
+// the exchange_response event of its trigger unit. The amounts are the base amount and the quote
+// amount exchanged, the fees are the matcher fees charged to each order in their fee asset.
+type MatcherTrade struct {
+	TriggerUnit    string `json:"triggerUnit"`
+	MatcherAddress string `json:"matcherAddress"`
+	MakerOrderHash string `json:"makerOrderHash"`
+	TakerOrderHash string `json:"takerOrderHash"`
+	Amount         int64  `json:"amount"`
+	QuoteAmount    int64  `json:"quoteAmount"`
+	MakerFee       int64  `json:"makerFee"`
+	TakerFee       int64  `json:"takerFee"`
+}
+
+// Types of the misbehaviours detected in the trades of the matchers
+const (
+	PriorityViolationAlert   = "PRIORITY_VIOLATION"
+	LimitPriceViolationAlert = "LIMIT_PRICE_VIOLATION"
+	FeeOverchargeAlert       = "FEE_OVERCHARGE"
+)
+
+// MatcherAlert reports a trade of a matcher that wronged the user of an order: the order was
+// skipped although it had priority over the maker order, was filled at a price worse than its
+// limit price, or was charged more than its signed matcher fee.
+// The heuristic alerts can be false positives: the priority violations are inferred from the
+// orders still open, whose balances at the time of the trade are not known.
+type MatcherAlert struct {
+	ID             bson.ObjectId `json:"id" bson:"_id"`
+	Type           string        `json:"type" bson:"type"`
+	MatcherAddress string        `json:"matcherAddress" bson:"matcherAddress"`
+	TriggerUnit    string        `json:"triggerUnit" bson:"triggerUnit"`
+	PairName       string        `json:"pairName" bson:"pairName"`
+	OrderHash      string        `json:"orderHash" bson:"orderHash"`
+	UserAddress    string        `json:"userAddress" bson:"userAddress"`
+	Message        string        `json:"message" bson:"message"`
+	Heuristic      bool          `json:"heuristic" bson:"heuristic"`
+	CreatedAt      time.Time     `json:"createdAt" bson:"createdAt"`
+}
+
+// NewMatcherAlert returns an alert about the wrong done to an order by a trade
+func NewMatcherAlert(alertType string, t *MatcherTrade, o *Order, message string) *MatcherAlert {
+	return &MatcherAlert{
+		Type:           alertType,
+		MatcherAddress: t.MatcherAddress,
+		TriggerUnit:    t.TriggerUnit,
+		PairName:       o.PairName,
+		OrderHash:      o.Hash,
+		UserAddress:    o.UserAddress,
+		Message:        message,
+		CreatedAt:      time.Now(),
+	}
+}
+
+// Check returns the alerts about the orders of the trade filled at a price worse than their
+// limit price or charged more than their signed matcher fee. The priority of the maker order
+// depends on the orderbook and is checked by the watchdog service.
+func (t *MatcherTrade) Check(maker, taker *Order) []*MatcherAlert {
+	alerts := []*MatcherAlert{}
+
+	for _, o := range []*Order{maker, taker} {
+		if alert := t.checkLimitPrice(o); alert != nil {
+			alerts = append(alerts, alert)
+		}
+	}
+
+	if alert := t.checkFee(maker, t.MakerFee); alert != nil {
+		alerts = append(alerts, alert)
+	}
+
+	if alert := t.checkFee(taker, t.TakerFee); alert != nil {
+		alerts = append(alerts, alert)
+	}
+
+	return alerts
+}
+
+// checkLimitPrice compares the quote amount of the trade with the quote amount due at the limit
+// price of the order, allowing for its rounding to an integer amount
+func (t *MatcherTrade) checkLimitPrice(o *Order) *MatcherAlert {
+	due := MulPrice(t.Amount, o.Price)
+
+	if o.Side == "SELL" && t.QuoteAmount < due-1 {
+		message := fmt.Sprintf("Sold %v for %v instead of at least %v at the limit price %v", t.Amount, t.QuoteAmount, due, o.Price)
+		return NewMatcherAlert(LimitPriceViolationAlert, t, o, message)
+	}
+
+	if o.Side == "BUY" && t.QuoteAmount > due+1 {
+		message := fmt.Sprintf("Bought %v for %v instead of at most %v at the limit price %v", t.Amount, t.QuoteAmount, due, o.Price)
+		return NewMatcherAlert(LimitPriceViolationAlert, t, o, message)
+	}
+
+	return nil
+}
+
+// checkFee compares the fee charged to an order with its signed matcher fee, prorated to the
+// amount it sold in the trade
+func (t *MatcherTrade) checkFee(o *Order, fee int64) *MatcherAlert {
+	signedMessage, ok := o.OriginalOrder["signed_message"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	matcherFee, _ := signedMessage["matcher_fee"].(float64)
+	sellAmount, _ := signedMessage["sell_amount"].(float64)
+	if sellAmount <= 0 {
+		return nil
+	}
+
+	sold := t.Amount
+	if o.Side == "BUY" {
+		sold = t.QuoteAmount
+	}
+
+	maxFee := int64(math.Ceil(matcherFee * float64(sold) / sellAmount))
+	if fee > maxFee {
+		message := fmt.Sprintf("Charged a fee of %v instead of at most %v", fee, maxFee)
+		return NewMatcherAlert(FeeOverchargeAlert, t, o, message)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatcherTradeCheck(t *testing.T) {
+	maker := &Order{
+		Hash:        "maker",
+		UserAddress: "SELLER",
+		PairName:    "GBYTE/USDC",
+		Side:        "SELL",
+		Price:       NewDecimal(2.5),
+		OriginalOrder: map[string]interface{}{
+			"signed_message": map[string]interface{}{"sell_amount": float64(1000), "matcher_fee": float64(10)},
+		},
+	}
+
+	taker := &Order{
+		Hash:        "taker",
+		UserAddress: "BUYER",
+		PairName:    "GBYTE/USDC",
+		Side:        "BUY",
+		Price:       NewDecimal(2.6),
+		OriginalOrder: map[string]interface{}{
+			"signed_message": map[string]interface{}{"sell_amount": float64(2600), "matcher_fee": float64(26)},
+		},
+	}
+
+	trade := &MatcherTrade{
+		TriggerUnit:    "unit",
+		MatcherAddress: "MATCHER",
+		MakerOrderHash: "maker",
+		TakerOrderHash: "taker",
+		Amount:         100,
+		QuoteAmount:    250,
+		MakerFee:       1,
+		TakerFee:       3,
+	}
+
+	assert.Equal(t, 0, len(trade.Check(maker, taker)))
+
+	// the buyer pays more than their limit price
+	trade.QuoteAmount = 270
+	alerts := trade.Check(maker, taker)
+	assert.Equal(t, 1, len(alerts))
+	assert.Equal(t, LimitPriceViolationAlert, alerts[0].Type)
+	assert.Equal(t, "taker", alerts[0].OrderHash)
+	assert.Equal(t, "BUYER", alerts[0].UserAddress)
+	assert.Equal(t, "MATCHER", alerts[0].MatcherAddress)
+	assert.Equal(t, "unit", alerts[0].TriggerUnit)
+
+	// the seller gets less than their limit price and is overcharged
+	trade.QuoteAmount = 240
+	trade.MakerFee = 2
+	alerts = trade.Check(maker, taker)
+	assert.Equal(t, 2, len(alerts))
+	assert.Equal(t, LimitPriceViolationAlert, alerts[0].Type)
+	assert.Equal(t, "maker", alerts[0].OrderHash)
+	assert.Equal(t, FeeOverchargeAlert, alerts[1].Type)
+	assert.Equal(t, "maker", alerts[1].OrderHash)
+}
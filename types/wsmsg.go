@@ -59,6 +59,12 @@ type OrderMatchedPayload struct {
 	Matches *Matches `json:"matches"`
 }
 
+// OrderAmendedPayload is sent when an order replaced the amended order
+type OrderAmendedPayload struct {
+	Order        *Order `json:"order"`
+	AmendedOrder *Order `json:"amendedOrder"`
+}
+
 type SubscriptionPayload struct {
 	PairName   string `json:"pairName,omitempty"`
 	QuoteToken string `json:"quoteToken,omitempty"`
@@ -13,6 +13,16 @@ func WriteJSON(w http.ResponseWriter, code int, payload interface{}) {
 	Write(w, code, map[string]interface{}{"data": payload})
 }
 
+// WritePage writes a page of results like WriteJSON, with the cursor of the next page in the
+// given header. The header is omitted after the last page.
+func WritePage(w http.ResponseWriter, code int, payload interface{}, cursorHeader string, nextCursor string) {
+	if nextCursor != "" {
+		w.Header().Set(cursorHeader, nextCursor)
+	}
+
+	WriteJSON(w, code, payload)
+}
+
 func Write(w http.ResponseWriter, code int, payload interface{}) {
 	response, _ := json.Marshal(payload)
 	w.WriteHeader(code)
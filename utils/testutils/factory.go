@@ -3,7 +3,6 @@ package testutils
 import (
 	"fmt"
 	"log"
-	"math"
 	"time"
 
 	"github.com/byteball/odex-backend/types"
@@ -100,7 +99,7 @@ func (f *OrderFactory) NewOrder(baseToken string, quoteToken string, amount int6
 	o.MatcherAddress = f.Params.MatcherAddress
 	o.BaseToken = baseToken
 	o.QuoteToken = quoteToken
-	o.Price = float64(pricepoint)
+	o.Price = types.NewDecimal(float64(pricepoint))
 	o.Amount = amount
 	o.Status = "OPEN"
 	//o.Sign(f.Wallet)
@@ -116,7 +115,7 @@ func (f *OrderFactory) NewLargeOrder(baseToken string, quoteToken string, amount
 	o.BaseToken = baseToken
 	o.QuoteToken = quoteToken
 	o.Amount = amount
-	o.Price = pricepoint
+	o.Price = types.NewDecimal(pricepoint)
 	o.Status = "OPEN"
 	//o.Sign(f.Wallet)
 
@@ -169,12 +168,12 @@ func (f *OrderFactory) NewBuyOrder(pricepoint float64, value int64, filled ...in
 	o.Side = "BUY"
 
 	o.PairName = f.Pair.Name()
-	o.Price = float64(pricepoint)
+	o.Price = types.NewDecimal(float64(pricepoint))
 	o.CreatedAt = time.Now()
 
 	if filled == nil {
 		o.FilledAmount = 0
-		o.RemainingSellAmount = int64(math.Round(float64(o.Amount) * o.Price))
+		o.RemainingSellAmount = types.MulPrice(o.Amount, o.Price)
 		o.Status = "OPEN"
 	} else if value == filled[0] {
 		o.FilledAmount = o.Amount
@@ -183,7 +182,7 @@ func (f *OrderFactory) NewBuyOrder(pricepoint float64, value int64, filled ...in
 	} else {
 		filledPoints := int64(filled[0] * 100)
 		o.FilledAmount = (etherPoints * filledPoints) / 100
-		o.RemainingSellAmount = int64(math.Round(float64(o.Amount-o.FilledAmount) * o.Price))
+		o.RemainingSellAmount = types.MulPrice(o.Amount-o.FilledAmount, o.Price)
 		o.Status = "PARTIAL_FILLED"
 	}
 
@@ -218,7 +217,7 @@ func (f *OrderFactory) NewSellOrder(pricepoint float64, value int64, filled ...i
 	o.QuoteToken = f.Pair.QuoteAsset
 	o.Side = "SELL"
 
-	o.Price = float64(pricepoint)
+	o.Price = types.NewDecimal(float64(pricepoint))
 	o.CreatedAt = time.Now()
 	o.PairName = f.Pair.Name()
 
@@ -31,7 +31,7 @@ func TestNewOrderFromFactory(t *testing.T) {
 		Amount:         1,
 		Hash:           order.Hash,
 		Status:         "OPEN",
-		Price:          1,
+		Price:          types.NewDecimal(1),
 	}
 
 	Compare(t, expected, order)
@@ -60,7 +60,7 @@ func TestNewFactoryBuyOrder(t *testing.T) {
 		BaseToken:           ZRX,
 		QuoteToken:          WETH,
 		FilledAmount:        0,
-		Price:               50,
+		Price:               types.NewDecimal(50),
 		Amount:              2,
 		RemainingSellAmount: 100,
 		Side:                "BUY",
@@ -99,7 +99,7 @@ func TestNewFactorySellOrder1(t *testing.T) {
 		Status:              "OPEN",
 		PairName:            "ZRX/WETH",
 		Hash:                order.Hash,
-		Price:               100,
+		Price:               types.NewDecimal(100),
 		Amount:              1,
 		RemainingSellAmount: 1,
 	}
@@ -134,7 +134,7 @@ func TestNewFactorySellOrder2(t *testing.T) {
 		Status:              "OPEN",
 		PairName:            "ZRX/WETH",
 		Hash:                order.Hash,
-		Price:               250,
+		Price:               types.NewDecimal(250),
 		Amount:              10,
 		RemainingSellAmount: 10,
 	}
@@ -166,7 +166,7 @@ func TestNewWebSocketMessage(t *testing.T) {
 		QuoteToken:     WETH,
 		Amount:         1,
 		Status:         "OPEN",
-		Price:          1,
+		Price:          types.NewDecimal(1),
 		Hash:           order.Hash,
 	}
 
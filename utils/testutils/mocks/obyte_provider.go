@@ -33,6 +33,27 @@ func (_m *ObyteProvider) AddOrder(signedOrder *interface{}) (string, error) {
 	return r0, r1
 }
 
+// AmendOrder provides a mock function with given fields: signedAmend
+func (_m *ObyteProvider) AmendOrder(signedAmend *interface{}) (string, error) {
+	ret := _m.Called(signedAmend)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(*interface{}) string); ok {
+		r0 = rf(signedAmend)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*interface{}) error); ok {
+		r1 = rf(signedAmend)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Asset provides a mock function with given fields: symbol
 func (_m *ObyteProvider) Asset(symbol string) (string, error) {
 	ret := _m.Called(symbol)
@@ -75,6 +96,20 @@ func (_m *ObyteProvider) BalanceOf(owner string, token string) (int64, error) {
 	return r0, r1
 }
 
+// CancelAllOrders provides a mock function with given fields: signedCancel
+func (_m *ObyteProvider) CancelAllOrders(signedCancel *interface{}) error {
+	ret := _m.Called(signedCancel)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*interface{}) error); ok {
+		r0 = rf(signedCancel)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // CancelOrder provides a mock function with given fields: signedCancel
 func (_m *ObyteProvider) CancelOrder(signedCancel *interface{}) error {
 	ret := _m.Called(signedCancel)
@@ -89,6 +124,20 @@ func (_m *ObyteProvider) CancelOrder(signedCancel *interface{}) error {
 	return r0
 }
 
+// ConnectionState provides a mock function with given fields:
+func (_m *ObyteProvider) ConnectionState() types.NodeConnectionState {
+	ret := _m.Called()
+
+	var r0 types.NodeConnectionState
+	if rf, ok := ret.Get(0).(func() types.NodeConnectionState); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(types.NodeConnectionState)
+	}
+
+	return r0
+}
+
 // Decimals provides a mock function with given fields: token
 func (_m *ObyteProvider) Decimals(token string) (uint8, error) {
 	ret := _m.Called(token)
@@ -133,6 +182,29 @@ func (_m *ObyteProvider) ExecuteTrade(m *types.Matches) ([]string, error) {
 	return r0, r1
 }
 
+// ExecuteTrades provides a mock function with given fields: batch
+func (_m *ObyteProvider) ExecuteTrades(batch []*types.Matches) ([]string, error) {
+	ret := _m.Called(batch)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func([]*types.Matches) []string); ok {
+		r0 = rf(batch)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]*types.Matches) error); ok {
+		r1 = rf(batch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetAuthorizedAddresses provides a mock function with given fields: address
 func (_m *ObyteProvider) GetAuthorizedAddresses(address string) ([]string, error) {
 	ret := _m.Called(address)
@@ -207,6 +279,29 @@ func (_m *ObyteProvider) GetOperatorAddress() string {
 	return r0
 }
 
+// GetTriggerUnitStatus provides a mock function with given fields: unit
+func (_m *ObyteProvider) GetTriggerUnitStatus(unit string) (*types.TriggerUnitStatus, error) {
+	ret := _m.Called(unit)
+
+	var r0 *types.TriggerUnitStatus
+	if rf, ok := ret.Get(0).(func(string) *types.TriggerUnitStatus); ok {
+		r0 = rf(unit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.TriggerUnitStatus)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(unit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListenToEvents provides a mock function with given fields:
 func (_m *ObyteProvider) ListenToEvents() (chan map[string]interface{}, error) {
 	ret := _m.Called()
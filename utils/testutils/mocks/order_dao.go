@@ -129,6 +129,27 @@ func (_m *OrderDao) FindAndModify(h string, o *types.Order) (*types.Order, error
 	return r0, r1
 }
 
+// GetBestPrice provides a mock function with given fields: p, side, matcherAddress
+func (_m *OrderDao) GetBestPrice(p *types.Pair, side string, matcherAddress string) (types.Decimal, error) {
+	ret := _m.Called(p, side, matcherAddress)
+
+	var r0 types.Decimal
+	if rf, ok := ret.Get(0).(func(*types.Pair, string, string) types.Decimal); ok {
+		r0 = rf(p, side, matcherAddress)
+	} else {
+		r0 = ret.Get(0).(types.Decimal)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.Pair, string, string) error); ok {
+		r1 = rf(p, side, matcherAddress)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetByHash provides a mock function with given fields: h
 func (_m *OrderDao) GetByHash(h string) (*types.Order, error) {
 	ret := _m.Called(h)
@@ -334,6 +355,29 @@ func (_m *OrderDao) GetHistoryByUserAddress(a string, limit ...int) ([]*types.Or
 	return r0, r1
 }
 
+// GetHistoryPageByUserAddress provides a mock function with given fields: q
+func (_m *OrderDao) GetHistoryPageByUserAddress(q *types.HistoryQuery) ([]*types.Order, error) {
+	ret := _m.Called(q)
+
+	var r0 []*types.Order
+	if rf, ok := ret.Get(0).(func(*types.HistoryQuery) []*types.Order); ok {
+		r0 = rf(q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.HistoryQuery) error); ok {
+		r1 = rf(q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetMatchingBuyOrders provides a mock function with given fields: o
 func (_m *OrderDao) GetMatchingBuyOrders(o *types.Order) ([]*types.Order, error) {
 	ret := _m.Called(o)
@@ -413,31 +457,61 @@ func (_m *OrderDao) GetOrderBook(_a0 *types.Pair) ([]map[string]interface{}, []m
 }
 
 // GetOrderBookPrice provides a mock function with given fields: p, pp, side
-func (_m *OrderDao) GetOrderBookPrice(p *types.Pair, pp float64, side string) (int64, string, error) {
+func (_m *OrderDao) GetOrderBookPrice(p *types.Pair, pp types.Decimal, side string) (int64, string, float64, error) {
 	ret := _m.Called(p, pp, side)
 
 	var r0 int64
-	if rf, ok := ret.Get(0).(func(*types.Pair, float64, string) int64); ok {
+	if rf, ok := ret.Get(0).(func(*types.Pair, types.Decimal, string) int64); ok {
 		r0 = rf(p, pp, side)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
 
 	var r1 string
-	if rf, ok := ret.Get(1).(func(*types.Pair, float64, string) string); ok {
+	if rf, ok := ret.Get(1).(func(*types.Pair, types.Decimal, string) string); ok {
 		r1 = rf(p, pp, side)
 	} else {
 		r1 = ret.Get(1).(string)
 	}
 
-	var r2 error
-	if rf, ok := ret.Get(2).(func(*types.Pair, float64, string) error); ok {
+	var r2 float64
+	if rf, ok := ret.Get(2).(func(*types.Pair, types.Decimal, string) float64); ok {
 		r2 = rf(p, pp, side)
 	} else {
-		r2 = ret.Error(2)
+		r2 = ret.Get(2).(float64)
 	}
 
-	return r0, r1, r2
+	var r3 error
+	if rf, ok := ret.Get(3).(func(*types.Pair, types.Decimal, string) error); ok {
+		r3 = rf(p, pp, side)
+	} else {
+		r3 = ret.Error(3)
+	}
+
+	return r0, r1, r2, r3
+}
+
+// GetPageByUserAddress provides a mock function with given fields: q
+func (_m *OrderDao) GetPageByUserAddress(q *types.HistoryQuery) ([]*types.Order, error) {
+	ret := _m.Called(q)
+
+	var r0 []*types.Order
+	if rf, ok := ret.Get(0).(func(*types.HistoryQuery) []*types.Order); ok {
+		r0 = rf(q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.HistoryQuery) error); ok {
+		r1 = rf(q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
 }
 
 // GetRawOrderBook provides a mock function with given fields: _a0
@@ -463,6 +537,29 @@ func (_m *OrderDao) GetRawOrderBook(_a0 *types.Pair) ([]*types.Order, error) {
 	return r0, r1
 }
 
+// GetUntriggeredOrders provides a mock function with given fields: p
+func (_m *OrderDao) GetUntriggeredOrders(p *types.Pair) ([]*types.Order, error) {
+	ret := _m.Called(p)
+
+	var r0 []*types.Order
+	if rf, ok := ret.Get(0).(func(*types.Pair) []*types.Order); ok {
+		r0 = rf(p)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Order)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.Pair) error); ok {
+		r1 = rf(p)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetUserLockedBalance provides a mock function with given fields: account, token
 func (_m *OrderDao) GetUserLockedBalance(account string, token string) (int64, []*types.Order, error) {
 	ret := _m.Called(account, token)
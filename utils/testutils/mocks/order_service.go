@@ -7,7 +7,11 @@ import (
 
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	types "github.com/byteball/odex-backend/types"
+
+	ws "github.com/byteball/odex-backend/ws"
 )
 
 // OrderService is an autogenerated mock type for the OrderService type
@@ -31,6 +35,57 @@ func (_m *OrderService) AdjustBalancesForUncommittedTrades(address string, balan
 	return r0
 }
 
+// AmendOrder provides a mock function with given fields: o
+func (_m *OrderService) AmendOrder(o *types.Order) error {
+	ret := _m.Called(o)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*types.Order) error); ok {
+		r0 = rf(o)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// AutoCancelOrders provides a mock function with given fields: address
+func (_m *OrderService) AutoCancelOrders(address string) error {
+	ret := _m.Called(address)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(address)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CancelAllOrders provides a mock function with given fields: oca
+func (_m *OrderService) CancelAllOrders(oca *types.OrderCancelAll) (*types.OrderCancelAllSummary, error) {
+	ret := _m.Called(oca)
+
+	var r0 *types.OrderCancelAllSummary
+	if rf, ok := ret.Get(0).(func(*types.OrderCancelAll) *types.OrderCancelAllSummary); ok {
+		r0 = rf(oca)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*types.OrderCancelAllSummary)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.OrderCancelAll) error); ok {
+		r1 = rf(oca)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CancelExpiredOrders provides a mock function with given fields:
 func (_m *OrderService) CancelExpiredOrders() {
 	_m.Called()
@@ -60,6 +115,16 @@ func (_m *OrderService) CheckIfBalancesAreSufficientAndCancel(address string, ba
 	_m.Called(address, balances)
 }
 
+// DisableCancelOnDisconnect provides a mock function with given fields: c, address
+func (_m *OrderService) DisableCancelOnDisconnect(c *ws.Client, address string) {
+	_m.Called(c, address)
+}
+
+// EnableCancelOnDisconnect provides a mock function with given fields: c, address, timeout
+func (_m *OrderService) EnableCancelOnDisconnect(c *ws.Client, address string, timeout time.Duration) {
+	_m.Called(c, address, timeout)
+}
+
 // FixOrderStatus provides a mock function with given fields: o
 func (_m *OrderService) FixOrderStatus(o *types.Order) {
 	_m.Called(o)
@@ -224,6 +289,66 @@ func (_m *OrderService) GetHistoryByUserAddress(a string, limit ...int) ([]*type
 	return r0, r1
 }
 
+// GetHistoryPageByUserAddress provides a mock function with given fields: q
+func (_m *OrderService) GetHistoryPageByUserAddress(q *types.HistoryQuery) ([]*types.Order, string, error) {
+	ret := _m.Called(q)
+
+	var r0 []*types.Order
+	if rf, ok := ret.Get(0).(func(*types.HistoryQuery) []*types.Order); ok {
+		r0 = rf(q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Order)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(*types.HistoryQuery) string); ok {
+		r1 = rf(q)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(*types.HistoryQuery) error); ok {
+		r2 = rf(q)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetPageByUserAddress provides a mock function with given fields: q
+func (_m *OrderService) GetPageByUserAddress(q *types.HistoryQuery) ([]*types.Order, string, error) {
+	ret := _m.Called(q)
+
+	var r0 []*types.Order
+	if rf, ok := ret.Get(0).(func(*types.HistoryQuery) []*types.Order); ok {
+		r0 = rf(q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Order)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(*types.HistoryQuery) string); ok {
+		r1 = rf(q)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(*types.HistoryQuery) error); ok {
+		r2 = rf(q)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetSenderAddresses provides a mock function with given fields: oc
 func (_m *OrderService) GetSenderAddresses(oc *types.OrderCancel) (string, string, error) {
 	ret := _m.Called(oc)
@@ -266,6 +391,20 @@ func (_m *OrderService) HandleEngineResponse(res *types.EngineResponse) error {
 	return r0
 }
 
+// Heartbeat provides a mock function with given fields: c, address
+func (_m *OrderService) Heartbeat(c *ws.Client, address string) bool {
+	ret := _m.Called(c, address)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*ws.Client, string) bool); ok {
+		r0 = rf(c, address)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // NewOrder provides a mock function with given fields: o
 func (_m *OrderService) NewOrder(o *types.Order) error {
 	ret := _m.Called(o)
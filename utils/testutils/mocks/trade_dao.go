@@ -362,6 +362,29 @@ func (_m *TradeDao) GetNTradesByPairAssets(bt string, qt string, n int) ([]*type
 	return r0, r1
 }
 
+// GetPageByUserAddress provides a mock function with given fields: q
+func (_m *TradeDao) GetPageByUserAddress(q *types.HistoryQuery) ([]*types.Trade, error) {
+	ret := _m.Called(q)
+
+	var r0 []*types.Trade
+	if rf, ok := ret.Get(0).(func(*types.HistoryQuery) []*types.Trade); ok {
+		r0 = rf(q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Trade)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(*types.HistoryQuery) error); ok {
+		r1 = rf(q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetSortedTrades provides a mock function with given fields: bt, qt, n
 func (_m *TradeDao) GetSortedTrades(bt string, qt string, n int) ([]*types.Trade, error) {
 	ret := _m.Called(bt, qt, n)
@@ -454,6 +477,50 @@ func (_m *TradeDao) GetUncommittedTradesByUserAddress(a string) []*types.Trade {
 	return r0
 }
 
+// GetUnsettledTrades provides a mock function with given fields: before
+func (_m *TradeDao) GetUnsettledTrades(before time.Time) ([]*types.Trade, error) {
+	ret := _m.Called(before)
+
+	var r0 []*types.Trade
+	if rf, ok := ret.Get(0).(func(time.Time) []*types.Trade); ok {
+		r0 = rf(before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Trade)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetUserVolume provides a mock function with given fields: address, pairName, since
+func (_m *TradeDao) GetUserVolume(address string, pairName string, since time.Time) (int64, error) {
+	ret := _m.Called(address, pairName, since)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(string, string, time.Time) int64); ok {
+		r0 = rf(address, pairName, since)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, time.Time) error); ok {
+		r1 = rf(address, pairName, since)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: t
 func (_m *TradeDao) Update(t *types.Trade) error {
 	ret := _m.Called(t)
@@ -6,6 +6,8 @@ import (
 	types "github.com/byteball/odex-backend/types"
 	mock "github.com/stretchr/testify/mock"
 
+	time "time"
+
 	ws "github.com/byteball/odex-backend/ws"
 )
 
@@ -221,6 +223,36 @@ func (_m *TradeService) GetByUserAddress(a string) ([]*types.Trade, error) {
 	return r0, r1
 }
 
+// GetPageByUserAddress provides a mock function with given fields: q
+func (_m *TradeService) GetPageByUserAddress(q *types.HistoryQuery) ([]*types.Trade, string, error) {
+	ret := _m.Called(q)
+
+	var r0 []*types.Trade
+	if rf, ok := ret.Get(0).(func(*types.HistoryQuery) []*types.Trade); ok {
+		r0 = rf(q)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Trade)
+		}
+	}
+
+	var r1 string
+	if rf, ok := ret.Get(1).(func(*types.HistoryQuery) string); ok {
+		r1 = rf(q)
+	} else {
+		r1 = ret.Get(1).(string)
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(*types.HistoryQuery) error); ok {
+		r2 = rf(q)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetSortedTrades provides a mock function with given fields: bt, qt, n
 func (_m *TradeService) GetSortedTrades(bt string, qt string, n int) ([]*types.Trade, error) {
 	ret := _m.Called(bt, qt, n)
@@ -274,6 +306,29 @@ func (_m *TradeService) GetSortedTradesByUserAddress(a string, limit ...int) ([]
 	return r0, r1
 }
 
+// GetUnsettledTrades provides a mock function with given fields: before
+func (_m *TradeService) GetUnsettledTrades(before time.Time) ([]*types.Trade, error) {
+	ret := _m.Called(before)
+
+	var r0 []*types.Trade
+	if rf, ok := ret.Get(0).(func(time.Time) []*types.Trade); ok {
+		r0 = rf(before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*types.Trade)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(before)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Subscribe provides a mock function with given fields: c, bt, qt
 func (_m *TradeService) Subscribe(c *ws.Client, bt string, qt string) {
 	_m.Called(c, bt, qt)
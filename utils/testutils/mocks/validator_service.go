@@ -40,6 +40,20 @@ func (_m *ValidatorService) ValidateBalance(o *types.Order) error {
 	return r0
 }
 
+// ValidateMatcherFee provides a mock function with given fields: o
+func (_m *ValidatorService) ValidateMatcherFee(o *types.Order) error {
+	ret := _m.Called(o)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*types.Order) error); ok {
+		r0 = rf(o)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ValidateOperatorAddress provides a mock function with given fields: o
 func (_m *ValidatorService) ValidateOperatorAddress(o *types.Order) error {
 	ret := _m.Called(o)
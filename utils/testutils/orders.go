@@ -14,7 +14,7 @@ func GetTestOrder1() types.Order {
 		MatcherAddress: "0xae55690d4b079460e6ac28aaa58c9ec7b73a7485",
 		BaseToken:      "0xe41d2489571d322189246dafa5ebde1f4699f498",
 		QuoteToken:     "0x12459c951127e0c374ff9105dda097662a027093",
-		Price:          1000,
+		Price:          types.NewDecimal(1000),
 		Amount:         1000,
 		FilledAmount:   100,
 		Status:         "OPEN",
@@ -33,7 +33,7 @@ func GetTestOrder2() types.Order {
 		MatcherAddress: "0xae55690d4b079460e6ac28aaa58c9ec7b73a7485",
 		BaseToken:      "0x4bc89ac6f1c55ea645294f3fed949813a768ac6d",
 		QuoteToken:     "0xd27a76b12bc4a870c1045c86844161337393d9fa",
-		Price:          1200,
+		Price:          types.NewDecimal(1200),
 		Amount:         1000,
 		FilledAmount:   100,
 		Status:         "OPEN",
@@ -52,7 +52,7 @@ func GetTestOrder3() types.Order {
 		MatcherAddress: "0xae55690d4b079460e6ac28aaa58c9ec7b73a7485",
 		BaseToken:      "0x4bc89ac6f1c55ea645294f3fed949813a768ac6d",
 		QuoteToken:     "0xd27a76b12bc4a870c1045c86844161337393d9fa",
-		Price:          1200,
+		Price:          types.NewDecimal(1200),
 		Amount:         1000,
 		FilledAmount:   100,
 		Status:         "OPEN",
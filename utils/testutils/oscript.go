@@ -0,0 +1,104 @@
+package testutils
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// The functions below are a reference model of the oscript arithmetic used by the exchange AA.
+// They work on decimal digit strings, the way the decimal library of oscript does, and are
+// independent from types.Decimal so that the engine results can be checked against them.
+
+// oscriptNumber is the number digits * 10^exp
+type oscriptNumber struct {
+	digits *big.Int
+	exp    int
+}
+
+// oscriptFloat returns the number oscript reads from a float: its 15 significant digits
+func oscriptFloat(f float64) oscriptNumber {
+	s := strconv.FormatFloat(f, 'e', 14, 64)
+	parts := strings.Split(s, "e")
+	exp, _ := strconv.Atoi(parts[1])
+	digits, _ := new(big.Int).SetString(strings.Replace(parts[0], ".", "", 1), 10)
+
+	return oscriptNumber{digits, exp - 14}
+}
+
+// roundDigits rounds a digit string to n digits, ties to even. sticky tells whether nonzero
+// digits were dropped before. It returns the rounded digits and the number of digits removed.
+func roundDigits(s string, n int, sticky bool) (*big.Int, int) {
+	if len(s) <= n {
+		d, _ := new(big.Int).SetString(s, 10)
+		return d, 0
+	}
+
+	kept, _ := new(big.Int).SetString(s[:n], 10)
+	if n == 0 {
+		kept = new(big.Int)
+	}
+
+	dropped := s[n:]
+	first := dropped[0]
+	rest := strings.TrimRight(dropped[1:], "0") != "" || sticky
+
+	up := first > '5' || (first == '5' && rest) || (first == '5' && !rest && kept.Bit(0) == 1)
+	if up {
+		kept.Add(kept, big.NewInt(1))
+	}
+
+	return kept, len(dropped)
+}
+
+// significant rounds a non-negative number to 15 significant digits
+func (x oscriptNumber) significant(sticky bool) oscriptNumber {
+	digits, removed := roundDigits(x.digits.String(), 15, sticky)
+	return oscriptNumber{digits, x.exp + removed}
+}
+
+// round rounds a non-negative number to an integer, ties away from zero
+func (x oscriptNumber) round() int64 {
+	if x.exp >= 0 {
+		pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(x.exp)), nil)
+		return new(big.Int).Mul(x.digits, pow).Int64()
+	}
+
+	s := x.digits.String()
+	n := len(s) + x.exp
+	if n < 0 {
+		return 0
+	}
+
+	kept := new(big.Int)
+	if n > 0 {
+		kept.SetString(s[:n], 10)
+	}
+
+	if s[n] >= '5' {
+		kept.Add(kept, big.NewInt(1))
+	}
+
+	return kept.Int64()
+}
+
+// OscriptMul returns round(amount * price) as computed by the exchange AA
+func OscriptMul(amount int64, price float64) int64 {
+	p := oscriptFloat(price)
+	product := oscriptNumber{new(big.Int).Mul(big.NewInt(amount), p.digits), p.exp}
+
+	return product.significant(false).round()
+}
+
+// OscriptDiv returns round(amount / price) as computed by the exchange AA
+func OscriptDiv(amount int64, price float64) int64 {
+	p := oscriptFloat(price)
+
+	// long division with 20 more digits than needed, the remainder only matters for ties
+	scale := 40
+	num := new(big.Int).Mul(big.NewInt(amount), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil))
+	q, r := new(big.Int).QuoRem(num, p.digits, new(big.Int))
+
+	quotient := oscriptNumber{q, -scale - p.exp}
+	return quotient.significant(r.Sign() != 0).round()
+}
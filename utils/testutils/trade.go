@@ -16,7 +16,7 @@ func GetTestTrade1() types.Trade {
 		MakerOrderHash: "0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff",
 		TakerOrderHash: "0x6d9ad89548c9e3ce4c97825d027291477f2c44a8caef792095f2cabc978493ff",
 		PairName:       "ZRX/WETH",
-		Price:          10000000,
+		Price:          types.NewDecimal(10000000),
 		Amount:         100,
 	}
 }
@@ -32,7 +32,7 @@ func GetTestTrade2() types.Trade {
 		MakerOrderHash: "0x400558b2f5a7b20dd06241c2313c08f652b297e819926b5a51a5abbc60f451e6",
 		TakerOrderHash: "0x400558b2f5a7b20dd06241c2313c08f652b297e819926b5a51a5abbc60f451e6",
 		PairName:       "ZRX/DAI",
-		Price:          10000000,
+		Price:          types.NewDecimal(10000000),
 		Amount:         100,
 	}
 }
@@ -13,6 +13,7 @@ const (
 	OHLCVChannel        = "ohlcv"
 	LoginChannel        = "login"
 	BalancesChannel     = "balances"
+	MatcherAlertChannel = "matcher_alerts"
 )
 
 var socketChannels map[string]func(interface{}, *Client)
@@ -1,24 +1,46 @@
 package ws
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
 	"errors"
+	"time"
+
 	sync "github.com/sasha-s/go-deadlock"
 )
 
 var loginSocket *LoginSocket
 
+// loginSessionLifetime is the time after which the logins not claimed are forgotten
+const loginSessionLifetime = time.Hour
+
 // LoginSocket holds the map of connections subscribed to session ids
-// corresponding to the key/event they have subscribed to.
+// corresponding to the key/event they have subscribed to, the addresses
+// logged in to the sessions and the addresses logged in on each connection.
 type LoginSocket struct {
 	subscriptions     map[string]map[*Client]bool
 	subscriptionsList map[*Client][]string
+	sessions          map[string]*loginSession
+	loggedIn          map[*Client]map[string]bool
 	mu                sync.Mutex
 }
 
+// loginSession is the address logged in to a session, the time of the login and the token
+// sent to the connections subscribed to the session at that time. Knowing the session id is not
+// enough to claim a login, the token must be presented too.
+type loginSession struct {
+	address    string
+	token      string
+	loggedInAt time.Time
+}
+
 func NewLoginSocket() *LoginSocket {
 	return &LoginSocket{
 		subscriptions:     make(map[string]map[*Client]bool),
 		subscriptionsList: make(map[*Client][]string),
+		sessions:          make(map[string]*loginSession),
+		loggedIn:          make(map[*Client]map[string]bool),
 		mu:                sync.Mutex{},
 	}
 }
@@ -116,13 +138,89 @@ func (s *LoginSocket) LinkAddressToClient(sessionId string, address string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	s.pruneSessions(loginSessionLifetime)
+
+	token, err := newSessionToken()
+	if err != nil {
+		logger.Error(err)
+	} else {
+		s.sessions[sessionId] = &loginSession{address, token, time.Now()}
+	}
+
 	for conn, active := range loginSocket.subscriptions[sessionId] {
 		if active {
 			RegisterOrderConnection(address, conn)
+
+			if token != "" {
+				s.SendMessage(conn, "TOKEN", map[string]string{"sessionId": sessionId, "token": token})
+			}
+
+			if s.loggedIn[conn] == nil {
+				s.loggedIn[conn] = make(map[string]bool)
+				RegisterConnectionUnsubscribeHandler(conn, s.logoutHandler())
+			}
+
+			s.loggedIn[conn][address] = true
+		}
+	}
+}
+
+func (s *LoginSocket) logoutHandler() func(c *Client) {
+	return func(c *Client) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.loggedIn, c)
+	}
+}
+
+// IsClientLoggedIn tells whether an address logged in with its wallet on a connection
+func (s *LoginSocket) IsClientLoggedIn(address string, c *Client) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.loggedIn[c][address]
+}
+
+// ClaimSession returns the address logged in to a session no longer than maxAge ago, and forgets
+// the session so that it can be claimed only once. The token must be the one sent with the TOKEN
+// message to the connections subscribed to the session. It returns an empty string when there is
+// no such login.
+func (s *LoginSocket) ClaimSession(sessionId string, token string, maxAge time.Duration) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneSessions(maxAge)
+
+	session := s.sessions[sessionId]
+	if session == nil || subtle.ConstantTimeCompare([]byte(token), []byte(session.token)) != 1 {
+		return ""
+	}
+
+	delete(s.sessions, sessionId)
+	return session.address
+}
+
+// pruneSessions forgets the logins older than maxAge. The mutex must be held.
+func (s *LoginSocket) pruneSessions(maxAge time.Duration) {
+	for id, session := range s.sessions {
+		if time.Since(session.loggedInAt) > maxAge {
+			delete(s.sessions, id)
 		}
 	}
 }
 
+// newSessionToken returns a random token for the login of a session
+func newSessionToken() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
 // BroadcastMessage broadcasts login message to all subscribed sockets
 func (s *LoginSocket) SendMessageBySession(sessionId string, p interface{}) {
 	s.mu.Lock()
@@ -0,0 +1,85 @@
+package ws
+
+import (
+	"errors"
+
+	sync "github.com/sasha-s/go-deadlock"
+)
+
+var matcherAlertSocket *MatcherAlertSocket
+
+// MatcherAlertSocket holds the connections subscribed to the alerts about the misbehaviour
+// of the matchers
+type MatcherAlertSocket struct {
+	subscriptions map[*Client]bool
+	mu            sync.Mutex
+}
+
+func NewMatcherAlertSocket() *MatcherAlertSocket {
+	return &MatcherAlertSocket{
+		subscriptions: make(map[*Client]bool),
+		mu:            sync.Mutex{},
+	}
+}
+
+// GetMatcherAlertSocket returns the singleton instance of MatcherAlertSocket
+func GetMatcherAlertSocket() *MatcherAlertSocket {
+	if matcherAlertSocket == nil {
+		matcherAlertSocket = NewMatcherAlertSocket()
+	}
+
+	return matcherAlertSocket
+}
+
+// Subscribe registers a websocket connection to the matcher alerts
+func (s *MatcherAlertSocket) Subscribe(c *Client) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c == nil {
+		return errors.New("No connection found")
+	}
+
+	s.subscriptions[c] = true
+
+	return nil
+}
+
+func (s *MatcherAlertSocket) UnsubscribeHandler() func(c *Client) {
+	return func(c *Client) {
+		s.Unsubscribe(c)
+	}
+}
+
+// Unsubscribe removes a websocket connection from the matcher alerts
+func (s *MatcherAlertSocket) Unsubscribe(c *Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subscriptions, c)
+}
+
+// BroadcastMessage sends an alert to all the subscribed connections
+func (s *MatcherAlertSocket) BroadcastMessage(p interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.subscriptions {
+		s.SendUpdateMessage(c, p)
+	}
+}
+
+// SendErrorMessage sends an error message on the matcher alert channel
+func (s *MatcherAlertSocket) SendErrorMessage(c *Client, p interface{}) {
+	go c.SendMessage(MatcherAlertChannel, "ERROR", p)
+}
+
+// SendInitMessage sends the latest alerts on the matcher alert channel at subscription
+func (s *MatcherAlertSocket) SendInitMessage(c *Client, p interface{}) {
+	go c.SendMessage(MatcherAlertChannel, "INIT", p)
+}
+
+// SendUpdateMessage sends a new alert on the matcher alert channel
+func (s *MatcherAlertSocket) SendUpdateMessage(c *Client, p interface{}) {
+	go c.SendMessage(MatcherAlertChannel, "UPDATE", p)
+}